@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// adminActionInMatch is an admin action taken against a player in a single match.
+type adminActionInMatch struct {
+	Match  int    `json:"match"` // 1-indexed, as in the "game_N" keys
+	Map    string `json:"map"`
+	Time   int    `json:"time"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// adminReport is the output of the "admin" report, keyed by player name.
+type adminReport map[string][]adminActionInMatch
+
+// Table lists one action per row, sorted by player and then by match.
+func (ar adminReport) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"player", "match", "map", "time", "action", "reason"}}
+	for _, player := range sortedKeys(ar) {
+		for _, a := range ar[player] {
+			t.Rows = append(t.Rows, []string{
+				player, strconv.Itoa(a.Match), a.Map, strconv.Itoa(a.Time), a.Action, a.Reason,
+			})
+		}
+	}
+	return t
+}
+
+// buildAdminReport gathers the admin actions taken against every player over every match. It
+// needs the admin actions of the matches.
+func buildAdminReport(matches qlp.Matches) adminReport {
+	report := make(adminReport)
+	for i, match := range matches {
+		for _, action := range match.AdminActions {
+			report[action.Player] = append(report[action.Player], adminActionInMatch{
+				Match: i + 1, Map: match.MapName, Time: action.Time, Action: action.Action, Reason: action.Reason,
+			})
+		}
+	}
+	return report
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// Thresholds of the anomaly heuristics. They are deliberately generous: an anomaly is meant to
+// point an admin at a match worth watching, not to be proof of cheating.
+const (
+	// burstKills kills within burstWindow seconds are beyond what weapons allow, even with
+	// splash damage.
+	burstKills  = 6
+	burstWindow = 3
+
+	// railKills railgun kills, at distinct times, within railWindow seconds are faster than
+	// the railgun fires. It reloads in 1.5 seconds, so three shots span at least 3 logged
+	// seconds; kills at the same second may come from a single shot going through players.
+	railKills  = 3
+	railWindow = 3
+
+	// killRate kills per minute, sustained over a match with at least minRateKills kills
+	// and lasting at least a minute.
+	killRate     = 12.0
+	minRateKills = 20
+)
+
+// anomaly is a performance flagged by findAnomalies.
+type anomaly struct {
+	Match  int    `json:"match"` // 1-indexed, as in the "game_N" keys
+	Player string `json:"player"`
+	Kind   string `json:"kind"`
+	Time   int    `json:"time,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// anomalies is the output of the "anomalies" report.
+type anomalies []anomaly
+
+// Table lists one anomaly per row.
+func (list anomalies) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"match", "player", "kind", "time", "detail"}}
+	for _, a := range list {
+		t.Rows = append(t.Rows, []string{strconv.Itoa(a.Match), a.Player, a.Kind, strconv.Itoa(a.Time), a.Detail})
+	}
+	return t
+}
+
+// findAnomalies flags statistically implausible performances per player per match. It needs
+// the kill feed of the matches. Details are written according to loc.
+func findAnomalies(matches qlp.Matches, loc *locale) anomalies {
+	found := anomalies{}
+	for i, match := range matches {
+		killTimes := make(map[string][]int)
+		railTimes := make(map[string][]int)
+		for _, kill := range match.KillFeed {
+			if kill.Killer == "<world>" || kill.Killer == kill.Victim {
+				continue
+			}
+			killTimes[kill.Killer] = append(killTimes[kill.Killer], kill.Time)
+
+			rails := railTimes[kill.Killer]
+			if kill.Means == "MOD_RAILGUN" && (len(rails) == 0 || rails[len(rails)-1] != kill.Time) {
+				railTimes[kill.Killer] = append(rails, kill.Time)
+			}
+		}
+
+		for _, player := range match.Players {
+			times := killTimes[player]
+
+			if start, ok := findBurst(times, burstKills, burstWindow); ok {
+				found = append(found, anomaly{
+					Match: i + 1, Player: player, Kind: "kill_burst", Time: start,
+					Detail: loc.sprintf("%d kills within %d seconds", burstKills, burstWindow),
+				})
+			}
+			if start, ok := findBurst(railTimes[player], railKills, railWindow); ok {
+				found = append(found, anomaly{
+					Match: i + 1, Player: player, Kind: "instant_rail", Time: start,
+					Detail: loc.sprintf("%d railgun kills within %d seconds, faster than the railgun fires", railKills, railWindow),
+				})
+			}
+			if minutes := float64(match.Duration) / 60; minutes >= 1 && len(times) >= minRateKills {
+				if rate := float64(len(times)) / minutes; rate > killRate {
+					found = append(found, anomaly{
+						Match: i + 1, Player: player, Kind: "kill_rate",
+						Detail: loc.sprintf("%.1f kills per minute over %d kills", rate, len(times)),
+					})
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+// findBurst looks for count times, sorted in ascending order, within less than window
+// seconds of each other. It returns the first time of the first such burst.
+func findBurst(times []int, count, window int) (start int, ok bool) {
+	for i := 0; i+count-1 < len(times); i++ {
+		if times[i+count-1]-times[i] < window {
+			return times[i], true
+		}
+	}
+	return 0, false
+}
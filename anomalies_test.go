@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// anomaliesLog returns a fixture log of a two-minute match in which Isgalamido kills six
+// players within three seconds, Zeh kills three with the railgun faster than it fires,
+// Mocinha's two railgun kills in the same second come from a single shot, and Dono da Bola
+// kills steadily at 12.5 kills per minute.
+func anomaliesLog() string {
+	var log strings.Builder
+	log.WriteString("  0:00 InitGame: \\mapname\\q3dm17\n")
+	for kill := range 6 {
+		fmt.Fprintf(&log, "  0:%02d Kill: 2 4 7: Isgalamido killed Mocinha by MOD_ROCKET_SPLASH\n", 10+kill/2)
+	}
+	for second := 20; second < 23; second++ {
+		fmt.Fprintf(&log, "  0:%02d Kill: 3 2 10: Zeh killed Isgalamido by MOD_RAILGUN\n", second)
+	}
+	log.WriteString("  0:30 Kill: 4 2 10: Mocinha killed Isgalamido by MOD_RAILGUN\n")
+	log.WriteString("  0:30 Kill: 4 3 10: Mocinha killed Zeh by MOD_RAILGUN\n")
+	log.WriteString("  0:34 Kill: 4 3 10: Mocinha killed Zeh by MOD_RAILGUN\n")
+	for kill := range 25 {
+		second := 32 + kill*4
+		fmt.Fprintf(&log, "  %d:%02d Kill: 5 2 3: Dono da Bola killed Isgalamido by MOD_MACHINEGUN\n", second/60, second%60)
+	}
+	log.WriteString("  2:00 ShutdownGame:\n")
+	log.WriteString("  2:00 " + fixtureSeparator + "\n")
+	return log.String()
+}
+
+func TestFindAnomalies(t *testing.T) {
+	found := findAnomalies(parseFixture(t, anomaliesLog()), locales[defaultLocale])
+	assert.ElementsMatch(t, anomalies{
+		{Match: 1, Player: "Isgalamido", Kind: "kill_burst", Time: 10, Detail: "6 kills within 3 seconds"},
+		{Match: 1, Player: "Zeh", Kind: "instant_rail", Time: 20, Detail: "3 railgun kills within 3 seconds, faster than the railgun fires"},
+		{Match: 1, Player: "Dono da Bola", Kind: "kill_rate", Detail: "12.5 kills per minute over 25 kills"},
+	}, found)
+}
+
+func TestFindBurst(t *testing.T) {
+	start, ok := findBurst([]int{1, 5, 6, 7, 20}, 3, 3)
+	assert.True(t, ok)
+	assert.Equal(t, 5, start)
+	_, ok = findBurst([]int{1, 4, 7}, 3, 3)
+	assert.False(t, ok)
+	_, ok = findBurst([]int{1}, 3, 3)
+	assert.False(t, ok)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditEntry records the ingestion of a log file in the audit log.
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	Path     string    `json:"path"`
+	SHA256   string    `json:"sha256,omitempty"`
+	Bytes    int64     `json:"bytes"`
+	Matches  int       `json:"matches"`
+	Warnings int       `json:"warnings"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// auditLog appends an entry, as a line of JSON, for every log file ingested, so that
+// long-running deployments can prove what was processed. It is safe for concurrent use.
+type auditLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// openAuditLog opens the audit log at filePath, creating it if needed. Entries are appended to
+// those already there.
+func openAuditLog(filePath string) (*auditLog, error) {
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// record appends the entry, along with the error the ingestion ended with, if any. Failures
+// to write are reported on stderr, as they should not stop the ingestion.
+func (a *auditLog) record(entry *auditEntry, err error) {
+	entry.Time = time.Now().UTC()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.encoder.Encode(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %s\n", err)
+	}
+}
+
+// Close closes the audit log.
+func (a *auditLog) Close() error {
+	return a.file.Close()
+}
+
+// hashInput returns the size and the hex encoded SHA-256 hash of a log file's contents,
+// leaving it ready to be read from the start. It fails on pipes, which cannot be read twice.
+func hashInput(input logInput) (int64, string, error) {
+	size, err := input.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, "", err
+	}
+	if _, err := input.Seek(0, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(input, 0, size)); err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+	"github.com/urfave/cli/v2"
+)
+
+// maxBalancePlayers is the most players "balance" splits, as every split is tried.
+const maxBalancePlayers = 24
+
+// balance is the output of the "balance" subcommand.
+type balance struct {
+	// Splits holds the most balanced splits found, the most balanced first.
+	Splits []teamSplit `json:"splits"`
+	// Unrated lists the players who played no rated match in the logs, who are taken to have
+	// qlpstats.InitialRating.
+	Unrated []string `json:"unrated,omitempty"`
+}
+
+// teamSplit is a division of the players into two teams.
+type teamSplit struct {
+	Teams [2]balanceTeam `json:"teams"`
+	// Difference is how far apart the average ratings of the teams are.
+	Difference float64 `json:"difference"`
+}
+
+// balanceTeam is one of the teams of a split.
+type balanceTeam struct {
+	Players []string `json:"players"`
+	// Rating is the average rating of the players of the team.
+	Rating float64 `json:"rating"`
+}
+
+// Table lists one team of a split per row, in order of balance.
+func (b balance) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"split", "team", "players", "rating", "difference"}}
+	for i, split := range b.Splits {
+		for j, team := range split.Teams {
+			t.Rows = append(t.Rows, []string{
+				strconv.Itoa(i + 1), strconv.Itoa(j + 1), strings.Join(team.Players, ", "),
+				formatFloat(team.Rating), formatFloat(split.Difference),
+			})
+		}
+	}
+	return t
+}
+
+// balanceCommand returns the "balance" subcommand, which proposes balanced teams for a list of
+// players, as rated over the given logs.
+func balanceCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "balance",
+		Usage:     "Proposes balanced team splits for a list of players, rated over log files.",
+		ArgsUsage: "<file...>",
+		Description: "Rates the players given with --player over the matches of the logs, as the \"ratings\" report " +
+			"does, and tries every split of them into two teams, printing the ones whose average ratings are " +
+			"closest. Players who played no rated match get the initial rating of 1500.",
+		Flags: append(append(append(inputFlags(), fileFlags()...),
+			&cli.StringSliceFlag{
+				Name:     "player",
+				Aliases:  []string{"p"},
+				Usage:    "split `NAME` into one of the teams; may be repeated or given a comma-separated list",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:  "suggestions",
+				Value: 3,
+				Usage: "propose the `N` most balanced splits",
+			},
+		), outputFlags...),
+		Action: func(c *cli.Context) error {
+			files, err := inputFiles(c)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			players := slices.Compact(slices.Sorted(slices.Values(c.StringSlice("player"))))
+			switch {
+			case len(players) < 2:
+				return cli.Exit("At least two players must be given", exitUsage)
+			case len(players) > maxBalancePlayers:
+				return cli.Exit(fmt.Sprintf("At most %d players can be split", maxBalancePlayers), exitUsage)
+			case c.Int("suggestions") < 1:
+				return cli.Exit("--suggestions must be at least 1", exitUsage)
+			}
+
+			config, _, err := newParseConfig(c, qlp.Options{})
+			if err != nil {
+				return err
+			}
+			var matches qlp.Matches
+			for _, filePath := range files {
+				err := parseFile(filePath, config, func(match qlp.Match) error {
+					matches = append(matches, match)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			output, err := openOutput(c)
+			if err != nil {
+				return err
+			}
+			defer output.Close()
+
+			result := balanceTeams(players, qlpstats.Ratings(matches), c.Int("suggestions"))
+			if err := writeFormatted(output, output.format, result); err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to write teams: %s", err), exitWrite)
+			}
+			if err := output.Close(); err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to write teams: %s", err), exitWrite)
+			}
+			return nil
+		},
+	}
+}
+
+// balanceTeams tries every split of players into two teams, as even in size as possible, and
+// returns the given number of splits whose average ratings are closest. Ties keep the split
+// found first.
+func balanceTeams(players []string, ratings map[string]float64, suggestions int) balance {
+	result := balance{Splits: []teamSplit{}}
+	playerRatings := make([]float64, len(players))
+	for i, player := range players {
+		rating, ok := ratings[player]
+		if !ok {
+			rating = qlpstats.InitialRating
+			result.Unrated = append(result.Unrated, player)
+		}
+		playerRatings[i] = rating
+	}
+
+	// the first player is always on the first team, so that no split is tried twice with the
+	// teams swapped
+	n := len(players)
+	for mask := uint32(1); mask < 1<<n; mask += 2 {
+		if size := bits.OnesCount32(mask); size != n/2 && size != (n+1)/2 {
+			continue
+		}
+
+		var totals [2]float64
+		var sizes [2]int
+		for i := range n {
+			team := int(mask>>i&1) ^ 1
+			totals[team] += playerRatings[i]
+			sizes[team]++
+		}
+		difference := math.Abs(totals[0]/float64(sizes[0]) - totals[1]/float64(sizes[1]))
+
+		index, _ := slices.BinarySearchFunc(result.Splits, difference, func(s teamSplit, d float64) int {
+			if s.Difference <= d {
+				return -1
+			}
+			return 1
+		})
+		if index >= suggestions {
+			continue
+		}
+		split := teamSplit{Difference: difference}
+		for team := range split.Teams {
+			split.Teams[team] = balanceTeam{Players: []string{}, Rating: totals[team] / float64(sizes[team])}
+		}
+		for i, player := range players {
+			team := int(mask>>i&1) ^ 1
+			split.Teams[team].Players = append(split.Teams[team].Players, player)
+		}
+		result.Splits = slices.Insert(result.Splits, index, split)
+		if len(result.Splits) > suggestions {
+			result.Splits = result.Splits[:suggestions]
+		}
+	}
+	return result
+}
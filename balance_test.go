@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agstrc/qlp/qlp/qlpstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+// teamPlayers returns the players of each team of the split.
+func teamPlayers(split teamSplit) [2][]string {
+	return [2][]string{split.Teams[0].Players, split.Teams[1].Players}
+}
+
+func TestBalanceTeams(t *testing.T) {
+	ratings := map[string]float64{"a": 1600, "b": 1400, "c": 1550, "d": 1450}
+	result := balanceTeams([]string{"a", "b", "c", "d"}, ratings, 3)
+
+	assert.Empty(t, result.Unrated)
+	if assert.Len(t, result.Splits, 3) {
+		assert.Equal(t, [2][]string{{"a", "b"}, {"c", "d"}}, teamPlayers(result.Splits[0]))
+		assert.Equal(t, 0.0, result.Splits[0].Difference)
+		assert.Equal(t, [2][]string{{"a", "d"}, {"b", "c"}}, teamPlayers(result.Splits[1]))
+		assert.Equal(t, 50.0, result.Splits[1].Difference)
+		assert.Equal(t, 1525.0, result.Splits[1].Teams[0].Rating)
+		assert.Equal(t, [2][]string{{"a", "c"}, {"b", "d"}}, teamPlayers(result.Splits[2]))
+		assert.Equal(t, 150.0, result.Splits[2].Difference)
+	}
+}
+
+func TestBalanceTeamsOdd(t *testing.T) {
+	ratings := map[string]float64{"a": 1700, "b": 1500, "c": 1500, "d": 1400, "e": 1300}
+	result := balanceTeams([]string{"a", "b", "c", "d", "e"}, ratings, 10)
+
+	// every split of five players into teams of three and two, each tried once
+	assert.Len(t, result.Splits, 10)
+	for _, split := range result.Splits {
+		sizes := []int{len(split.Teams[0].Players), len(split.Teams[1].Players)}
+		assert.ElementsMatch(t, []int{2, 3}, sizes)
+		assert.Contains(t, split.Teams[0].Players, "a")
+	}
+	assert.Equal(t, [2][]string{{"a", "e"}, {"b", "c", "d"}}, teamPlayers(result.Splits[0]))
+	assert.InDelta(t, 33.33, result.Splits[0].Difference, 0.01)
+	for i := 1; i < len(result.Splits); i++ {
+		assert.LessOrEqual(t, result.Splits[i-1].Difference, result.Splits[i].Difference)
+	}
+}
+
+func TestBalanceTeamsTies(t *testing.T) {
+	// nobody is rated, so every split is as balanced, and the ones found first are kept
+	result := balanceTeams([]string{"a", "b", "c", "d"}, nil, 2)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, result.Unrated)
+	if assert.Len(t, result.Splits, 2) {
+		assert.Equal(t, [2][]string{{"a", "b"}, {"c", "d"}}, teamPlayers(result.Splits[0]))
+		assert.Equal(t, [2][]string{{"a", "c"}, {"b", "d"}}, teamPlayers(result.Splits[1]))
+		assert.Equal(t, float64(qlpstats.InitialRating), result.Splits[0].Teams[1].Rating)
+	}
+}
+
+// runBalance runs the "balance" subcommand with args, returning its output and error.
+func runBalance(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "teams.csv")
+	app := &cli.App{
+		Commands:       []*cli.Command{balanceCommand()},
+		ExitErrHandler: func(*cli.Context, error) {},
+	}
+	err := app.Run(append([]string{"qlp", "balance", "--format", "csv", "-o", output}, args...))
+	data, _ := os.ReadFile(output)
+	return string(data), err
+}
+
+func TestBalanceCommandLimit(t *testing.T) {
+	args := []string{"--suggestions", "1"}
+	for i := range maxBalancePlayers {
+		args = append(args, "-p", fmt.Sprintf("player%02d", i))
+	}
+	output, err := runBalance(t, append(args, "qlp/test_log.txt")...)
+	assert.NoError(t, err)
+	assert.Contains(t, output, "split,team,players,rating,difference\n1,1,\"player00, ")
+
+	output, err = runBalance(t, append(args, "-p", "one too many", "qlp/test_log.txt")...)
+	var exit cli.ExitCoder
+	if assert.ErrorAs(t, err, &exit) {
+		assert.Equal(t, exitUsage, exit.ExitCode())
+		assert.EqualError(t, err, fmt.Sprintf("At most %d players can be split", maxBalancePlayers))
+	}
+	assert.Empty(t, output)
+
+	_, err = runBalance(t, "-p", "alone", "qlp/test_log.txt")
+	assert.EqualError(t, err, "At least two players must be given")
+}
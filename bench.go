@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// benchCommand returns the "bench" subcommand, which measures how fast logs are parsed.
+func benchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "bench",
+		Usage:     "Measures parse throughput and allocations on log files.",
+		ArgsUsage: "<file...>",
+		Description: "Loads each file into memory and parses it repeatedly, reporting the throughput " +
+			"(MB/s, lines/s, matches/s) and allocations per run. Reading the file is not measured.",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "runs",
+				Value: 5,
+				Usage: "parse each file `N` times",
+			},
+			&cli.IntFlag{
+				Name:    "jobs",
+				Aliases: []string{"j"},
+				Value:   1,
+				Usage:   "parse on `N` goroutines, 0 meaning one per CPU",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+			if c.Int("runs") < 1 {
+				return cli.Exit("The number of runs must be at least 1", exitUsage)
+			}
+
+			for _, filePath := range c.Args().Slice() {
+				data, err := os.ReadFile(filePath)
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to open file: %s", err), exitOpen)
+				}
+
+				result, err := benchmarkParse(data, c.Int("runs"), c.Int("jobs"))
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to parse file %s: %s", filePath, err), exitParse)
+				}
+				result.print(c.App.Writer, filePath)
+			}
+			return nil
+		},
+	}
+}
+
+// benchResult holds the measurements of benchmarkParse, averaged per run.
+type benchResult struct {
+	runs     int
+	bytes    int
+	lines    int
+	matches  int
+	duration time.Duration
+	allocs   uint64
+	allocMB  float64
+}
+
+// benchmarkParse parses data the given number of times, on the given number of goroutines.
+func benchmarkParse(data []byte, runs, jobs int) (*benchResult, error) {
+	result := &benchResult{runs: runs, bytes: len(data), lines: bytes.Count(data, []byte{'\n'})}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		result.lines++
+	}
+
+	parser := qlp.NewParser(qlp.Options{})
+	parse := func() (qlp.Matches, error) {
+		if jobs == 1 {
+			return parser.Parse(bytes.NewReader(data))
+		}
+		return qlp.ParseLogParallel(bytes.NewReader(data), int64(len(data)), qlp.Options{}, jobs)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	for i := 0; i < runs; i++ {
+		matches, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		result.matches = len(matches)
+	}
+
+	result.duration = time.Since(start) / time.Duration(runs)
+	runtime.ReadMemStats(&after)
+	result.allocs = (after.Mallocs - before.Mallocs) / uint64(runs)
+	result.allocMB = float64(after.TotalAlloc-before.TotalAlloc) / float64(runs) / 1e6
+
+	return result, nil
+}
+
+// print writes the result in a human readable form.
+func (r *benchResult) print(w io.Writer, name string) {
+	seconds := r.duration.Seconds()
+	fmt.Fprintf(w, "%s: %d runs, %.1f MB, %d lines, %d matches\n",
+		name, r.runs, float64(r.bytes)/1e6, r.lines, r.matches)
+	fmt.Fprintf(w, "  time/run:     %v\n", r.duration)
+	fmt.Fprintf(w, "  throughput:   %.2f MB/s, %.0f lines/s, %.0f matches/s\n",
+		float64(r.bytes)/1e6/seconds, float64(r.lines)/seconds, float64(r.matches)/seconds)
+	fmt.Fprintf(w, "  allocations:  %d allocs/run, %.2f MB/run\n", r.allocs, r.allocMB)
+}
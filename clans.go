@@ -0,0 +1,147 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// defaultClanPatterns are the clan tag patterns used when the configuration sets none: tags
+// in brackets, parentheses or braces, or between equal signs, before the name, tags followed
+// by a pipe, and tags in brackets after the name.
+var defaultClanPatterns = []string{
+	`^\[(?P<tag>[^\]]+)\]`,
+	`^\((?P<tag>[^)]+)\)`,
+	`^\{(?P<tag>[^}]+)\}`,
+	`^=(?P<tag>[^=]+)=`,
+	`^(?P<tag>[^|\s]+)\|`,
+	`\[(?P<tag>[^\]]+)\]$`,
+}
+
+// colorCodeExpr matches the color codes of Quake III names, such as "^1".
+var colorCodeExpr = regexp.MustCompile(`\^[^^]`)
+
+// clanMatcher finds the clan tags in player names.
+type clanMatcher []*regexp.Regexp
+
+// newClanMatcher compiles the clan tag patterns, which capture the tag in the group named
+// "tag", or in their first group if there is no such group. No patterns means
+// defaultClanPatterns.
+func newClanMatcher(patterns []string) (clanMatcher, error) {
+	if len(patterns) == 0 {
+		patterns = defaultClanPatterns
+	}
+
+	matcher := make(clanMatcher, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clan pattern: %w", err)
+		}
+		if expr.NumSubexp() == 0 {
+			return nil, fmt.Errorf("clan pattern %q captures no tag", pattern)
+		}
+		matcher = append(matcher, expr)
+	}
+	return matcher, nil
+}
+
+// clanOf returns the clan tag in a player's name, color codes aside, or an empty string if
+// there is none.
+func (m clanMatcher) clanOf(player string) string {
+	name := colorCodeExpr.ReplaceAllString(player, "")
+	for _, expr := range m {
+		groups := expr.FindStringSubmatch(name)
+		if groups == nil {
+			continue
+		}
+		group := expr.SubexpIndex("tag")
+		if group < 0 {
+			group = 1
+		}
+		if groups[group] != "" {
+			return groups[group]
+		}
+	}
+	return ""
+}
+
+// clanStanding is the line of a clan in the clan leaderboard.
+type clanStanding struct {
+	Rank    int      `json:"rank"`
+	Clan    string   `json:"clan"`
+	Members []string `json:"members"`
+	// Matches counts the matches in which at least one member played.
+	Matches int `json:"matches"`
+	// Kills is the sum of the members' kills, as counted in Match.Kills.
+	Kills         int     `json:"kills"`
+	KillsPerMatch float64 `json:"kills_per_match"`
+}
+
+// clanLeaderboard is the output of the "clans" report.
+type clanLeaderboard []clanStanding
+
+// Table lists one clan per row, in order of rank.
+func (l clanLeaderboard) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"rank", "clan", "members", "matches", "kills", "kills_per_match"}}
+	for _, s := range l {
+		t.Rows = append(t.Rows, []string{
+			strconv.Itoa(s.Rank), s.Clan, strconv.Itoa(len(s.Members)), strconv.Itoa(s.Matches),
+			strconv.Itoa(s.Kills), formatFloat(s.KillsPerMatch),
+		})
+	}
+	return t
+}
+
+// buildClanLeaderboard ranks the clans by the kills of their members over every match, ties
+// being broken by tag. Clans with the same kills share a rank. Players without a clan tag are
+// left out.
+func buildClanLeaderboard(matches qlp.Matches, clans clanMatcher) clanLeaderboard {
+	byClan := make(map[string]*clanStanding)
+	members := make(map[string]map[string]struct{})
+	for _, match := range matches {
+		played := make(map[string]bool)
+		for _, player := range match.Players {
+			clan := clans.clanOf(player)
+			if clan == "" {
+				continue
+			}
+			s := byClan[clan]
+			if s == nil {
+				s = &clanStanding{Clan: clan}
+				byClan[clan] = s
+				members[clan] = make(map[string]struct{})
+			}
+			s.Kills += match.Kills[player]
+			members[clan][player] = struct{}{}
+			if !played[clan] {
+				played[clan] = true
+				s.Matches++
+			}
+		}
+	}
+
+	standings := make(clanLeaderboard, 0, len(byClan))
+	for clan, s := range byClan {
+		s.Members = sortedKeys(members[clan])
+		s.KillsPerMatch = float64(s.Kills) / float64(s.Matches)
+		standings = append(standings, *s)
+	}
+	slices.SortFunc(standings, func(a, b clanStanding) int {
+		return cmp.Or(cmp.Compare(b.Kills, a.Kills), cmp.Compare(a.Clan, b.Clan))
+	})
+
+	for i := range standings {
+		if i > 0 && standings[i].Kills == standings[i-1].Kills {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings
+}
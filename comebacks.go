@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// comeback describes how the lead of a match evolved.
+type comeback struct {
+	Match int `json:"match"` // 1-indexed, as in the "game_N" keys
+	// Winner is the player with the highest final score, or empty if the top score is tied or
+	// nobody played.
+	Winner string `json:"winner"`
+	// LeadChanges counts how many times a player took the sole lead from another one.
+	LeadChanges int `json:"lead_changes"`
+	// LargestDeficit is the most points the winner was behind the leader at any point.
+	LargestDeficit int `json:"largest_deficit"`
+}
+
+// comebacks is the output of the "comebacks" report.
+type comebacks []comeback
+
+// Table lists one match per row.
+func (cs comebacks) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"match", "winner", "lead_changes", "largest_deficit"}}
+	for _, c := range cs {
+		t.Rows = append(t.Rows, []string{
+			strconv.Itoa(c.Match), c.Winner, strconv.Itoa(c.LeadChanges), strconv.Itoa(c.LargestDeficit),
+		})
+	}
+	return t
+}
+
+// findComebacks replays the score of each match, as counted in Match.Kills, kill by kill. It
+// needs the kill feed of the matches.
+func findComebacks(matches qlp.Matches) comebacks {
+	cs := make(comebacks, 0, len(matches))
+	for i, match := range matches {
+		c := comeback{Match: i + 1, Winner: soleLeader(match.Kills)}
+
+		scores := make(map[string]int)
+		leader := ""
+		for _, kill := range match.KillFeed {
+			switch {
+			case kill.Killer == "<world>":
+				scores[kill.Victim]--
+			case kill.Killer != kill.Victim:
+				scores[kill.Killer]++
+			}
+
+			if current := soleLeader(scores); current != "" {
+				if leader != "" && current != leader {
+					c.LeadChanges++
+				}
+				leader = current
+			}
+			if c.Winner != "" {
+				best := scores[c.Winner]
+				for _, score := range scores {
+					best = max(best, score)
+				}
+				c.LargestDeficit = max(c.LargestDeficit, best-scores[c.Winner])
+			}
+		}
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// soleLeader returns the player with the highest score, or an empty string if the highest
+// score is tied or there are no players.
+func soleLeader(scores map[string]int) string {
+	leader, tied := "", false
+	for player, score := range scores {
+		switch {
+		case leader == "" || score > scores[leader]:
+			leader, tied = player, false
+		case score == scores[leader]:
+			tied = true
+		}
+	}
+	if tied {
+		return ""
+	}
+	return leader
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// comebacksLog holds a match which the winner wins from two points behind, a tied match and
+// a match without kills.
+const comebacksLog = "  0:00 InitGame: \\mapname\\q3dm17\n" +
+	"  0:10 Kill: 2 3 7: Isgalamido killed Zeh by MOD_ROCKET_SPLASH\n" +
+	"  0:20 Kill: 3 2 7: Zeh killed Isgalamido by MOD_ROCKET_SPLASH\n" +
+	"  0:30 Kill: 3 2 7: Zeh killed Isgalamido by MOD_ROCKET_SPLASH\n" +
+	"  0:40 Kill: 3 2 7: Zeh killed Isgalamido by MOD_ROCKET_SPLASH\n" +
+	"  0:50 Kill: 2 3 10: Isgalamido killed Zeh by MOD_RAILGUN\n" +
+	"  1:00 Kill: 2 3 10: Isgalamido killed Zeh by MOD_RAILGUN\n" +
+	"  1:10 Kill: 2 3 10: Isgalamido killed Zeh by MOD_RAILGUN\n" +
+	"  1:20 Kill: 1022 3 22: <world> killed Zeh by MOD_TRIGGER_HURT\n" +
+	"  1:30 " + fixtureSeparator + "\n" +
+	"  0:00 InitGame: \\mapname\\q3dm6\n" +
+	"  0:10 Kill: 2 3 7: Isgalamido killed Zeh by MOD_ROCKET_SPLASH\n" +
+	"  0:20 Kill: 3 2 7: Zeh killed Isgalamido by MOD_ROCKET_SPLASH\n" +
+	"  0:30 " + fixtureSeparator + "\n" +
+	"  0:00 InitGame: \\mapname\\q3dm6\n" +
+	"  0:30 " + fixtureSeparator + "\n"
+
+func TestFindComebacks(t *testing.T) {
+	found := findComebacks(parseFixture(t, comebacksLog))
+	assert.Equal(t, comebacks{
+		// Isgalamido leads, falls two points behind Zeh and takes the lead back
+		{Match: 1, Winner: "Isgalamido", LeadChanges: 2, LargestDeficit: 2},
+		{Match: 2},
+		{Match: 3},
+	}, found)
+	assert.Equal(t, []string{"1", "Isgalamido", "2", "2"}, found.Table().Rows[0])
+}
+
+func TestSoleLeader(t *testing.T) {
+	assert.Equal(t, "Zeh", soleLeader(map[string]int{"Isgalamido": 1, "Zeh": 2}))
+	assert.Empty(t, soleLeader(map[string]int{"Isgalamido": 2, "Zeh": 2, "Mocinha": 1}))
+	assert.Empty(t, soleLeader(nil))
+	assert.Equal(t, "Mocinha", soleLeader(map[string]int{"Mocinha": -1}))
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/urfave/cli/v2"
+)
+
+// flagValues lists the accepted values of enumerated flags, keyed by the flag's main name.
+// Completion scripts offer these values right after the flag is typed.
+var flagValues = map[string][]string{
+	"dedupe":       {"drop", "flag"},
+	"format":       qlpformat.Names(),
+	"include-raw":  {"embed", "files"},
+	"invalid-utf8": {"windows1252", "replace", "strip"},
+	"lang":         {"en", "pt-BR"},
+	"period":       {"month", "quarter", "year"},
+	"restarts":     {"separate", "merge"},
+	"score-model":  {"classic", "q3", "custom"},
+	"world-deaths": {"decrement", "count", "both"},
+}
+
+// completionCommand returns the "completion" subcommand, which prints a shell completion
+// script for the whole application.
+func completionCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "completion",
+		Usage:       "Prints a shell completion script (bash, zsh or fish).",
+		ArgsUsage:   "<bash|zsh|fish>",
+		Description: "Prints a completion script covering every subcommand, flag and enumerated flag value.",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return cli.Exit("Exactly one shell must be given: bash, zsh or fish", exitUsage)
+			}
+
+			switch shell := c.Args().Get(0); shell {
+			case "bash":
+				writeBashCompletion(c.App.Writer, c.App)
+			case "zsh":
+				writeZshCompletion(c.App.Writer, c.App)
+			case "fish":
+				writeFishCompletion(c.App.Writer, c.App)
+			default:
+				return cli.Exit(fmt.Sprintf("Unsupported shell: %s", shell), exitUsage)
+			}
+			return nil
+		},
+	}
+}
+
+// identifierExpr matches characters which may not be used in shell function names.
+var identifierExpr = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// completionFuncName returns the name of the shell function which holds the completion logic.
+func completionFuncName(app *cli.App) string {
+	return "_" + identifierExpr.ReplaceAllString(app.Name, "_") + "_complete"
+}
+
+// flagWords returns every spelling of the flags, each prefixed with the right amount of dashes.
+func flagWords(flags []cli.Flag) []string {
+	var words []string
+	for _, flag := range flags {
+		for _, name := range flag.Names() {
+			words = append(words, dashed(name))
+		}
+	}
+	return words
+}
+
+// dashed prefixes a flag name with one dash for single letter names and two otherwise.
+func dashed(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// commandWords returns the names and aliases of the given commands.
+func commandWords(commands []*cli.Command) []string {
+	var words []string
+	for _, command := range commands {
+		words = append(words, command.Names()...)
+	}
+	return words
+}
+
+// valueFlags returns the flags of flagValues that are present in flags.
+func valueFlags(flags []cli.Flag) []cli.Flag {
+	var found []cli.Flag
+	for _, flag := range flags {
+		if _, ok := flagValues[flag.Names()[0]]; ok {
+			found = append(found, flag)
+		}
+	}
+	return found
+}
+
+// writeBashCompletion writes a bash completion script for app to w.
+func writeBashCompletion(w io.Writer, app *cli.App) {
+	funcName := completionFuncName(app)
+
+	fmt.Fprintf(w, "# bash completion for %s\n", app.Name)
+	fmt.Fprintf(w, "%s() {\n", funcName)
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `	local words`)
+
+	allFlags := app.VisibleFlags()
+	for _, command := range app.VisibleCommands() {
+		allFlags = append(allFlags, command.VisibleFlags()...)
+	}
+	if flags := valueFlags(allFlags); len(flags) > 0 {
+		fmt.Fprintln(w, `	case "$prev" in`)
+		for _, flag := range flags {
+			values := flagValues[flag.Names()[0]]
+			fmt.Fprintf(w, "\t%s)\n", strings.Join(flagWords([]cli.Flag{flag}), "|"))
+			fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(values, " "))
+			fmt.Fprintln(w, "\t\treturn")
+			fmt.Fprintln(w, "\t\t;;")
+		}
+		fmt.Fprintln(w, "\tesac")
+	}
+
+	fmt.Fprintln(w, `	case "${COMP_WORDS[1]}" in`)
+	for _, command := range app.VisibleCommands() {
+		words := append(commandWords(command.VisibleCommands()), flagWords(command.VisibleFlags())...)
+		fmt.Fprintf(w, "\t%s)\n", strings.Join(command.Names(), "|"))
+		fmt.Fprintf(w, "\t\twords=%q\n", strings.Join(words, " "))
+		fmt.Fprintln(w, "\t\t;;")
+	}
+	rootWords := append(commandWords(app.VisibleCommands()), flagWords(app.VisibleFlags())...)
+	fmt.Fprintln(w, "\t*)")
+	fmt.Fprintf(w, "\t\twords=%q\n", strings.Join(rootWords, " "))
+	fmt.Fprintln(w, "\t\t;;")
+	fmt.Fprintln(w, "\tesac")
+
+	fmt.Fprintln(w, `	if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintln(w, `		COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, `	else`)
+	fmt.Fprintln(w, `		COMPREPLY=($(compgen -W "$words" -- "$cur") $(compgen -f -- "$cur"))`)
+	fmt.Fprintln(w, `	fi`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -o filenames -F %s %s\n", funcName, app.Name)
+}
+
+// writeZshCompletion writes a zsh completion script for app to w. It relies on bashcompinit,
+// which ships with zsh, so that both shells share a single completion implementation.
+func writeZshCompletion(w io.Writer, app *cli.App) {
+	fmt.Fprintf(w, "#compdef %s\n", app.Name)
+	fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+	writeBashCompletion(w, app)
+}
+
+// writeFishCompletion writes a fish completion script for app to w.
+func writeFishCompletion(w io.Writer, app *cli.App) {
+	fmt.Fprintf(w, "# fish completion for %s\n", app.Name)
+
+	commands := commandWords(app.VisibleCommands())
+	noCommand := "not __fish_seen_subcommand_from " + strings.Join(commands, " ")
+	if len(commands) == 0 {
+		noCommand = "true"
+	}
+
+	for _, command := range app.VisibleCommands() {
+		for _, name := range command.Names() {
+			fmt.Fprintf(w, "complete -c %s -n %q -a %s -d %q\n", app.Name, noCommand, name, command.Usage)
+		}
+	}
+	for _, flag := range app.VisibleFlags() {
+		writeFishFlag(w, app.Name, noCommand, flag)
+	}
+
+	for _, command := range app.VisibleCommands() {
+		condition := "__fish_seen_subcommand_from " + strings.Join(command.Names(), " ")
+		for _, sub := range command.VisibleCommands() {
+			for _, name := range sub.Names() {
+				fmt.Fprintf(w, "complete -c %s -n %q -a %s -d %q\n", app.Name, condition, name, sub.Usage)
+			}
+		}
+		for _, flag := range command.VisibleFlags() {
+			writeFishFlag(w, app.Name, condition, flag)
+		}
+	}
+}
+
+// writeFishFlag writes the fish completion line for a single flag.
+func writeFishFlag(w io.Writer, program, condition string, flag cli.Flag) {
+	var parts []string
+	for _, name := range flag.Names() {
+		if len(name) == 1 {
+			parts = append(parts, "-s "+name)
+		} else {
+			parts = append(parts, "-l "+name)
+		}
+	}
+
+	if values, ok := flagValues[flag.Names()[0]]; ok {
+		parts = append(parts, fmt.Sprintf("-x -a %q", strings.Join(values, " ")))
+	}
+	if docFlag, ok := flag.(cli.DocGenerationFlag); ok && docFlag.GetUsage() != "" {
+		parts = append(parts, fmt.Sprintf("-d %q", docFlag.GetUsage()))
+	}
+
+	fmt.Fprintf(w, "complete -c %s -n %q %s\n", program, condition, strings.Join(parts, " "))
+}
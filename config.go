@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the contents of the configuration file given with --config. The file may be
+// written in YAML or JSON, as JSON is valid YAML.
+type fileConfig struct {
+	// Events describes extra events to be captured, see qlp.EventRule.
+	Events []struct {
+		Target  string `yaml:"target"`
+		Pattern string `yaml:"pattern"`
+		Key     string `yaml:"key"`
+		Value   string `yaml:"value"`
+	} `yaml:"events"`
+
+	// NameRules describes how the names of players are normalized, see qlp.NameRules.
+	NameRules struct {
+		Trim           string   `yaml:"trim"`
+		CollapseSpaces bool     `yaml:"collapse_spaces"`
+		Banned         []string `yaml:"banned"`
+	} `yaml:"name_rules"`
+
+	// WorldEntities are the killers standing for the world besides <world>, see
+	// qlp.Options.WorldEntities. --world-entity replaces them.
+	WorldEntities worldEntities `yaml:"world_entities"`
+
+	// ClanPatterns are the regular expressions finding clan tags in player names, which
+	// replace the default ones.
+	ClanPatterns []string `yaml:"clan_patterns"`
+
+	// GeoIPDatabase is the MaxMind DB file, such as GeoLite2 Country, used to find the
+	// countries of the players.
+	GeoIPDatabase string `yaml:"geoip_database"`
+
+	// Sinks describes where finished matches are announced, such as chat channels.
+	Sinks []struct {
+		Type    string `yaml:"type"`
+		URL     string `yaml:"url"`
+		Channel string `yaml:"channel"`
+		Token   string `yaml:"token"`
+		ChatID  string `yaml:"chat_id"`
+	} `yaml:"sinks"`
+
+	// ScoreModel describes the scoring of --score-model custom. The points left out are the
+	// ones of the classic model.
+	ScoreModel *struct {
+		Kill       *int `yaml:"kill"`
+		Teamkill   *int `yaml:"teamkill"`
+		Suicide    *int `yaml:"suicide"`
+		WorldDeath *int `yaml:"world_death"`
+	} `yaml:"score_model"`
+
+	// Servers describes the log sources served separately by the "serve" subcommand, under
+	// /servers/{id}/.
+	Servers []struct {
+		ID   string   `yaml:"id"`
+		Logs []string `yaml:"logs"`
+	} `yaml:"servers"`
+}
+
+// allDialects is the key of world_entities, and the dialect of --world-entity, standing for
+// every dialect.
+const allDialects = "*"
+
+// worldEntities are the killers standing for the world of each dialect, keyed by gamename as
+// in qlp.Options.WorldEntities. In the file, they are either a mapping of gamenames, "*"
+// standing for every dialect, to lists, or a list for every dialect.
+type worldEntities map[string][]string
+
+// UnmarshalYAML decodes the mapping or the list of world_entities.
+func (w *worldEntities) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var entities []string
+		if err := node.Decode(&entities); err != nil {
+			return err
+		}
+		*w = worldEntities{qlp.AllDialects: entities}
+		return nil
+	}
+
+	var byDialect map[string][]string
+	if err := node.Decode(&byDialect); err != nil {
+		return err
+	}
+	*w = make(worldEntities, len(byDialect))
+	for dialect, entities := range byDialect {
+		if dialect == allDialects {
+			dialect = qlp.AllDialects
+		}
+		(*w)[dialect] = append((*w)[dialect], entities...)
+	}
+	return nil
+}
+
+// parseWorldEntities parses the values of --world-entity, [GAMENAME=]NAME, the names without
+// a gamename or with "*" standing for the world in every dialect.
+func parseWorldEntities(values []string) worldEntities {
+	entities := make(worldEntities)
+	for _, value := range values {
+		dialect, name, ok := strings.Cut(value, "=")
+		if !ok {
+			dialect, name = qlp.AllDialects, value
+		} else if dialect == allDialects {
+			dialect = qlp.AllDialects
+		}
+		entities[dialect] = append(entities[dialect], name)
+	}
+	return entities
+}
+
+// loadConfig reads the configuration file at filePath. An empty path yields an empty
+// configuration.
+func loadConfig(filePath string) (*fileConfig, error) {
+	config := &fileConfig{}
+	if filePath == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", filePath, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", filePath, err)
+	}
+	return config, nil
+}
+
+// idExpr matches the identifiers of servers, which are used in URLs.
+var idExpr = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// validate checks the configuration for mistakes which cannot be caught while decoding it.
+func (c *fileConfig) validate() error {
+	ids := make(map[string]bool, len(c.Servers))
+	for _, server := range c.Servers {
+		if !idExpr.MatchString(server.ID) {
+			return fmt.Errorf("invalid server id %q: only letters, digits, '_', '.' and '-' are allowed", server.ID)
+		}
+		if ids[server.ID] {
+			return fmt.Errorf("duplicate server id %q", server.ID)
+		}
+		if len(server.Logs) == 0 {
+			return fmt.Errorf("server %q has no logs", server.ID)
+		}
+		ids[server.ID] = true
+	}
+	return nil
+}
+
+// scoreModel builds the qlp.ScoreModel described by the configuration.
+func (c *fileConfig) scoreModel() (qlp.ScoreModel, error) {
+	if c.ScoreModel == nil {
+		return qlp.ScoreModel{}, fmt.Errorf("no score_model is configured")
+	}
+	model := qlp.ClassicScoring
+	for _, point := range []struct {
+		value  *int
+		target *int
+	}{
+		{c.ScoreModel.Kill, &model.Kill},
+		{c.ScoreModel.Teamkill, &model.Teamkill},
+		{c.ScoreModel.Suicide, &model.Suicide},
+		{c.ScoreModel.WorldDeath, &model.WorldDeath},
+	} {
+		if point.value != nil {
+			*point.target = *point.value
+		}
+	}
+	return model, nil
+}
+
+// eventRules builds the qlp.EventRules described by the configuration.
+func (c *fileConfig) eventRules() ([]qlp.EventRule, error) {
+	rules := make([]qlp.EventRule, 0, len(c.Events))
+	for _, event := range c.Events {
+		rule, err := qlp.NewEventRule(event.Target, event.Pattern, event.Key, event.Value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// nameRules builds the qlp.NameRules described by the configuration.
+func (c *fileConfig) nameRules() qlp.NameRules {
+	return qlp.NewNameRules(c.NameRules.Trim, c.NameRules.CollapseSpaces, c.NameRules.Banned)
+}
+
+// sinks builds the sinks described by the configuration.
+func (c *fileConfig) sinks() (sinkSet, error) {
+	sinks := make(sinkSet, 0, len(c.Sinks))
+	for _, config := range c.Sinks {
+		switch config.Type {
+		case "discord":
+			if config.URL == "" {
+				return nil, fmt.Errorf("discord sink without a webhook url")
+			}
+			sinks = append(sinks, discordSink{url: config.URL})
+		case "slack":
+			if config.URL == "" {
+				return nil, fmt.Errorf("slack sink without a webhook url")
+			}
+			sinks = append(sinks, slackSink{url: config.URL, channel: config.Channel})
+		case "telegram":
+			if config.Token == "" || config.ChatID == "" {
+				return nil, fmt.Errorf("telegram sink without a bot token and a chat id")
+			}
+			sinks = append(sinks, telegramSink{token: config.Token, chatID: config.ChatID})
+		default:
+			return nil, fmt.Errorf("unknown sink type %q", config.Type)
+		}
+	}
+	return sinks, nil
+}
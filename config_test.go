@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWorldEntitiesConfig(t *testing.T) {
+	var config fileConfig
+	assert.NoError(t, yaml.Unmarshal([]byte("world_entities: ['<environment>']"), &config))
+	assert.Equal(t, worldEntities{qlp.AllDialects: {"<environment>"}}, config.WorldEntities)
+
+	config = fileConfig{}
+	document := "world_entities:\n  '*': ['<environment>']\n  q3ut4: ['<void>']\n  baseq3: []\n"
+	assert.NoError(t, yaml.Unmarshal([]byte(document), &config))
+	assert.Equal(t, worldEntities{
+		qlp.AllDialects: {"<environment>"},
+		"q3ut4":         {"<void>"},
+		"baseq3":        nil,
+	}, config.WorldEntities)
+
+	assert.Equal(t, worldEntities{
+		qlp.AllDialects: {"<environment>", "<void>"},
+		"q3ut4":         {"<trigger>"},
+	}, parseWorldEntities([]string{"<environment>", "q3ut4=<trigger>", "*=<void>"}))
+}
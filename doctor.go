@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// doctorCommand returns the "doctor" subcommand, which checks logs for problems and explains
+// how to deal with them.
+func doctorCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "doctor",
+		Usage:     "Checks log files for problems and suggests how to deal with them.",
+		ArgsUsage: "<file...>",
+		Description: "Runs a battery of checks on each file: encoding, truncation, rotation seams, " +
+			"dialect and the ratio of malformed lines.",
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			for i, filePath := range c.Args().Slice() {
+				if i > 0 {
+					fmt.Fprintln(c.App.Writer)
+				}
+				if err := diagnoseFile(c.App.Writer, filePath); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// diagnoseFile diagnoses the log file at filePath and writes the findings to w.
+func diagnoseFile(w io.Writer, filePath string) error {
+	file, err := openLog(context.Background(), filePath, false)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to open file: %s", err), exitOpen)
+	}
+	defer file.Close()
+
+	diagnosis, err := qlp.Diagnose(file, qlp.Options{})
+	if err != nil {
+		return fmt.Errorf("Failed to read file %s: %s", filePath, err)
+	}
+
+	fmt.Fprintf(w, "%s: %d lines, %d complete matches\n", filePath, diagnosis.Lines, diagnosis.Matches)
+	findings := diagnosisFindings(diagnosis)
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "  No problems found.")
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(w, "  - %s\n", finding)
+	}
+	return nil
+}
+
+// diagnosisFindings turns a diagnosis into a list of actionable findings.
+func diagnosisFindings(d *qlp.Diagnosis) []string {
+	var findings []string
+
+	if malformed := d.WarningsFor(qlp.ErrMalformedLine); len(malformed) > 0 {
+		ratio := float64(len(malformed)) / float64(d.Lines)
+		finding := fmt.Sprintf("%d of %d lines (%.1f%%) have no timestamp, the first one being line %d. ",
+			len(malformed), d.Lines, ratio*100, malformed[0].Line)
+		if ratio > 0.5 {
+			finding += "This does not look like a Quake III Arena server log at all."
+		} else {
+			finding += "Parsing fails on them; they usually come from crashes or from other " +
+				"programs writing to the same file, and can be removed or skipped with --resync."
+		}
+		findings = append(findings, finding)
+	}
+
+	if invalid := d.WarningsFor(qlp.ErrInvalidUTF8); len(invalid) > 0 {
+		findings = append(findings, fmt.Sprintf("%d lines are not valid UTF-8, the first one being line %d. "+
+			"They are decoded as Windows-1252 (which covers Latin-1); if player names look wrong, "+
+			"try --invalid-utf8 replace.", len(invalid), invalid[0].Line))
+	}
+
+	if d.Unfinished {
+		findings = append(findings, "The log ends in the middle of a match, which is left out of the "+
+			"output. The file is either truncated or still being written to.")
+	}
+
+	if interrupted := d.WarningsFor(qlp.ErrMatchInterrupted); len(interrupted) > 0 {
+		findings = append(findings, fmt.Sprintf("%d matches start while another one is still open, "+
+			"the first one at line %d. This is the seam left by a server crash or by log rotation; "+
+			"the interrupted match is merged into the next one.", len(interrupted), interrupted[0].Line))
+	}
+	if backwards := d.WarningsFor(qlp.ErrTimestampBackwards); len(backwards) > 0 {
+		findings = append(findings, fmt.Sprintf("Timestamps go back in time %d times within a match, "+
+			"the first one at line %d. Logs of several servers or restarts may have been "+
+			"concatenated.", len(backwards), backwards[0].Line))
+	}
+	if outside := d.WarningsFor(qlp.ErrKillOutsideMatch); len(outside) > 0 {
+		findings = append(findings, fmt.Sprintf("%d kill events are outside of any match, the first "+
+			"one at line %d. The log probably starts in the middle of a match, after rotation.",
+			len(outside), outside[0].Line))
+	}
+	if malformed := d.WarningsFor(qlp.ErrMalformedKill); len(malformed) > 0 {
+		findings = append(findings, fmt.Sprintf("%d kill events could not be understood, the first "+
+			"one at line %d, and are not counted.", len(malformed), malformed[0].Line))
+	}
+
+	if d.GameName != "" && d.GameName != "baseq3" {
+		findings = append(findings, fmt.Sprintf("The log was written by the %q mod (%s). Mods may log "+
+			"events the parser does not understand.", d.GameName, d.Version))
+	}
+	if unknown := unknownEventTypes(d); len(unknown) > 0 {
+		findings = append(findings, fmt.Sprintf("Events of unknown types were found: %s. The parser "+
+			"ignores them; see --event-stats for every type in the log.", strings.Join(unknown, ", ")))
+	}
+	if d.Lines > 0 && d.EventCounts["InitGame"] == 0 {
+		findings = append(findings, "No InitGame event was found, so the log has no matches.")
+	}
+
+	return findings
+}
+
+// unknownEventTypes lists the unknown event types of a diagnosis along with their counts,
+// e.g. "Callvote (3)".
+func unknownEventTypes(d *qlp.Diagnosis) []string {
+	counts := make(map[string]int)
+	for _, warning := range d.Warnings {
+		if unknown, ok := warning.Reason.(qlp.UnknownEventError); ok {
+			counts[unknown.Type]++
+		}
+	}
+
+	types := make([]string, 0, len(counts))
+	for typ := range counts {
+		types = append(types, typ)
+	}
+	slices.Sort(types)
+
+	for i, typ := range types {
+		types[i] = fmt.Sprintf("%s (%d)", typ, counts[typ])
+	}
+	return types
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// dryRunReport tallies what a parse would have written, for --dry-run, which writes nothing
+// to the output, the sinks or the audit log.
+type dryRunReport struct {
+	files      int
+	matches    int
+	inProgress int
+	// deliveries is the number of matches the sinks would have been sent, summed over them.
+	deliveries int
+	// eventTypes is the number of event types --event-stats would have written.
+	eventTypes int
+}
+
+// Encode counts the match as written.
+func (d *dryRunReport) Encode(match qlp.Match) error {
+	d.matches++
+	if match.InProgress {
+		d.inProgress++
+	}
+	return nil
+}
+
+// Close does nothing, as nothing was written.
+func (d *dryRunReport) Close() error {
+	return nil
+}
+
+// print writes a summary of what the parse configured by c would have written, with sinks,
+// to w.
+func (d *dryRunReport) print(w io.Writer, c *cli.Context, sinks sinkSet) {
+	fmt.Fprintln(w, "Dry run: nothing was written.")
+	fmt.Fprintf(w, "Parsed %d files with %d matches, %d of them in progress.\n", d.files, d.matches, d.inProgress)
+
+	format := c.String("format")
+	switch {
+	case c.Bool("gzip"):
+		format += " compressed with gzip"
+	case c.Bool("zstd"):
+		format += " compressed with zstd"
+	}
+	switch {
+	case c.Bool("event-stats"):
+		fmt.Fprintf(w, "Would write the counts of %d event types as %s to %s.\n", d.eventTypes, format, destination(c))
+	case c.Bool("split-output"):
+		fmt.Fprintf(w, "Would write %d matches as %s, one per file, to %s.\n", d.matches, format, c.Path("out-dir"))
+	default:
+		fmt.Fprintf(w, "Would write %d matches as %s to %s.\n", d.matches, format, destination(c))
+	}
+
+	if len(sinks) > 0 {
+		fmt.Fprintf(w, "Would make %d deliveries to %d sinks.\n", d.deliveries, len(sinks))
+	}
+	if filePath := c.Path("audit-log"); filePath != "" {
+		fmt.Fprintf(w, "Would record %d files in the audit log %s.\n", d.files, filePath)
+	}
+}
+
+// destination describes where the outputFlags send the output.
+func destination(c *cli.Context) string {
+	if filePath := c.Path("output"); filePath != "" {
+		return filePath
+	}
+	return "stdout"
+}
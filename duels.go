@@ -0,0 +1,95 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// duelItems lists the items whose control the "duels" report measures, in the order they are
+// presented.
+var duelItems = []string{"item_health_mega", "item_armor_body", "item_armor_combat"}
+
+// duel describes a match between two players.
+type duel struct {
+	Match int    `json:"match"` // 1-indexed, as in the "game_N" keys
+	Map   string `json:"map"`
+	// Duelists holds both players, the one with the most kills first.
+	Duelists [2]duelist `json:"duelists"`
+}
+
+// duelist describes how a player did in a duel.
+type duelist struct {
+	Player string `json:"player"`
+	Kills  int    `json:"kills"`
+	// Items tells, for each of the duelItems which was picked up in the duel, how many
+	// pickups the player made and their share of the pickups of both players. What the
+	// player did not take was denied to them by the opponent.
+	Items map[string]itemControl `json:"items"`
+}
+
+// itemControl tells how often a player took an item in a duel.
+type itemControl struct {
+	Pickups int     `json:"pickups"`
+	Share   float64 `json:"share"`
+}
+
+// duels is the output of the "duels" report.
+type duels []duel
+
+// Table lists one item of a duelist per row, for the items picked up in each duel.
+func (ds duels) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"match", "map", "player", "kills", "item", "pickups", "share"}}
+	for _, d := range ds {
+		for _, item := range duelItems {
+			for _, duelist := range d.Duelists {
+				control, ok := duelist.Items[item]
+				if !ok {
+					continue
+				}
+				t.Rows = append(t.Rows, []string{
+					strconv.Itoa(d.Match), d.Map, duelist.Player, strconv.Itoa(duelist.Kills),
+					item, strconv.Itoa(control.Pickups), formatFloat(control.Share),
+				})
+			}
+		}
+	}
+	return t
+}
+
+// findDuels returns the matches played by exactly two players, with the share of the pickups
+// of the Mega Health and of the armors each player took. It needs the item pickups of the
+// matches.
+func findDuels(matches qlp.Matches) duels {
+	ds := duels{}
+	for i, match := range matches {
+		if len(match.Players) != 2 {
+			continue
+		}
+
+		players := slices.Clone(match.Players)
+		slices.SortFunc(players, func(a, b string) int {
+			return cmp.Or(cmp.Compare(match.Kills[b], match.Kills[a]), cmp.Compare(a, b))
+		})
+
+		d := duel{Match: i + 1, Map: match.MapName}
+		for j, player := range players {
+			d.Duelists[j] = duelist{Player: player, Kills: match.Kills[player], Items: make(map[string]itemControl)}
+		}
+		for _, item := range duelItems {
+			total := match.ItemPickups[players[0]][item] + match.ItemPickups[players[1]][item]
+			if total == 0 {
+				continue
+			}
+			for j, player := range players {
+				pickups := match.ItemPickups[player][item]
+				d.Duelists[j].Items[item] = itemControl{Pickups: pickups, Share: float64(pickups) / float64(total)}
+			}
+		}
+		ds = append(ds, d)
+	}
+	return ds
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Exit statuses of the program, which are documented in the README so that wrappers can tell
+// failures apart.
+const (
+	exitUsage       = 1   // invalid arguments or configuration
+	exitOpen        = 2   // a file, database or server could not be opened or reached
+	exitStrict      = 3   // unknown events with --strict-fail, or warnings found by validate
+	exitWrite       = 4   // the output could not be written
+	exitParse       = 5   // a log could not be parsed
+	exitPartial     = 6   // with --resync or --lenient, parts of the logs were skipped
+	exitSink        = 7   // some matches could not be delivered to the sinks
+	exitInterrupted = 130 // interrupted with Ctrl-C or SIGTERM
+)
+
+// exitKinds names the exit statuses in the reports of --errors-json.
+var exitKinds = map[int]string{
+	exitUsage:       "usage",
+	exitOpen:        "open",
+	exitStrict:      "strict",
+	exitWrite:       "write",
+	exitParse:       "parse",
+	exitPartial:     "partial",
+	exitSink:        "sink",
+	exitInterrupted: "interrupted",
+}
+
+// errorReport is what --errors-json writes when the program fails.
+type errorReport struct {
+	ExitCode int    `json:"exit_code"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+}
+
+// handleExitError is the cli.ExitErrHandler of the application. It writes the report of
+// --errors-json, if requested, before exiting with the status of err. Errors without a status
+// are returned by the application and make it exit with status 1.
+func handleExitError(c *cli.Context, err error) {
+	code := exitUsage
+	var exitCoder cli.ExitCoder
+	if errors.As(err, &exitCoder) {
+		code = exitCoder.ExitCode()
+	}
+
+	if filePath := c.Path("errors-json"); filePath != "" {
+		report, _ := json.Marshal(errorReport{
+			ExitCode: code,
+			Kind:     cmp.Or(exitKinds[code], "error"),
+			Message:  err.Error(),
+		})
+		if err := os.WriteFile(filePath, append(report, '\n'), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write error report: %s\n", err)
+		}
+	}
+	cli.HandleExitCoder(err)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// followCommand returns the "follow" subcommand, which parses a log as the server writes it.
+func followCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "follow",
+		Usage:     "Parses a log as the server writes it, writing each match as soon as it ends.",
+		ArgsUsage: "<file | ssh://user@host/path>",
+		Description: "Reads the log from its start and then waits for new lines, like tail -f, until interrupted. " +
+			"Each match is written as a line of JSON once it ends, and announced to the sinks of the --config " +
+			"file. Logs which are truncated or replaced, as by log rotation, are followed from their start. " +
+			"Logs on game servers are followed over SSH, with tail -F run by the system's ssh client.",
+		Flags: append(append(inputFlags(), matchFlags()...),
+			&cli.PathFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "append the matches to `FILE` instead of writing them to stdout",
+			},
+		),
+		Action: follow,
+	}
+}
+
+// followConflicts lists the flags of the "parse" subcommand which --follow cannot be used
+// with, as they need the logs to end.
+var followConflicts = []string{
+	"jobs", "mmap", "dedupe", "event-stats", "audit-log", "split-output", "include-raw", "dry-run",
+	"gzip", "zstd",
+}
+
+// checkFollow checks the flags --follow is used with. Its errors are ready to be returned from
+// a cli.ActionFunc.
+func checkFollow(c *cli.Context) error {
+	for _, name := range followConflicts {
+		if c.IsSet(name) {
+			return cli.Exit(fmt.Sprintf("--follow cannot be used with --%s", name), exitUsage)
+		}
+	}
+	if c.String("format") != "json" {
+		return cli.Exit("--follow only writes JSON", exitUsage)
+	}
+	return nil
+}
+
+// follow is the action of the "follow" subcommand, and of --follow.
+func follow(c *cli.Context) error {
+	files, err := inputFiles(c)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		cli.ShowSubcommandHelpAndExit(c, exitUsage)
+	}
+
+	config, fileConfig, err := newParseConfig(c, qlp.Options{
+		MaxMatchEntries: c.Int("max-match-entries"),
+		MeansCategories: c.Bool("means-categories"),
+		Powerups:        c.Bool("powerups"),
+		Pings:           c.Bool("pings"),
+		Accuracy:        c.Bool("accuracy"),
+		ItemControl:     c.Bool("item-control"),
+		Votes:           c.Bool("votes"),
+		AdminActions:    c.Bool("admin-actions"),
+	})
+	if err != nil {
+		return err
+	}
+	sinks, err := fileConfig.sinks()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+	}
+
+	var w io.Writer = c.App.Writer
+	if filePath := c.Path("output"); filePath != "" {
+		file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to open output file: %s", err), exitOpen)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	filePath := files[0]
+	file, err := openFollowed(ctx, filePath)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to open file: %s", err), exitOpen)
+	}
+	defer file.Close()
+
+	warnings := newWarningReport(filePath)
+	config.opts.OnWarning = func(warning qlp.ParseWarning) {
+		warnings.add(warning)
+		warnings.print(os.Stderr)
+		warnings = newWarningReport(filePath)
+	}
+
+	// matches are written one per line, as the output never ends
+	encoder := json.NewEncoder(w)
+	encoded, sinkFailures := 0, 0
+	matches, errs := qlp.NewParser(config.opts).Follow(ctx, file)
+	for match := range matches {
+		encoded++
+		sinkFailures += sinks.send(ctx, encoded, match)
+		if err := encoder.Encode(match); err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to write game data: %s", err), exitWrite)
+		}
+	}
+	if err := <-errs; err != nil && ctx.Err() == nil { // being interrupted is how following ends
+		return cli.Exit(fmt.Sprintf("Failed to follow file %s: %s", filePath, err), exitParse)
+	}
+	if sinkFailures > 0 {
+		return cli.Exit(fmt.Sprintf("Failed %d deliveries to sinks", sinkFailures), exitSink)
+	}
+	return nil
+}
+
+// openFollowed opens the log at path to be followed: a local file, or a log on a game server
+// given as an ssh:// URL. Logs downloaded over HTTP or from S3 cannot be followed.
+func openFollowed(ctx context.Context, path string) (io.ReadCloser, error) {
+	if strings.HasPrefix(path, "ssh://") {
+		return openSSHTail(ctx, path)
+	}
+	if isRemote(path) {
+		return nil, fmt.Errorf("only local files and ssh:// URLs can be followed, not %s", path)
+	}
+	if qlp.IsCompressed(path) {
+		// archived logs do not grow, but are followed from a decompressed copy all the same
+		return openLog(ctx, path, true)
+	}
+	return os.Open(path)
+}
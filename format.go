@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/encode"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// writeFormatted writes v to w in the format with the given name. Only tabular values can be
+// written in the tabular formats.
+func writeFormatted(w io.Writer, format string, v any) error {
+	formatter, ok := qlpformat.Lookup(format)
+	if !ok {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	err := formatter.Format(w, v)
+	if errors.Is(err, qlpformat.ErrNotTabular) {
+		return fmt.Errorf("this output cannot be written as %s", format)
+	}
+	return err
+}
+
+// formatFloat formats the decimal numbers of tables.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// matchEncoder writes matches one at a time, as qlp.MatchEncoder does.
+type matchEncoder interface {
+	Encode(match qlp.Match) error
+	Close() error
+}
+
+// newMatchEncoder returns an encoder writing matches to w in the given format. JSON and YAML
+// are streamed, while other formats are given a table of the matches.
+func newMatchEncoder(w io.Writer, format string) matchEncoder {
+	switch format {
+	case "json":
+		return qlp.NewMatchEncoder(w, "  ")
+	case "yaml":
+		return &yamlEncoder{w: w}
+	}
+	return &tableEncoder{w: w, format: format, t: encode.MatchesTable(nil)}
+}
+
+// yamlEncoder writes matches as YAML, as soon as each is encoded, keyed "game_1", "game_2",
+// ... as in JSON.
+type yamlEncoder struct {
+	w       io.Writer
+	matches int
+}
+
+// Encode writes the match.
+func (e *yamlEncoder) Encode(match qlp.Match) error {
+	e.matches++
+	return writeFormatted(e.w, "yaml", map[string]qlp.Match{fmt.Sprintf("game_%d", e.matches): match})
+}
+
+// Close writes an empty mapping if there were no matches.
+func (e *yamlEncoder) Close() error {
+	if e.matches == 0 {
+		_, err := io.WriteString(e.w, "{}\n")
+		return err
+	}
+	return nil
+}
+
+// tableEncoder writes a row for each match in a tabular format, as encode.MatchesTable
+// lays them out. Rows are held until Close, as the width of the columns depends on every row.
+type tableEncoder struct {
+	w      io.Writer
+	format string
+	t      qlpformat.Table
+}
+
+// Encode adds a row for the match.
+func (e *tableEncoder) Encode(match qlp.Match) error {
+	e.t.Rows = append(e.t.Rows, encode.MatchRow(len(e.t.Rows)+1, match))
+	return nil
+}
+
+// Close writes the table.
+func (e *tableEncoder) Close() error {
+	return writeFormatted(e.w, e.format, e.t)
+}
+
+// eventCounts is the output of --event-stats.
+type eventCounts map[string]int
+
+// Table lists the event types from the most to the least frequent.
+func (counts eventCounts) Table() qlpformat.Table {
+	types := make([]string, 0, len(counts))
+	for typ := range counts {
+		types = append(types, typ)
+	}
+	slices.SortFunc(types, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	t := qlpformat.Table{Header: []string{"event", "count"}}
+	for _, typ := range types {
+		t.Rows = append(t.Rows, []string{typ, strconv.Itoa(counts[typ])})
+	}
+	return t
+}
+
+// groupEncoder writes the matches of each file under the path of the file, numbered from
+// "game_1" in each, for --group-by-file. The matches of a file are held until the next file
+// starts, so that they can be indented inside the file's key.
+type groupEncoder struct {
+	w       io.Writer
+	files   int
+	file    string
+	matches qlp.Matches
+}
+
+// startFile writes the matches of the previous file, the following matches being those of
+// the file at path.
+func (e *groupEncoder) startFile(path string) error {
+	if err := e.writeFile(); err != nil {
+		return err
+	}
+	e.file, e.matches = path, qlp.Matches{}
+	e.files++
+	return nil
+}
+
+// writeFile writes the matches of the current file, if any file was started.
+func (e *groupEncoder) writeFile() error {
+	if e.files == 0 {
+		return nil
+	}
+	key, err := json.Marshal(e.file)
+	if err != nil {
+		return err
+	}
+	matchesJSON, err := json.MarshalIndent(e.matches, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := "{\n  "
+	if e.files > 1 {
+		separator = ",\n  "
+	}
+	_, err = fmt.Fprintf(e.w, "%s%s: %s", separator, key, matchesJSON)
+	return err
+}
+
+// Encode adds the match to those of the current file.
+func (e *groupEncoder) Encode(match qlp.Match) error {
+	e.matches = append(e.matches, match)
+	return nil
+}
+
+// Close writes the matches of the last file and terminates the JSON object.
+func (e *groupEncoder) Close() error {
+	if e.files == 0 {
+		_, err := io.WriteString(e.w, "{}")
+		return err
+	}
+	if err := e.writeFile(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n}")
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// geoIP finds the countries of IP addresses in a MaxMind DB file, such as GeoLite2 Country,
+// supplied by the user.
+type geoIP struct {
+	reader *maxminddb.Reader
+}
+
+// openGeoIP opens the MaxMind DB file at filePath.
+func openGeoIP(filePath string) (*geoIP, error) {
+	reader, err := maxminddb.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &geoIP{reader: reader}, nil
+}
+
+// Close closes the database.
+func (g *geoIP) Close() error {
+	return g.reader.Close()
+}
+
+// country returns the ISO 3166-1 code of the country of ip, such as "BR", or an empty string
+// if the database does not know it.
+func (g *geoIP) country(ip string) string {
+	address := net.ParseIP(ip)
+	if address == nil {
+		return ""
+	}
+
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if err := g.reader.Lookup(address, &record); err != nil {
+		return ""
+	}
+	return record.Country.ISOCode
+}
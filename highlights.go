@@ -0,0 +1,143 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// Thresholds of the highlights.
+const (
+	// multiKillGap is the most seconds between two kills of a multi-kill. Quake III Arena
+	// awards "Excellent" for two kills within 2 seconds; a logged second more allows for the
+	// rounding of the timestamps.
+	multiKillGap = 3
+	// minStreakEnded is the shortest kill streak whose end is a highlight.
+	minStreakEnded = 5
+	// airRocketGap is the most seconds between a rocket kill and the death of its killer by
+	// falling for the kill to be an air rocket, which covers the fall of a rocket jump.
+	airRocketGap = 2
+)
+
+// highlight is a notable moment found by findHighlights.
+type highlight struct {
+	Match int    `json:"match"` // 1-indexed, as in the "game_N" keys
+	Map   string `json:"map"`
+	// Time is when the moment started, in seconds since the server started, as logged, and
+	// MatchTime is the same in seconds since the match started, which is where demos of the
+	// match are scrubbed to.
+	Time      int    `json:"time"`
+	MatchTime int    `json:"match_time"`
+	Kind      string `json:"kind"` // multi_kill, streak_ended or air_rocket
+	Player    string `json:"player"`
+	// Victims lists the players killed in the moment, in order.
+	Victims []string `json:"victims"`
+	// Streak is the length of the kill streak ended, for streak_ended.
+	Streak int `json:"streak,omitempty"`
+}
+
+// highlights is the output of the "highlights" report.
+type highlights []highlight
+
+// Table lists one highlight per row, in order of match and time.
+func (hs highlights) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"match", "map", "time", "match_time", "kind", "player", "victims", "streak"}}
+	for _, h := range hs {
+		t.Rows = append(t.Rows, []string{
+			strconv.Itoa(h.Match), h.Map, formatClock(h.Time), formatClock(h.MatchTime), h.Kind, h.Player,
+			strings.Join(h.Victims, ", "), strconv.Itoa(h.Streak),
+		})
+	}
+	return t
+}
+
+// formatClock formats seconds as the timestamps of the logs do, such as "20:37".
+func formatClock(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// findHighlights finds the notable moments of the matches: multi-kills, kills within
+// multiKillGap seconds of each other, which end when the player dies; the ends of kill streaks
+// of at least minStreakEnded kills; and air rockets. The logs do not tell where players were,
+// so air rockets are inferred from sequences of kills: a rocket kill followed, within
+// airRocketGap seconds, by the death of its killer by falling or by a trigger_hurt, such as
+// into the void, was shot by a player in the air, as after a rocket jump. It needs the kill
+// feed of the matches.
+func findHighlights(matches qlp.Matches) highlights {
+	found := highlights{}
+	for i, match := range matches {
+		start := len(found)
+		add := func(kind, player string, kill qlp.Kill, victims []string, streak int) {
+			found = append(found, highlight{
+				Match: i + 1, Map: match.MapName, Time: kill.Time, MatchTime: kill.Time - match.StartTime,
+				Kind: kind, Player: player, Victims: victims, Streak: streak,
+			})
+		}
+
+		// multi is the multi-kill each player is scoring, which is a highlight once it has two
+		// kills and ends
+		multi := make(map[string][]qlp.Kill)
+		endMulti := func(player string) {
+			if kills := multi[player]; len(kills) >= 2 {
+				victims := make([]string, len(kills))
+				for j, kill := range kills {
+					victims[j] = kill.Victim
+				}
+				add("multi_kill", player, kills[0], victims, 0)
+			}
+			delete(multi, player)
+		}
+
+		streaks := make(map[string]int)
+		for j, kill := range match.KillFeed {
+			endMulti(kill.Victim)
+			frag := kill.Killer != "<world>" && kill.Killer != kill.Victim
+			if frag && streaks[kill.Victim] >= minStreakEnded {
+				add("streak_ended", kill.Killer, kill, []string{kill.Victim}, streaks[kill.Victim])
+			}
+			streaks[kill.Victim] = 0
+			if !frag {
+				continue
+			}
+			streaks[kill.Killer]++
+
+			if isRocket(kill.Means) && fellAfter(match.KillFeed[j+1:], kill) {
+				add("air_rocket", kill.Killer, kill, []string{kill.Victim}, 0)
+			}
+			if kills := multi[kill.Killer]; len(kills) > 0 && kill.Time-kills[len(kills)-1].Time > multiKillGap {
+				endMulti(kill.Killer)
+			}
+			multi[kill.Killer] = append(multi[kill.Killer], kill)
+		}
+		for _, player := range sortedKeys(multi) {
+			endMulti(player)
+		}
+		// multi-kills are only found once they end
+		slices.SortStableFunc(found[start:], func(a, b highlight) int { return cmp.Compare(a.Time, b.Time) })
+	}
+	return found
+}
+
+// isRocket reports whether the means of death is a rocket, hitting directly or by splash.
+func isRocket(means string) bool {
+	return means == "MOD_ROCKET" || means == "MOD_ROCKET_SPLASH"
+}
+
+// fellAfter reports whether the killer of kill died by falling or by a trigger_hurt within
+// airRocketGap seconds of it, as told by the kills following it.
+func fellAfter(following []qlp.Kill, kill qlp.Kill) bool {
+	for _, next := range following {
+		if next.Time-kill.Time > airRocketGap {
+			return false
+		}
+		if next.Victim == kill.Killer {
+			return next.Means == "MOD_FALLING" || next.Means == "MOD_TRIGGER_HURT"
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixtureSeparator is the line ending matches in the fixture logs.
+const fixtureSeparator = "------------------------------------------------------------"
+
+// parseFixture parses a fixture log along with its kill feed, which the reports need.
+func parseFixture(t *testing.T, log string) qlp.Matches {
+	t.Helper()
+	matches, _, err := qlp.ParseLogWithOptions(strings.NewReader(log), qlp.Options{KillFeed: true})
+	assert.NoError(t, err)
+	return matches
+}
+
+// highlightsLog holds a match with a multi-kill, a direct rocket hit from the ground and the
+// end of a streak, a match ending in the middle of a multi-kill, and a match with air rockets
+// and kills around a death which are no multi-kill.
+const highlightsLog = "  1:00 InitGame: \\mapname\\q3dm17\n" +
+	"  1:10 Kill: 2 3 7: Isgalamido killed Zeh by MOD_ROCKET_SPLASH\n" +
+	"  1:12 Kill: 2 4 7: Isgalamido killed Mocinha by MOD_ROCKET_SPLASH\n" +
+	"  1:20 Kill: 2 3 6: Isgalamido killed Zeh by MOD_ROCKET\n" +
+	"  1:30 Kill: 2 4 10: Isgalamido killed Mocinha by MOD_RAILGUN\n" +
+	"  1:40 Kill: 2 3 10: Isgalamido killed Zeh by MOD_RAILGUN\n" +
+	"  1:50 Kill: 1022 3 22: <world> killed Zeh by MOD_TRIGGER_HURT\n" +
+	"  2:00 Kill: 3 2 10: Zeh killed Isgalamido by MOD_RAILGUN\n" +
+	"  2:05 ShutdownGame:\n" +
+	"  2:05 " + fixtureSeparator + "\n" +
+	"  0:00 InitGame: \\mapname\\q3dm6\n" +
+	"  0:05 Kill: 3 4 2: Zeh killed Mocinha by MOD_SHOTGUN\n" +
+	"  0:07 Kill: 3 2 2: Zeh killed Isgalamido by MOD_SHOTGUN\n" +
+	"  0:08 Kill: 3 5 2: Zeh killed Dono da Bola by MOD_SHOTGUN\n" +
+	"  0:08 " + fixtureSeparator + "\n" +
+	"  0:00 InitGame: \\mapname\\q3dm13\n" +
+	"  0:10 Kill: 2 3 6: Isgalamido killed Zeh by MOD_ROCKET\n" +
+	"  0:11 Kill: 1022 2 19: <world> killed Isgalamido by MOD_FALLING\n" +
+	"  0:20 Kill: 3 2 6: Zeh killed Isgalamido by MOD_ROCKET\n" +
+	"  0:30 Kill: 3 4 7: Zeh killed Mocinha by MOD_ROCKET_SPLASH\n" +
+	"  0:32 Kill: 1022 3 22: <world> killed Zeh by MOD_TRIGGER_HURT\n" +
+	"  0:40 Kill: 2 4 10: Isgalamido killed Mocinha by MOD_RAILGUN\n" +
+	"  0:41 Kill: 3 2 10: Zeh killed Isgalamido by MOD_RAILGUN\n" +
+	"  0:42 Kill: 2 3 10: Isgalamido killed Zeh by MOD_RAILGUN\n" +
+	"  0:45 " + fixtureSeparator + "\n"
+
+func TestFindHighlights(t *testing.T) {
+	found := findHighlights(parseFixture(t, highlightsLog))
+	assert.Equal(t, highlights{
+		{Match: 1, Map: "q3dm17", Time: 70, MatchTime: 10, Kind: "multi_kill", Player: "Isgalamido", Victims: []string{"Zeh", "Mocinha"}},
+		{Match: 1, Map: "q3dm17", Time: 120, MatchTime: 60, Kind: "streak_ended", Player: "Zeh", Victims: []string{"Isgalamido"}, Streak: 5},
+		{Match: 2, Map: "q3dm6", Time: 5, MatchTime: 5, Kind: "multi_kill", Player: "Zeh", Victims: []string{"Mocinha", "Isgalamido", "Dono da Bola"}},
+		{Match: 3, Map: "q3dm13", Time: 10, MatchTime: 10, Kind: "air_rocket", Player: "Isgalamido", Victims: []string{"Zeh"}},
+		{Match: 3, Map: "q3dm13", Time: 30, MatchTime: 30, Kind: "air_rocket", Player: "Zeh", Victims: []string{"Mocinha"}},
+	}, found)
+
+	table := found.Table()
+	assert.Equal(t, []string{"1", "q3dm17", "1:10", "0:10", "multi_kill", "Isgalamido", "Zeh, Mocinha", "0"}, table.Rows[0])
+}
+
+func TestFindHighlightsWithoutKillFeed(t *testing.T) {
+	matches, err := qlp.ParseLog(strings.NewReader(highlightsLog))
+	assert.NoError(t, err)
+	assert.Empty(t, findHighlights(matches))
+}
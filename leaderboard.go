@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+)
+
+// leaderboard is the output of the "leaderboard" report.
+type leaderboard []qlpstats.Standing
+
+// Table lists one player per row, in order of rank.
+func (l leaderboard) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"rank", "player", "kills", "matches", "average_kills", "best_game", "best_score"}}
+	for _, s := range l {
+		t.Rows = append(t.Rows, []string{
+			strconv.Itoa(s.Rank), s.Player, strconv.Itoa(s.Kills), strconv.Itoa(s.Matches),
+			formatFloat(s.AverageKills), strconv.Itoa(s.BestGame), strconv.Itoa(s.BestScore),
+		})
+	}
+	return t
+}
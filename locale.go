@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// locale holds the translations of the text written in reports.
+type locale struct {
+	// messages maps the English format strings to their translations. Formats without a
+	// translation are used as they are.
+	messages map[string]string
+	// means maps the means of death, such as "MOD_ROCKET", to their display names.
+	means map[string]string
+}
+
+// defaultLocale is the locale used when no other one is selected.
+const defaultLocale = "en"
+
+// locales holds every supported locale, keyed by its BCP 47 tag.
+var locales = map[string]*locale{
+	"en": {
+		means: map[string]string{
+			"MOD_UNKNOWN":        "Unknown",
+			"MOD_SHOTGUN":        "Shotgun",
+			"MOD_GAUNTLET":       "Gauntlet",
+			"MOD_MACHINEGUN":     "Machine Gun",
+			"MOD_GRENADE":        "Grenade Launcher",
+			"MOD_GRENADE_SPLASH": "Grenade Launcher (splash)",
+			"MOD_ROCKET":         "Rocket Launcher",
+			"MOD_ROCKET_SPLASH":  "Rocket Launcher (splash)",
+			"MOD_PLASMA":         "Plasma Gun",
+			"MOD_PLASMA_SPLASH":  "Plasma Gun (splash)",
+			"MOD_RAILGUN":        "Railgun",
+			"MOD_LIGHTNING":      "Lightning Gun",
+			"MOD_BFG":            "BFG10K",
+			"MOD_BFG_SPLASH":     "BFG10K (splash)",
+			"MOD_WATER":          "Drowning",
+			"MOD_SLIME":          "Slime",
+			"MOD_LAVA":           "Lava",
+			"MOD_CRUSH":          "Crushed",
+			"MOD_TELEFRAG":       "Telefrag",
+			"MOD_FALLING":        "Falling",
+			"MOD_SUICIDE":        "Suicide",
+			"MOD_TARGET_LASER":   "Laser",
+			"MOD_TRIGGER_HURT":   "Map hazard",
+			"MOD_NAIL":           "Nailgun",
+			"MOD_CHAINGUN":       "Chaingun",
+			"MOD_PROXIMITY_MINE": "Proximity Mine",
+			"MOD_KAMIKAZE":       "Kamikaze",
+			"MOD_JUICED":         "Juiced",
+			"MOD_GRAPPLE":        "Grappling Hook",
+		},
+	},
+	"pt-BR": {
+		messages: map[string]string{
+			"%d kills within %d seconds":                                        "%d abates em %d segundos",
+			"%d railgun kills within %d seconds, faster than the railgun fires": "%d abates com a railgun em %d segundos, mais rápido do que a railgun dispara",
+			"%.1f kills per minute over %d kills":                               "%.1f abates por minuto em %d abates",
+		},
+		means: map[string]string{
+			"MOD_UNKNOWN":        "Desconhecido",
+			"MOD_SHOTGUN":        "Escopeta",
+			"MOD_GAUNTLET":       "Manopla",
+			"MOD_MACHINEGUN":     "Metralhadora",
+			"MOD_GRENADE":        "Lança-granadas",
+			"MOD_GRENADE_SPLASH": "Lança-granadas (explosão)",
+			"MOD_ROCKET":         "Lança-foguetes",
+			"MOD_ROCKET_SPLASH":  "Lança-foguetes (explosão)",
+			"MOD_PLASMA":         "Arma de plasma",
+			"MOD_PLASMA_SPLASH":  "Arma de plasma (explosão)",
+			"MOD_RAILGUN":        "Railgun",
+			"MOD_LIGHTNING":      "Arma de raios",
+			"MOD_BFG":            "BFG10K",
+			"MOD_BFG_SPLASH":     "BFG10K (explosão)",
+			"MOD_WATER":          "Afogamento",
+			"MOD_SLIME":          "Lodo",
+			"MOD_LAVA":           "Lava",
+			"MOD_CRUSH":          "Esmagamento",
+			"MOD_TELEFRAG":       "Telefrag",
+			"MOD_FALLING":        "Queda",
+			"MOD_SUICIDE":        "Suicídio",
+			"MOD_TARGET_LASER":   "Laser",
+			"MOD_TRIGGER_HURT":   "Armadilha do mapa",
+			"MOD_NAIL":           "Pregadora",
+			"MOD_CHAINGUN":       "Metralhadora giratória",
+			"MOD_PROXIMITY_MINE": "Mina de proximidade",
+			"MOD_KAMIKAZE":       "Kamikaze",
+			"MOD_JUICED":         "Juiced",
+			"MOD_GRAPPLE":        "Gancho",
+		},
+	},
+}
+
+// sprintf formats according to the translation of format.
+func (l *locale) sprintf(format string, args ...any) string {
+	if translated, ok := l.messages[format]; ok {
+		format = translated
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// meansName returns the display name of a means of death, or the means itself if it has no
+// display name, as happens with those introduced by mods.
+func (l *locale) meansName(means string) string {
+	if name, ok := l.means[means]; ok {
+		return name
+	}
+	return means
+}
+
+// localeNames returns the tags of the supported locales, sorted.
+func localeNames() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// findLocale returns the locale selected by lang, such as "pt-BR". If lang is empty, the
+// locale is taken from the environment, as with "LANG=pt_BR.UTF-8", falling back to English
+// when the environment names no supported locale.
+func findLocale(lang string) (*locale, error) {
+	if lang != "" {
+		if loc := matchLocale(lang); loc != nil {
+			return loc, nil
+		}
+		return nil, fmt.Errorf("unsupported language %q, the supported ones being %s",
+			lang, strings.Join(localeNames(), ", "))
+	}
+
+	for _, variable := range [...]string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(variable); value != "" {
+			if loc := matchLocale(value); loc != nil {
+				return loc, nil
+			}
+			break
+		}
+	}
+	return locales[defaultLocale], nil
+}
+
+// matchLocale returns the locale matching a BCP 47 tag or a POSIX locale name, first by the
+// whole tag and then by its language alone, or nil if there is none.
+func matchLocale(name string) *locale {
+	// POSIX locale names, such as "pt_BR.UTF-8@euro", are turned into tags
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	name = strings.ReplaceAll(name, "_", "-")
+
+	language, _, _ := strings.Cut(name, "-")
+	for _, tag := range localeNames() {
+		if strings.EqualFold(tag, name) {
+			return locales[tag]
+		}
+	}
+	for _, tag := range localeNames() {
+		tagLanguage, _, _ := strings.Cut(tag, "-")
+		if strings.EqualFold(tagLanguage, language) {
+			return locales[tag]
+		}
+	}
+	return nil
+}
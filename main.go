@@ -1,8 +1,11 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 
@@ -13,41 +16,183 @@ import (
 func main() {
 	app := &cli.App{
 		Usage:           "Parses game data from a file and outputs it in JSON format.",
-		UsageText:       path.Base(os.Args[0]) + " [file]",
-		ArgsUsage:       "[file]",
-		Description:     "This program takes a file path as an argument, parses the game data contained within, and outputs the data in a nicely formatted JSON structure.",
+		UsageText:       path.Base(os.Args[0]) + " [command] [options] [file...]",
+		ArgsUsage:       "[file...]",
+		Description:     "This program takes file paths as arguments, parses the game data contained within, and outputs the data in a nicely formatted JSON structure. Matches from every file are merged in the given order.",
 		Args:            true,
 		HideHelpCommand: true,
-		Action: func(c *cli.Context) error {
-			if c.NArg() == 0 {
-				cli.ShowAppHelpAndExit(c, 1)
-			}
+		Commands: []*cli.Command{
+			parseCommand(), reportCommand(), rankCommand(), serveCommand(), followCommand(), validateCommand(),
+			completionCommand(), doctorCommand(), replCommand(), benchCommand(), migrateCommand(), rconCommand(),
+			snapshotCommand(), seasonCommand(), balanceCommand(),
+		},
+		// without a subcommand, the logs are parsed, as they were before there were any
+		Flags:          parseFlags(),
+		Action:         parse,
+		ExitErrHandler: handleExitError,
+	}
 
-			filePath := c.Args().Get(0)
-			file, err := os.Open(filePath)
-			if err != nil {
-				return cli.Exit(fmt.Sprintf("Failed to open file: %s", err), 2)
-			}
-			defer file.Close()
+	err := app.Run(os.Args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseConfig holds the settings that control how each file is parsed.
+type parseConfig struct {
+	opts qlp.Options
+	// jobs is the number of goroutines parsing each file. Anything other than 1 selects
+	// qlp.ParseLogParallel.
+	jobs int
+	// mmap selects reading the files through memory mappings.
+	mmap bool
+	// ctx, when set, interrupts parsing once it is cancelled. The match being parsed is then
+	// passed on as in progress and errInterrupted is returned. Parsing on several goroutines
+	// is not interrupted.
+	ctx context.Context
+	// audit, when set, receives an entry for every file parsed.
+	audit *auditLog
+	// progress selects drawing the progress of each file on stderr. Parsing on several
+	// goroutines has no progress drawn.
+	progress bool
+}
 
-			games, err := qlp.ParseLog(file)
-			if err != nil {
-				return fmt.Errorf("Failed to parse file: %s", err)
+// errInterrupted is returned by parseFile when parseConfig.ctx is cancelled.
+var errInterrupted = errors.New("parsing was interrupted")
+
+// parseFile opens and parses the log file at filePath according to config, calling fn with
+// each match. Its errors are ready to be returned from a cli.ActionFunc.
+func parseFile(filePath string, config parseConfig, fn func(qlp.Match) error) (err error) {
+	var entry *auditEntry
+	if config.audit != nil {
+		entry = &auditEntry{Path: filePath}
+		onWarning := config.opts.OnWarning
+		config.opts.OnWarning = func(warning qlp.ParseWarning) {
+			entry.Warnings++
+			if onWarning != nil {
+				onWarning(warning)
 			}
+		}
+		defer func() { config.audit.record(entry, err) }()
+	}
+
+	// parsing in parallel and mapping need to read the log at any offset
+	file, err := openLog(config.ctx, filePath, config.mmap || config.jobs != 1)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to open file: %s", err), exitOpen)
+	}
+	defer file.Close()
 
-			jsonOutput, err := json.MarshalIndent(games, "", "  ")
-			if err != nil {
-				return cli.Exit(fmt.Sprintf("Failed to marshal game data: %s", err), 4)
+	var fnErr error
+	callback := func(match qlp.Match) error {
+		if entry != nil {
+			entry.Matches++
+		}
+		fnErr = fn(match)
+		return fnErr
+	}
+
+	var input logInput
+	if config.mmap {
+		data, unmap, err := mapFile(file.File)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to map file: %s", err), exitOpen)
+		}
+		defer unmap()
+		input = bytes.NewReader(data)
+	} else if file.seekable() {
+		input = file.File
+	}
+	if entry != nil && input != nil {
+		// streams cannot be read twice, so they are recorded without a hash
+		entry.Bytes, entry.SHA256, _ = hashInput(input)
+	}
+
+	var reader io.Reader = file
+	if input != nil {
+		reader = input
+	}
+	if config.progress && config.jobs == 1 {
+		size := int64(-1)
+		if input != nil {
+			size = inputSize(input)
+		}
+		progress := startProgress(os.Stderr, filePath, size)
+		defer progress.stop()
+		reader = progress.reader(input)
+		counted := callback
+		callback = func(match qlp.Match) error {
+			progress.addMatch()
+			return counted(match)
+		}
+	}
+
+	if config.jobs == 1 && config.ctx != nil {
+		parser := qlp.NewParser(config.opts)
+		err = parser.ParseFunc(contextReader{ctx: config.ctx, r: reader}, callback)
+		if config.ctx.Err() != nil && fnErr == nil {
+			if match, ok := parser.OpenMatch(); ok {
+				callback(match)
+			}
+			if fnErr == nil {
+				return errInterrupted
 			}
-			os.Stdout.Write(jsonOutput)
+		}
+	} else if config.jobs == 1 {
+		err = qlp.ParseLogFunc(reader, config.opts, callback)
+	} else if input == nil {
+		err = errors.New("pipes cannot be parsed in parallel")
+	} else {
+		err = parseFileParallel(input, config, callback)
+	}
+	if fnErr != nil {
+		return cli.Exit(fmt.Sprintf("Failed to write game data: %s", fnErr), exitWrite)
+	}
+	if err != nil {
+		return cli.Exit(fmt.Errorf("Failed to parse file %s: %w", filePath, err), exitParse)
+	}
+	return nil
+}
 
-			return nil
-		},
+// contextReader is a reader which fails with the error of its context once it is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads from the underlying reader, unless the context is cancelled.
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
 	}
+	return r.r.Read(p)
+}
 
-	err := app.Run(os.Args)
+// logInput is the contents of a log file, either the file itself or its memory mapping.
+type logInput interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// parseFileParallel parses input with qlp.ParseLogParallel, calling fn with each match once
+// the whole input has been parsed.
+func parseFileParallel(input logInput, config parseConfig, fn func(qlp.Match) error) error {
+	size, err := input.Seek(0, io.SeekEnd)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+
+	matches, err := qlp.ParseLogParallel(input, size, config.opts, config.jobs)
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		if err := fn(match); err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/klauspost/compress/zstd"
+	"github.com/urfave/cli/v2"
+)
+
+// migrateCommand returns the "migrate" subcommand, which upgrades saved outputs to the current
+// schema version.
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "migrate",
+		Usage:     "Upgrades saved outputs to the current schema version.",
+		ArgsUsage: "<file...>",
+		Description: fmt.Sprintf("Reads outputs written by earlier versions, either of every match or of a single one "+
+			"as written by --split-output, and writes them with schema version %d. Files ending in .gz or .zst are "+
+			"decompressed, and compressed again when rewritten with --in-place. Data which earlier versions did not "+
+			"record is left empty.", qlp.SchemaVersion),
+		Flags: []cli.Flag{
+			&cli.PathFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "write the upgraded output to `FILE` instead of stdout",
+			},
+			&cli.BoolFlag{
+				Name:  "in-place",
+				Usage: "replace each file with its upgraded version, instead of writing a single one",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 || c.NArg() > 1 && !c.Bool("in-place") {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+			if c.Bool("in-place") && c.Path("output") != "" {
+				return cli.Exit("Only one of --in-place and --output may be given", exitUsage)
+			}
+
+			for _, filePath := range c.Args().Slice() {
+				upgraded, err := migrateFile(filePath)
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to migrate %s: %s", filePath, err), exitOpen)
+				}
+
+				destination := c.Path("output")
+				if c.Bool("in-place") {
+					destination = filePath
+				}
+				if err := writeMigrated(c.App.Writer, destination, upgraded); err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to write %s: %s", cmp.Or(destination, "output"), err), exitWrite)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migrateFile reads the output at filePath and returns it upgraded to the current schema.
+func migrateFile(filePath string) (any, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	switch filepath.Ext(filePath) {
+	case ".gz":
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		r = gzipReader
+	case ".zst":
+		zstdReader, err := zstd.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer zstdReader.Close()
+		r = zstdReader
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the output of a single match is told apart by its fields, which the object of every
+	// match, keyed by game, never has
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	if _, single := object["total_kills"]; single {
+		return qlp.MigrateMatch(data)
+	}
+	return qlp.MigrateMatches(data)
+}
+
+// writeMigrated writes the upgraded output to the file at destination, compressed according to
+// its extension, or to w if destination is empty. Files are replaced atomically, so that an
+// archive is never left with a partially written file.
+func writeMigrated(w io.Writer, destination string, upgraded any) error {
+	if destination == "" {
+		return writeFormatted(w, "json", upgraded)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(destination), ".qlp-migrate-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if info, err := os.Stat(destination); err == nil {
+		file.Chmod(info.Mode())
+	}
+
+	out := &output{Writer: file, format: "json"}
+	out.push(file, file)
+	ext := filepath.Ext(destination)
+	if err := out.bufferAndCompress(ext == ".gz", ext == ".zst"); err != nil {
+		out.Close()
+		return err
+	}
+	if err := writeFormatted(out, "json", upgraded); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), destination)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is not supported on this platform, so callers fall back to regular reads.
+func mapFile(file *os.File) (data []byte, unmap func() error, err error) {
+	return nil, nil, errors.New("memory-mapped files are not supported on this platform")
+}
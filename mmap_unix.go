@@ -0,0 +1,28 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the whole file into memory, read-only. Reading the mapping costs no syscalls
+// and allows random access to any part of the file, which suits large logs. The returned
+// function unmaps the file; data must not be used after it is called.
+func mapFile(file *os.File) (data []byte, unmap func() error, err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		// empty files cannot be mapped
+		return []byte{}, func() error { return nil }, nil
+	}
+
+	data, err = syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
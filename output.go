@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/klauspost/compress/zstd"
+	"github.com/urfave/cli/v2"
+)
+
+// outputFlags are the flags which control where and how the output is written.
+var outputFlags = []cli.Flag{
+	&cli.PathFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "write the output to `FILE` instead of stdout",
+	},
+	&cli.StringFlag{
+		Name:  "format",
+		Value: "json",
+		Usage: "write the output as " + strings.Join(qlpformat.Names(), ", "),
+	},
+	&cli.BoolFlag{
+		Name:  "gzip",
+		Usage: "compress the output with gzip",
+	},
+	&cli.BoolFlag{
+		Name:  "zstd",
+		Usage: "compress the output with zstd",
+	},
+}
+
+// output is the destination of the program's output. Everything written to it is buffered
+// and, if requested, compressed; Close must be called to flush it.
+type output struct {
+	io.Writer
+	closers []io.Closer
+	// format is the format selected with --format.
+	format string
+}
+
+// openOutput opens the output described by the outputFlags. Its errors are ready to be
+// returned from a cli.ActionFunc.
+func openOutput(c *cli.Context) (*output, error) {
+	if err := checkOutput(c); err != nil {
+		return nil, err
+	}
+
+	out := &output{Writer: os.Stdout, format: c.String("format")}
+	if filePath := c.Path("output"); filePath != "" {
+		file, err := os.Create(filePath)
+		if err != nil {
+			return nil, cli.Exit(fmt.Sprintf("Failed to create output file: %s", err), exitOpen)
+		}
+		out.push(file, file)
+	}
+
+	if err := out.bufferAndCompress(c.Bool("gzip"), c.Bool("zstd")); err != nil {
+		out.Close()
+		return nil, cli.Exit(fmt.Sprintf("Failed to start zstd compression: %s", err), exitWrite)
+	}
+	return out, nil
+}
+
+// checkOutput checks the outputFlags. Its errors are ready to be returned from a
+// cli.ActionFunc.
+func checkOutput(c *cli.Context) error {
+	if c.Bool("gzip") && c.Bool("zstd") {
+		return cli.Exit("Only one of --gzip and --zstd may be given", exitUsage)
+	}
+	format := c.String("format")
+	if _, ok := qlpformat.Lookup(format); !ok {
+		return cli.Exit(fmt.Sprintf("Invalid format: %s", format), exitUsage)
+	}
+	return nil
+}
+
+// bufferAndCompress buffers the output and, if requested, compresses it with gzip or zstd.
+func (o *output) bufferAndCompress(gzipped, zstded bool) error {
+	buffered := bufio.NewWriter(o.Writer)
+	o.push(buffered, flusher{buffered})
+
+	switch {
+	case gzipped:
+		gzipWriter := gzip.NewWriter(o.Writer)
+		o.push(gzipWriter, gzipWriter)
+	case zstded:
+		zstdWriter, err := zstd.NewWriter(o.Writer)
+		if err != nil {
+			return err
+		}
+		o.push(zstdWriter, zstdWriter)
+	}
+	return nil
+}
+
+// push makes w the writer of the output, with closer being called when the output is closed,
+// before any writer pushed earlier.
+func (o *output) push(w io.Writer, closer io.Closer) {
+	o.Writer = w
+	o.closers = append(o.closers, closer)
+}
+
+// Close flushes and closes every layer of the output, from the outermost to the innermost.
+// Calling it more than once has no effect.
+func (o *output) Close() error {
+	var errs []error
+	for i := len(o.closers) - 1; i >= 0; i-- {
+		errs = append(errs, o.closers[i].Close())
+	}
+	o.closers = nil
+	return errors.Join(errs...)
+}
+
+// flusher adapts a bufio.Writer into an io.Closer which flushes it.
+type flusher struct {
+	*bufio.Writer
+}
+
+// Close flushes the underlying bufio.Writer.
+func (f flusher) Close() error {
+	return f.Flush()
+}
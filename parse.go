@@ -0,0 +1,521 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// inputFlags returns the flags which control how logs are read, shared by every subcommand
+// which parses logs and read by newParseConfig.
+func inputFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:  "config",
+			Usage: "read settings, such as extra event rules, clan tag patterns and sinks, from the YAML or JSON `FILE`",
+		},
+		&cli.IntFlag{
+			Name:  "max-line-length",
+			Usage: "fail on log lines longer than `BYTES` (default 65536)",
+		},
+		&cli.StringFlag{
+			Name:  "invalid-utf8",
+			Value: "windows1252",
+			Usage: "how to decode lines which are not valid UTF-8: windows1252 (also covers Latin-1), replace or strip",
+		},
+		&cli.BoolFlag{
+			Name:  "resync",
+			Usage: "skip corrupted regions of the logs, such as binary garbage left by crashes, up to the next match instead of failing",
+		},
+		&cli.BoolFlag{
+			Name:  "lenient",
+			Usage: "skip malformed lines, those without a timestamp, with a warning instead of failing, and go on parsing the match they are in",
+		},
+		&cli.IntFlag{
+			Name:  "max-errors",
+			Usage: "with --resync or --lenient, fail once more than `N` lines were skipped, as happens when parsing a file of another format",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "warn about events of types unknown to the parser, with their counts",
+		},
+		&cli.StringSliceFlag{
+			Name:  "world-entity",
+			Usage: "take kills by `[GAMENAME=]NAME` as deaths by <world>, for mods which name the world differently, in the matches of GAMENAME only if given; may be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "tz",
+			Usage: "read the g_timestamp setting of the matches, and write started_at, ended_at and the other RFC 3339 times, in the IANA time `ZONE`, such as UTC or America/Sao_Paulo (default local time)",
+		},
+		&cli.PathFlag{
+			Name:  "errors-json",
+			Usage: "on failure, write the exit status, its kind and the error message as JSON to `FILE`",
+		},
+	}
+}
+
+// fileFlags returns the flags which control how whole log files are read, shared by the
+// subcommands which parse a list of files.
+func fileFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "remote",
+			Usage: "also parse the log at `USER@HOST:PATH`, read over SSH with the system's ssh client, after the file arguments; may be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "mmap",
+			Usage: "read files through memory mappings instead of regular reads, where supported",
+		},
+		&cli.IntFlag{
+			Name:    "jobs",
+			Aliases: []string{"j"},
+			Value:   1,
+			Usage:   "parse each file on `N` goroutines, 0 meaning one per CPU; matches are then written only once the whole file is parsed",
+		},
+		&cli.BoolFlag{
+			Name:  "no-progress",
+			Usage: "do not show the progress of each file on stderr, which is otherwise shown when stderr is a terminal",
+		},
+	}
+}
+
+// matchFlags returns the flags which select the optional data of the matches written out.
+func matchFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "max-match-entries",
+			Usage: "keep at most `N` players, means of death and kill feed entries per match, marking larger matches as truncated",
+		},
+		&cli.BoolFlag{
+			Name:  "powerups",
+			Usage: "list the powerups, such as Quad Damage, each player picked up, along with when",
+		},
+		&cli.BoolFlag{
+			Name:  "pings",
+			Usage: "add the minimum, average and maximum ping of each player, from the score lines",
+		},
+		&cli.StringFlag{
+			Name:  "score-model",
+			Value: "classic",
+			Usage: "how kills are scored: classic, as the original specification, q3, taking a point off for suicides and teamkills as the game does, or custom, as the score_model of the --config file",
+		},
+		&cli.StringFlag{
+			Name:  "world-deaths",
+			Value: "decrement",
+			Usage: "how deaths by <world> count: decrement, taking a kill off the victim, count, adding world_deaths instead so kills are never negative, or both",
+		},
+		&cli.StringFlag{
+			Name:  "restarts",
+			Value: "separate",
+			Usage: "what to do with matches restarted with map_restart: separate, marking them with \"restart\", or merge them into the match they restart",
+		},
+		&cli.BoolFlag{
+			Name:  "item-control",
+			Usage: "add item_pickups, how many times each player took the Mega Health, the Red Armor and the Yellow Armor",
+		},
+		&cli.BoolFlag{
+			Name:  "votes",
+			Usage: "add the votes called in each match, with their ballots and results, from the callvote and vote lines",
+		},
+		&cli.BoolFlag{
+			Name:  "admin-actions",
+			Usage: "add admin_actions, the kicks, bans and mutes of the players in each match, from the lines admin mods log",
+		},
+		&cli.BoolFlag{
+			Name:  "accuracy",
+			Usage: "add the accuracy of each player with each weapon, from the Weapon_Stats lines of mods such as OSP and CPMA",
+		},
+		&cli.BoolFlag{
+			Name:  "means-categories",
+			Usage: "add kills_by_category, grouping the means of death into hitscan, explosive, environmental, melee and other",
+		},
+	}
+}
+
+// newParseConfig returns the parseConfig described by the inputFlags, and the fileFlags if
+// given, starting from opts, along with the loaded --config file. Its errors are ready to be
+// returned from a cli.ActionFunc.
+func newParseConfig(c *cli.Context, opts qlp.Options) (parseConfig, *fileConfig, error) {
+	switch mode := c.String("invalid-utf8"); mode {
+	case "windows1252":
+		opts.Decoding = qlp.DecodeWindows1252
+	case "replace":
+		opts.Decoding = qlp.DecodeReplace
+	case "strip":
+		opts.Decoding = qlp.DecodeStrip
+	default:
+		return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Invalid UTF-8 decoding: %s", mode), exitUsage)
+	}
+
+	switch mode := c.String("world-deaths"); mode {
+	case "", "decrement":
+		opts.WorldDeaths = qlp.WorldDeathsDecrement
+	case "count":
+		opts.WorldDeaths = qlp.WorldDeathsCount
+	case "both":
+		opts.WorldDeaths = qlp.WorldDeathsBoth
+	default:
+		return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Invalid world deaths mode: %s", mode), exitUsage)
+	}
+	switch mode := c.String("restarts"); mode {
+	case "", "separate":
+		opts.Restarts = qlp.RestartSeparate
+	case "merge":
+		opts.Restarts = qlp.RestartMerge
+	default:
+		return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Invalid restarts mode: %s", mode), exitUsage)
+	}
+
+	if zone := c.String("tz"); zone != "" {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Invalid time zone: %s", zone), exitUsage)
+		}
+		opts.TimeZone = loc
+	}
+
+	fileConfig, err := loadConfig(c.Path("config"))
+	if err != nil {
+		return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+	}
+	if opts.EventRules, err = fileConfig.eventRules(); err != nil {
+		return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+	}
+	opts.NameRules = fileConfig.nameRules()
+	opts.WorldEntities = fileConfig.WorldEntities
+	if c.IsSet("world-entity") {
+		opts.WorldEntities = parseWorldEntities(c.StringSlice("world-entity"))
+	}
+	switch model := c.String("score-model"); model {
+	case "", "classic":
+		opts.Scoring = qlp.ClassicScoring
+	case "q3":
+		opts.Scoring = qlp.Q3Scoring
+	case "custom":
+		if opts.Scoring, err = fileConfig.scoreModel(); err != nil {
+			return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+		}
+	default:
+		return parseConfig{}, nil, cli.Exit(fmt.Sprintf("Invalid score model: %s", model), exitUsage)
+	}
+
+	opts.MaxLineLength = c.Int("max-line-length")
+	opts.Resync = c.Bool("resync")
+	opts.Lenient = c.Bool("lenient")
+	opts.MaxErrors = c.Int("max-errors")
+	opts.Strict = opts.Strict || c.Bool("strict")
+	config := parseConfig{opts: opts, jobs: 1}
+	if c.IsSet("jobs") {
+		config.jobs = c.Int("jobs")
+	}
+	config.mmap = c.Bool("mmap")
+	config.progress = !c.Bool("no-progress") && isTerminal(os.Stderr)
+	return config, fileConfig, nil
+}
+
+// inputFiles returns the files given as arguments followed by those of --remote. Arguments
+// which are glob patterns, such as games_*.log, are expanded to the files they match in
+// lexical order, for shells which do not expand them and for patterns quoted on purpose.
+func inputFiles(c *cli.Context) ([]string, error) {
+	var files []string
+	for _, arg := range c.Args().Slice() {
+		matches, err := expandGlob(arg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	for _, remote := range c.StringSlice("remote") {
+		file, err := sshURL(remote)
+		if err != nil {
+			return nil, cli.Exit(err.Error(), exitUsage)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// expandGlob returns the files matched by arg if it is a glob pattern, or arg itself
+// otherwise. A file named as the pattern is taken as it is.
+func expandGlob(arg string) ([]string, error) {
+	if !strings.ContainsAny(arg, "*?[") {
+		return []string{arg}, nil
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return []string{arg}, nil
+	}
+	matches, err := filepath.Glob(arg)
+	if err != nil {
+		return nil, cli.Exit(fmt.Sprintf("Invalid pattern %s: %s", arg, err), exitUsage)
+	}
+	if len(matches) == 0 {
+		return nil, cli.Exit(fmt.Sprintf("No files match %s", arg), exitOpen)
+	}
+	return matches, nil // already sorted
+}
+
+// parseFlags returns the flags of the "parse" subcommand, which is also the default action of
+// the application.
+func parseFlags() []cli.Flag {
+	flags := append(inputFlags(), fileFlags()...)
+	flags = append(flags, matchFlags()...)
+	flags = append(flags,
+		&cli.StringFlag{
+			Name:  "dedupe",
+			Usage: "what to do with matches already seen in a previous file: drop or flag",
+		},
+		&cli.BoolFlag{
+			Name:  "strict-fail",
+			Usage: "like --strict, but also exit with status 3 if any unknown event is found",
+		},
+		&cli.BoolFlag{
+			Name:  "event-stats",
+			Usage: "output how many events of each type (InitGame, Kill, Item, say, ...) the logs have, instead of the matches",
+		},
+		&cli.PathFlag{
+			Name:  "audit-log",
+			Usage: "append a line of JSON for every file parsed (path, SHA-256 hash, size, matches, warnings and errors) to `FILE`",
+		},
+		&cli.BoolFlag{
+			Name:  "group-by-file",
+			Usage: "instead of merging the matches of every file, write them under the path of their file, numbered from game_1 in each",
+		},
+		&cli.BoolFlag{
+			Name:  "split-output",
+			Usage: "write each match to its own JSON file in the directory given by --out-dir, instead of a single document",
+		},
+		&cli.PathFlag{
+			Name:  "out-dir",
+			Usage: "with --split-output, write the files of the matches to `DIR`, which is created if needed",
+		},
+		&cli.StringFlag{
+			Name:  "include-raw",
+			Usage: "add the log lines of each match, so the output can be audited without the logs: embed, as the base64 encoded raw field, or files, written next to the file of each match by --split-output",
+		},
+		&cli.BoolFlag{
+			Name:  "follow",
+			Usage: "keep reading the single log given as the server writes it, like tail -f, writing each match as a line of JSON once it ends, as the \"follow\" subcommand does",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "parse and check the logs, but instead of writing anything to the output, the sinks or the audit log, print a summary of what would be written",
+		},
+	)
+	return append(flags, outputFlags...)
+}
+
+// parseCommand returns the "parse" subcommand, which writes the matches of the given logs. It
+// is also what the application does when no subcommand is given.
+func parseCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "parse",
+		Usage:     "Parses the matches of log files, which is the default when no subcommand is given.",
+		ArgsUsage: "<file...>",
+		Flags:     parseFlags(),
+		Action:    parse,
+	}
+}
+
+// parse is the action of the "parse" subcommand.
+func parse(c *cli.Context) error {
+	files, err := inputFiles(c)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		cli.ShowSubcommandHelpAndExit(c, exitUsage)
+	}
+	if c.Bool("follow") {
+		if err := checkFollow(c); err != nil {
+			return err
+		}
+		return follow(c)
+	}
+
+	var dedupe qlp.DedupeMode
+	switch mode := c.String("dedupe"); mode {
+	case "":
+	case "drop":
+		dedupe = qlp.DedupeDrop
+	case "flag":
+		dedupe = qlp.DedupeFlag
+	default:
+		return cli.Exit(fmt.Sprintf("Invalid dedupe mode: %s", mode), exitUsage)
+	}
+
+	switch mode := c.String("include-raw"); mode {
+	case "", "embed":
+	case "files":
+		if !c.Bool("split-output") {
+			return cli.Exit("--include-raw files requires --split-output", exitUsage)
+		}
+	default:
+		return cli.Exit(fmt.Sprintf("Invalid raw mode: %s", mode), exitUsage)
+	}
+
+	config, fileConfig, err := newParseConfig(c, qlp.Options{
+		MaxMatchEntries: c.Int("max-match-entries"),
+		MeansCategories: c.Bool("means-categories"),
+		Powerups:        c.Bool("powerups"),
+		Pings:           c.Bool("pings"),
+		Accuracy:        c.Bool("accuracy"),
+		ItemControl:     c.Bool("item-control"),
+		Votes:           c.Bool("votes"),
+		AdminActions:    c.Bool("admin-actions"),
+		Raw:             c.String("include-raw") != "",
+		Strict:          c.Bool("strict-fail"),
+	})
+	if err != nil {
+		return err
+	}
+	sinks, err := fileConfig.sinks()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+	}
+
+	// on Ctrl-C, the match being parsed is written as in progress and the output is closed
+	// properly, so that it remains valid
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	config.ctx = ctx
+
+	var dryRun *dryRunReport
+	if c.Bool("dry-run") {
+		dryRun = &dryRunReport{}
+	}
+
+	if filePath := c.Path("audit-log"); filePath != "" && dryRun == nil {
+		audit, err := openAuditLog(filePath)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to open audit log: %s", err), exitOpen)
+		}
+		defer audit.Close()
+		config.audit = audit
+	}
+
+	eventStats := c.Bool("event-stats")
+	if eventStats {
+		config.opts.EventCounts = make(map[string]int)
+	}
+
+	// matches are encoded as soon as they are parsed, so memory usage stays flat regardless
+	// of the size of the logs
+	output := &output{}
+	var encoder matchEncoder
+	switch {
+	case c.Bool("split-output") && dryRun != nil:
+		if err := checkSplitOutput(c); err != nil {
+			return err
+		}
+		encoder = dryRun
+	case c.Bool("split-output"):
+		if encoder, err = openSplitOutput(c); err != nil {
+			return err
+		}
+	case c.Path("out-dir") != "":
+		return cli.Exit("--out-dir requires --split-output", exitUsage)
+	case c.Bool("group-by-file") && eventStats:
+		return cli.Exit("--group-by-file cannot be used with --event-stats", exitUsage)
+	case dryRun != nil:
+		if err := checkOutput(c); err != nil {
+			return err
+		}
+		encoder = dryRun
+	default:
+		if output, err = openOutput(c); err != nil {
+			return err
+		}
+		encoder = newMatchEncoder(output, output.format)
+		if c.Bool("group-by-file") {
+			if output.format != "json" {
+				return cli.Exit("--group-by-file requires the json format", exitUsage)
+			}
+			encoder = &groupEncoder{w: output}
+		}
+	}
+	defer output.Close()
+
+	deduper := qlp.NewDeduper(dedupe)
+	unknownEvents, corruptRegions, malformedLines, sinkFailures := 0, 0, 0, 0
+	encoded := 0 // matches written so far, which numbers them
+	interrupted := false
+	for _, filePath := range files {
+		warnings := newWarningReport(filePath)
+		config.opts.OnWarning = warnings.add
+		if group, ok := encoder.(*groupEncoder); ok {
+			if err := group.startFile(filePath); err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to write game data: %s", err), exitWrite)
+			}
+		}
+
+		err := parseFile(filePath, config, func(match qlp.Match) error {
+			if eventStats || !deduper.Filter(&match) {
+				return nil
+			}
+			encoded++
+			if dryRun != nil {
+				if !match.InProgress {
+					dryRun.deliveries += len(sinks)
+				}
+			} else {
+				sinkFailures += sinks.send(ctx, encoded, match)
+			}
+			return encoder.Encode(match)
+		})
+		warnings.print(os.Stderr)
+		if errors.Is(err, errInterrupted) {
+			interrupted = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+		unknownEvents += warnings.unknownEvents
+		corruptRegions += warnings.corruptRegions
+		malformedLines += warnings.malformedLines
+		if dryRun != nil {
+			dryRun.files++
+		}
+	}
+
+	switch {
+	case dryRun != nil:
+		dryRun.eventTypes = len(config.opts.EventCounts)
+		dryRun.print(c.App.Writer, c, sinks)
+	case eventStats:
+		err = writeFormatted(output, output.format, eventCounts(config.opts.EventCounts))
+	default:
+		err = encoder.Close()
+	}
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to write game data: %s", err), exitWrite)
+	}
+	if err := output.Close(); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to write game data: %s", err), exitWrite)
+	}
+	if interrupted {
+		return cli.Exit("Interrupted before the end of the logs", exitInterrupted)
+	}
+	if unknownEvents > 0 && c.Bool("strict-fail") {
+		return cli.Exit(fmt.Sprintf("Found %d events of unknown types", unknownEvents), exitStrict)
+	}
+	if corruptRegions > 0 {
+		return cli.Exit(fmt.Sprintf("Skipped %d corrupted regions of the logs", corruptRegions), exitPartial)
+	}
+	if malformedLines > 0 {
+		return cli.Exit(fmt.Sprintf("Skipped %d malformed lines of the logs", malformedLines), exitPartial)
+	}
+	if sinkFailures > 0 {
+		return cli.Exit(fmt.Sprintf("Failed %d deliveries to sinks", sinkFailures), exitSink)
+	}
+	return nil
+}
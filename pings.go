@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// pingInMatch is the ping of a player in a single match.
+type pingInMatch struct {
+	Match int `json:"match"` // 1-indexed, as in the "game_N" keys
+	qlp.PingStats
+}
+
+// pingReport is the output of the "pings" report, keyed by player name.
+type pingReport map[string][]pingInMatch
+
+// Table lists one match of a player per row, sorted by player and then by match.
+func (pr pingReport) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"player", "match", "min", "average", "max"}}
+	for _, player := range sortedKeys(pr) {
+		for _, p := range pr[player] {
+			t.Rows = append(t.Rows, []string{
+				player, strconv.Itoa(p.Match), strconv.Itoa(p.Min), formatFloat(p.Average), strconv.Itoa(p.Max),
+			})
+		}
+	}
+	return t
+}
+
+// buildPingReport gathers the pings of every player in every match with score lines. It
+// needs the pings of the matches.
+func buildPingReport(matches qlp.Matches) pingReport {
+	report := make(pingReport)
+	for i, match := range matches {
+		for _, player := range sortedKeys(match.Pings) {
+			report[player] = append(report[player], pingInMatch{Match: i + 1, PingStats: match.Pings[player]})
+		}
+	}
+	return report
+}
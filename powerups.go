@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// powerupWindow is how long, in seconds, kills are credited to a powerup after its pickup,
+// which is how long Quad Damage and the Battle Suit last.
+const powerupWindow = 30
+
+// powerupEffectiveness tells how well a player made use of a powerup.
+type powerupEffectiveness struct {
+	Pickups int `json:"pickups"`
+	// Kills counts the kills within powerupWindow seconds of each pickup, or until the next
+	// pickup of the same powerup, so no kill is counted twice.
+	Kills          int     `json:"kills"`
+	KillsPerPickup float64 `json:"kills_per_pickup"`
+}
+
+// powerupStats is the output of the "powerups" report, keyed by player name and then by item,
+// such as "item_quad".
+type powerupStats map[string]map[string]*powerupEffectiveness
+
+// Table lists one powerup of a player per row, sorted by player and item.
+func (ps powerupStats) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"player", "item", "pickups", "kills", "kills_per_pickup"}}
+	for _, player := range sortedKeys(ps) {
+		for _, item := range sortedKeys(ps[player]) {
+			e := ps[player][item]
+			t.Rows = append(t.Rows, []string{
+				player, item, strconv.Itoa(e.Pickups), strconv.Itoa(e.Kills), formatFloat(e.KillsPerPickup),
+			})
+		}
+	}
+	return t
+}
+
+// buildPowerupStats correlates the powerups picked up by each player with their kills right
+// after. Suicides and deaths by the world are not kills. It needs the kill feed and the
+// powerups of the matches.
+func buildPowerupStats(matches qlp.Matches) powerupStats {
+	stats := make(powerupStats)
+	for _, match := range matches {
+		for i, pickup := range match.Powerups {
+			// times are in whole seconds, so the window ends right after its last second
+			end := pickup.Time + powerupWindow + 1
+			for _, next := range match.Powerups[i+1:] {
+				if next.Player == pickup.Player && next.Item == pickup.Item {
+					end = min(end, next.Time)
+					break
+				}
+			}
+
+			if stats[pickup.Player] == nil {
+				stats[pickup.Player] = make(map[string]*powerupEffectiveness)
+			}
+			e := stats[pickup.Player][pickup.Item]
+			if e == nil {
+				e = &powerupEffectiveness{}
+				stats[pickup.Player][pickup.Item] = e
+			}
+			e.Pickups++
+			for _, kill := range match.KillFeed {
+				if kill.Killer == pickup.Player && kill.Victim != kill.Killer &&
+					kill.Time >= pickup.Time && kill.Time < end {
+					e.Kills++
+				}
+			}
+		}
+	}
+
+	for _, items := range stats {
+		for _, e := range items {
+			e.KillsPerPickup = float64(e.Kills) / float64(e.Pickups)
+		}
+	}
+	return stats
+}
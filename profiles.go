@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// profile gathers the statistics of a single player across every match.
+type profile struct {
+	Clan           string           `json:"clan,omitempty"`    // clan tag in the player's name
+	Country        string           `json:"country,omitempty"` // ISO 3166-1 code, if known
+	Nemesis        *rival           `json:"nemesis"`
+	FavoriteVictim *rival           `json:"favorite_victim"`
+	Humiliations   humiliations     `json:"humiliations"`
+	Matches        []profileInMatch `json:"matches"`
+}
+
+// profileInMatch gathers the statistics of a player in a single match.
+type profileInMatch struct {
+	Match          int          `json:"match"` // 1-indexed, as in the "game_N" keys
+	Nemesis        *rival       `json:"nemesis"`
+	FavoriteVictim *rival       `json:"favorite_victim"`
+	Humiliations   humiliations `json:"humiliations"`
+	// Survival is nil if the player died less than twice in the match.
+	Survival *survival `json:"survival"`
+}
+
+// survival describes how long a player stayed alive between consecutive deaths, in seconds.
+// Time before the first death is not counted, as the log does not tell when players spawn.
+type survival struct {
+	AverageLifetime float64 `json:"average_lifetime"`
+	LongestLife     int     `json:"longest_life"`
+}
+
+// humiliations counts the gauntlet kills a player scored and suffered.
+type humiliations struct {
+	Given    int `json:"given"`
+	Received int `json:"received"`
+}
+
+// rival is another player along with how many times they killed or were killed by a player.
+type rival struct {
+	Player string `json:"player"`
+	Kills  int    `json:"kills"`
+}
+
+// profiles is the output of the "profiles" report, keyed by player name.
+type profiles map[string]*profile
+
+// Table lists the overall statistics of one player per row, sorted by name.
+func (ps profiles) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{
+		"player", "clan", "country", "matches", "nemesis", "nemesis_kills", "favorite_victim", "favorite_victim_kills",
+		"humiliations_given", "humiliations_received",
+	}}
+	for _, player := range sortedKeys(ps) {
+		p := ps[player]
+		row := []string{player, p.Clan, p.Country, strconv.Itoa(len(p.Matches))}
+		for _, r := range [...]*rival{p.Nemesis, p.FavoriteVictim} {
+			if r == nil {
+				row = append(row, "", "")
+			} else {
+				row = append(row, r.Player, strconv.Itoa(r.Kills))
+			}
+		}
+		row = append(row, strconv.Itoa(p.Humiliations.Given), strconv.Itoa(p.Humiliations.Received))
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// buildProfiles computes the profile of every player, keyed by name. Humiliations are kills
+// with the gauntlet and clans are found by clans. Countries are found by geo, if not nil, from
+// the IP addresses of the players. It needs the kill feed of the matches.
+func buildProfiles(matches qlp.Matches, clans clanMatcher, geo *geoIP) profiles {
+	byPlayer := make(profiles)
+	killedBy := make(map[string]map[string]int) // victim -> killer -> kills, across matches
+	victims := make(map[string]map[string]int)  // killer -> victim -> kills, across matches
+
+	for i, match := range matches {
+		matchKilledBy := make(map[string]map[string]int)
+		matchVictims := make(map[string]map[string]int)
+		deaths := make(map[string][]int) // victim -> times of death
+		matchHumiliations := make(map[string]*humiliations)
+		for _, kill := range match.KillFeed {
+			deaths[kill.Victim] = append(deaths[kill.Victim], kill.Time)
+			if kill.Killer == "<world>" || kill.Killer == kill.Victim {
+				continue
+			}
+			for _, counts := range [...]map[string]map[string]int{killedBy, matchKilledBy} {
+				increment(counts, kill.Victim, kill.Killer)
+			}
+			for _, counts := range [...]map[string]map[string]int{victims, matchVictims} {
+				increment(counts, kill.Killer, kill.Victim)
+			}
+			if kill.Means == "MOD_GAUNTLET" {
+				humiliationsOf(matchHumiliations, kill.Killer).Given++
+				humiliationsOf(matchHumiliations, kill.Victim).Received++
+			}
+		}
+
+		for _, player := range match.Players {
+			if byPlayer[player] == nil {
+				byPlayer[player] = &profile{Clan: clans.clanOf(player)}
+			}
+			if ip, ok := match.PlayerIPs[player]; ok && geo != nil {
+				if country := geo.country(ip); country != "" {
+					byPlayer[player].Country = country
+				}
+			}
+			h := humiliationsOf(matchHumiliations, player)
+			byPlayer[player].Humiliations.Given += h.Given
+			byPlayer[player].Humiliations.Received += h.Received
+			byPlayer[player].Matches = append(byPlayer[player].Matches, profileInMatch{
+				Match:          i + 1,
+				Nemesis:        topRival(matchKilledBy[player]),
+				FavoriteVictim: topRival(matchVictims[player]),
+				Humiliations:   *h,
+				Survival:       survivalOf(deaths[player]),
+			})
+		}
+	}
+
+	for player, p := range byPlayer {
+		p.Nemesis = topRival(killedBy[player])
+		p.FavoriteVictim = topRival(victims[player])
+	}
+	return byPlayer
+}
+
+// increment adds one to counts[outer][inner], allocating the inner map if needed.
+func increment(counts map[string]map[string]int, outer, inner string) {
+	if counts[outer] == nil {
+		counts[outer] = make(map[string]int)
+	}
+	counts[outer][inner]++
+}
+
+// humiliationsOf returns the humiliations of player, allocating them if needed.
+func humiliationsOf(byPlayer map[string]*humiliations, player string) *humiliations {
+	if byPlayer[player] == nil {
+		byPlayer[player] = &humiliations{}
+	}
+	return byPlayer[player]
+}
+
+// topRival returns the player with the highest count, ties going to the alphabetically first
+// name, or nil if there is none.
+func topRival(counts map[string]int) *rival {
+	var top *rival
+	for player, kills := range counts {
+		if top == nil || kills > top.Kills || kills == top.Kills && player < top.Player {
+			top = &rival{Player: player, Kills: kills}
+		}
+	}
+	return top
+}
+
+// survivalOf computes the survival metrics from the ordered times of death of a player, or
+// returns nil if there are less than two.
+func survivalOf(deaths []int) *survival {
+	if len(deaths) < 2 {
+		return nil
+	}
+
+	var s survival
+	for i := 1; i < len(deaths); i++ {
+		s.LongestLife = max(s.LongestLife, deaths[i]-deaths[i-1])
+	}
+	s.AverageLifetime = float64(deaths[len(deaths)-1]-deaths[0]) / float64(len(deaths)-1)
+	return &s
+}
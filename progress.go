@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// progressDelay is how long a parse runs before its progress is shown, so that short parses
+// do not flicker.
+const progressDelay = 500 * time.Millisecond
+
+// progressInterval is how often the progress line is redrawn.
+const progressInterval = 200 * time.Millisecond
+
+// isTerminal reports whether f is a terminal, as opposed to a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progress draws a line on a terminal with how much of a file was parsed and how many matches
+// were found, redrawing it until stopped.
+type progress struct {
+	w    io.Writer
+	name string
+	size int64
+
+	read    atomic.Int64
+	matches atomic.Int64
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// startProgress starts drawing the progress of the parse of the file name, of size bytes or -1
+// if unknown, to w, which must be a terminal.
+func startProgress(w io.Writer, name string, size int64) *progress {
+	p := &progress{w: w, name: name, size: size, done: make(chan struct{}), stopped: make(chan struct{})}
+	go p.run()
+	return p
+}
+
+// run redraws the progress line until the progress is stopped, clearing it then.
+func (p *progress) run() {
+	defer close(p.stopped)
+
+	select {
+	case <-p.done:
+		return
+	case <-time.After(progressDelay):
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		p.draw()
+		select {
+		case <-p.done:
+			fmt.Fprint(p.w, "\r\x1b[K")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// draw redraws the progress line.
+func (p *progress) draw() {
+	read := p.read.Load()
+	if p.size < 0 {
+		fmt.Fprintf(p.w, "\r\x1b[K%s: %s, %d matches", p.name, formatBytes(read), p.matches.Load())
+		return
+	}
+	percent := 100.0
+	if p.size > 0 {
+		percent = 100 * float64(read) / float64(p.size)
+	}
+	fmt.Fprintf(p.w, "\r\x1b[K%s: %s of %s (%.0f%%), %d matches",
+		p.name, formatBytes(read), formatBytes(p.size), percent, p.matches.Load())
+}
+
+// inputSize returns the size of input, leaving it at its start, or -1 if it cannot be told, as
+// with pipes.
+func inputSize(input io.Seeker) int64 {
+	size, err := input.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err := input.Seek(0, io.SeekStart); err != nil {
+		return -1
+	}
+	return size
+}
+
+// reader returns a reader which counts the bytes read from r as parsed.
+func (p *progress) reader(r io.Reader) io.Reader {
+	return progressReader{r: r, p: p}
+}
+
+// addMatch counts a match as found.
+func (p *progress) addMatch() {
+	p.matches.Add(1)
+}
+
+// stop stops drawing the progress and clears its line.
+func (p *progress) stop() {
+	close(p.done)
+	<-p.stopped
+}
+
+// progressReader counts the bytes read through it in the progress.
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+// Read reads from the underlying reader.
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.p.read.Add(int64(n))
+	return n, err
+}
+
+// formatBytes formats a number of bytes with a binary unit, such as "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < len("KMGTPE")-1 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[prefix])
+}
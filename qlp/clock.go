@@ -0,0 +1,43 @@
+package qlp
+
+import "time"
+
+// timestampLayout is the layout of the g_timestamp setting, with which servers record in the
+// InitGame event the date and time of day a match started.
+const timestampLayout = "2006-01-02 15:04:05"
+
+// anchorTimes fills in the wall-clock times of the match and of its events, in RFC 3339 in
+// loc, from its g_timestamp setting, which tells when the InitGame event was logged. The
+// times of the events are relative to the InitGame event, so each is the timestamp plus how
+// long after the InitGame event it was logged. Matches without the setting are left alone.
+func (m *Match) anchorTimes(loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
+	}
+	timestamp, ok := m.Settings["g_timestamp"]
+	if !ok {
+		return
+	}
+	start, err := time.ParseInLocation(timestampLayout, timestamp, loc)
+	if err != nil {
+		return
+	}
+
+	at := func(seconds int) string {
+		return start.Add(time.Duration(seconds-m.StartTime) * time.Second).Format(time.RFC3339)
+	}
+	m.StartedAt = at(m.StartTime)
+	m.EndedAt = at(m.StartTime + m.Duration)
+	for i := range m.KillFeed {
+		m.KillFeed[i].At = at(m.KillFeed[i].Time)
+	}
+	for i := range m.Powerups {
+		m.Powerups[i].At = at(m.Powerups[i].Time)
+	}
+	for i := range m.Votes {
+		m.Votes[i].At = at(m.Votes[i].Time)
+	}
+	for i := range m.AdminActions {
+		m.AdminActions[i].At = at(m.AdminActions[i].Time)
+	}
+}
@@ -0,0 +1,81 @@
+package qlp
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Diagnosis is the result of Diagnose. It gathers what is known about a log, so that problems
+// with it can be explained to the user.
+type Diagnosis struct {
+	Lines   int // number of lines in the log
+	Matches int // number of complete matches
+
+	// Warnings holds every warning found, including malformed lines and unknown events.
+	Warnings []ParseWarning
+	// EventCounts holds the number of events of each type.
+	EventCounts map[string]int
+
+	// Unfinished is set when the log ended while a match was still open.
+	Unfinished bool
+
+	// GameName and Version are taken from the first InitGame event, and hint at the mod
+	// and server which wrote the log, e.g. "baseq3" and "ioq3 1.36 linux-x86_64".
+	GameName string
+	Version  string
+}
+
+// Diagnose parses the log in order to find problems with it. Unlike the regular parse, it does
+// not stop at malformed lines, which are reported as warnings with ErrMalformedLine, and
+// unknown events are reported as in strict mode. Only errors while reading the log are
+// returned.
+func Diagnose(log io.Reader, opts Options) (*Diagnosis, error) {
+	diagnosis := &Diagnosis{EventCounts: make(map[string]int)}
+
+	opts.Strict = true
+	opts.Lenient = true
+	opts.EventCounts = diagnosis.EventCounts
+	onWarning := opts.OnWarning
+	opts.OnWarning = func(warning ParseWarning) {
+		diagnosis.Warnings = append(diagnosis.Warnings, warning)
+		if onWarning != nil {
+			onWarning(warning)
+		}
+	}
+
+	parser := NewParser(opts)
+	parser.onEvent = func(event string) {
+		if diagnosis.GameName != "" || !strings.HasPrefix(event, "InitGame:") {
+			return
+		}
+		settings := parseInfoString(strings.TrimPrefix(event, "InitGame:"))
+		diagnosis.GameName = settings["gamename"]
+		diagnosis.Version = settings["version"]
+	}
+
+	err := parser.ParseFunc(log, func(Match) error {
+		diagnosis.Matches++
+		return nil
+	})
+	diagnosis.Lines = parser.lines
+
+	if errors.Is(err, ErrUnfinishedMatch) {
+		diagnosis.Unfinished = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	return diagnosis, nil
+}
+
+// WarningsFor returns the warnings whose reason matches target, as reported by errors.Is.
+func (d *Diagnosis) WarningsFor(target error) []ParseWarning {
+	var warnings []ParseWarning
+	for _, warning := range d.Warnings {
+		if errors.Is(warning.Reason, target) {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
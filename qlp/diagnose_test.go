@@ -0,0 +1,48 @@
+package qlp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnose(t *testing.T) {
+	log := "garbage\n" +
+		"  0:00 InitGame: \\gamename\\osp\\version\\Q3 1.32\n" +
+		"  0:01 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  0:02 Callvote: 2 map\n" +
+		"\xff\xfe\n" +
+		"  0:03 InitGame: \\gamename\\baseq3\n" +
+		"  0:04 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET"
+
+	diagnosis, err := Diagnose(strings.NewReader(log), Options{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 7, diagnosis.Lines)
+	assert.Equal(t, 0, diagnosis.Matches)
+	assert.True(t, diagnosis.Unfinished)
+	assert.Equal(t, "osp", diagnosis.GameName)
+	assert.Equal(t, "Q3 1.32", diagnosis.Version)
+	assert.Equal(t, 2, diagnosis.EventCounts["Kill"])
+
+	malformed := diagnosis.WarningsFor(ErrMalformedLine)
+	assert.Len(t, malformed, 2)
+	assert.Equal(t, 1, malformed[0].Line)
+	assert.Equal(t, 5, malformed[1].Line)
+	assert.Len(t, diagnosis.WarningsFor(ErrInvalidUTF8), 1)
+	assert.Len(t, diagnosis.WarningsFor(ErrMatchInterrupted), 1)
+	assert.Len(t, diagnosis.Warnings, 5)
+}
+
+func TestDiagnoseTestLog(t *testing.T) {
+	diagnosis, err := Diagnose(bytes.NewReader(testLogFile), Options{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 5306, diagnosis.Lines)
+	assert.Equal(t, 21, diagnosis.Matches)
+	assert.False(t, diagnosis.Unfinished)
+	assert.Empty(t, diagnosis.Warnings)
+	assert.Equal(t, "baseq3", diagnosis.GameName)
+}
@@ -0,0 +1,63 @@
+// Package encode writes matches in the formats of the command other than JSON, such as CSV
+// for spreadsheets, so that other Go applications can write them as the command does.
+package encode
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// MatchesTable returns the table of the matches, with a row for each match as told by
+// MatchRow. Without matches, it only has the header.
+func MatchesTable(matches qlp.Matches) qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"game", "map", "server", "total_kills", "players", "duration", "competitiveness"}}
+	for i, match := range matches {
+		t.Rows = append(t.Rows, MatchRow(i+1, match))
+	}
+	return t
+}
+
+// MatchRow returns the row of the match in the table of MatchesTable, where it is the n-th
+// match, numbered from 1 as in the keys of the JSON of qlp.Matches.
+func MatchRow(n int, match qlp.Match) []string {
+	return []string{
+		fmt.Sprintf("game_%d", n),
+		match.MapName,
+		match.Server.Hostname,
+		strconv.Itoa(match.TotalKills),
+		strconv.Itoa(match.PlayerCount),
+		strconv.Itoa(match.Duration),
+		strconv.FormatFloat(match.Competitiveness, 'f', 2, 64),
+	}
+}
+
+// EncodeCSV writes the table of the matches, as returned by MatchesTable, to w as
+// comma-separated values, which spreadsheets import.
+func EncodeCSV(w io.Writer, matches qlp.Matches) error {
+	return write(w, "csv", MatchesTable(matches))
+}
+
+// EncodeTable writes the table of the matches, as returned by MatchesTable, to w as plain text
+// with aligned columns.
+func EncodeTable(w io.Writer, matches qlp.Matches) error {
+	return write(w, "table", MatchesTable(matches))
+}
+
+// EncodeYAML writes the matches to w as YAML, keyed "game_1", "game_2", ... with the same
+// fields as in JSON.
+func EncodeYAML(w io.Writer, matches qlp.Matches) error {
+	return write(w, "yaml", matches)
+}
+
+// write writes v to w in the built-in format of qlpformat with the given name.
+func write(w io.Writer, format string, v any) error {
+	formatter, ok := qlpformat.Lookup(format)
+	if !ok {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	return formatter.Format(w, v)
+}
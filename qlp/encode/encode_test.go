@@ -0,0 +1,41 @@
+package encode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMatches = qlp.Matches{
+	{MapName: "q3dm17", Server: qlp.Server{Hostname: "Code Miner Server"}, TotalKills: 11, PlayerCount: 3, Duration: 900, Competitiveness: 0.5},
+	{MapName: "q3dm6", TotalKills: 0},
+}
+
+func TestEncodeCSV(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, EncodeCSV(&b, testMatches))
+	assert.Equal(t, "game,map,server,total_kills,players,duration,competitiveness\n"+
+		"game_1,q3dm17,Code Miner Server,11,3,900,0.50\n"+
+		"game_2,q3dm6,,0,0,0,0.00\n", b.String())
+
+	b.Reset()
+	assert.NoError(t, EncodeCSV(&b, nil))
+	assert.Equal(t, "game,map,server,total_kills,players,duration,competitiveness\n", b.String())
+}
+
+func TestEncodeTable(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, EncodeTable(&b, testMatches[:1]))
+	assert.Equal(t, "game    map     server             total_kills  players  duration  competitiveness\n"+
+		"game_1  q3dm17  Code Miner Server  11           3        900       0.50\n", b.String())
+}
+
+func TestEncodeYAML(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, EncodeYAML(&b, testMatches))
+	assert.True(t, strings.HasPrefix(b.String(), "game_1:\n  schema_version: 0\n"), b.String())
+	assert.Contains(t, b.String(), "game_2:\n")
+	assert.Contains(t, b.String(), "  map_name: q3dm6\n")
+}
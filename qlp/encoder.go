@@ -0,0 +1,76 @@
+package qlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MatchEncoder writes matches to an output stream one at a time, producing the same JSON
+// object as marshaling Matches, without having to hold every match in memory. It is meant to
+// be paired with ParseLogFunc.
+type MatchEncoder struct {
+	w      io.Writer
+	indent string
+	count  int
+	err    error
+}
+
+// NewMatchEncoder returns an encoder that writes to w. Each nesting level of the output is
+// indented with indent; an empty indent produces compact JSON.
+func NewMatchEncoder(w io.Writer, indent string) *MatchEncoder {
+	return &MatchEncoder{w: w, indent: indent}
+}
+
+// Encode writes the next match to the stream. Matches are numbered in the order they are
+// encoded, starting at "game_1".
+func (e *MatchEncoder) Encode(match Match) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	var matchJSON []byte
+	if e.indent == "" {
+		matchJSON, e.err = json.Marshal(match)
+	} else {
+		matchJSON, e.err = json.MarshalIndent(match, e.indent, e.indent)
+	}
+	if e.err != nil {
+		return e.err
+	}
+
+	separator := "{"
+	if e.count > 0 {
+		separator = ","
+	}
+	if e.indent != "" {
+		separator += "\n" + e.indent
+	}
+
+	e.count++
+	key := fmt.Sprintf(`"game_%d":`, e.count) // 1-indexed
+	if e.indent != "" {
+		key += " "
+	}
+
+	_, e.err = fmt.Fprintf(e.w, "%s%s%s", separator, key, matchJSON)
+	return e.err
+}
+
+// Close terminates the JSON object. It must be called once every match has been encoded; it
+// does not close the underlying writer.
+func (e *MatchEncoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+
+	closing := "}"
+	if e.count == 0 {
+		closing = "{}"
+	} else if e.indent != "" {
+		closing = "\n}"
+	}
+
+	_, e.err = io.WriteString(e.w, closing)
+	return e.err
+}
@@ -0,0 +1,41 @@
+package qlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchEncoderMatchesMarshal(t *testing.T) {
+	matches, err := ParseLog(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+
+	for _, indent := range []string{"", "  "} {
+		var expected []byte
+		if indent == "" {
+			expected, err = json.Marshal(matches)
+		} else {
+			expected, err = json.MarshalIndent(matches, "", indent)
+		}
+		assert.NoError(t, err)
+
+		buff := bytes.Buffer{}
+		encoder := NewMatchEncoder(&buff, indent)
+		err := ParseLogFunc(bytes.NewReader(testLogFile), Options{}, encoder.Encode)
+		assert.NoError(t, err)
+		assert.NoError(t, encoder.Close())
+
+		assert.Equal(t, string(expected), buff.String())
+	}
+}
+
+func TestMatchEncoderEmpty(t *testing.T) {
+	for _, indent := range []string{"", "  "} {
+		buff := bytes.Buffer{}
+		encoder := NewMatchEncoder(&buff, indent)
+		assert.NoError(t, encoder.Close())
+		assert.Equal(t, "{}", buff.String())
+	}
+}
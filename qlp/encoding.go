@@ -0,0 +1,89 @@
+package qlp
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Decoding selects how log lines which are not valid UTF-8 are turned into UTF-8. Lines which
+// are valid UTF-8 are always kept as they are.
+type Decoding int
+
+const (
+	// DecodeWindows1252 decodes invalid lines as Windows-1252, a superset of the printable
+	// characters of Latin-1. Old servers commonly log player names in either of them.
+	DecodeWindows1252 Decoding = iota
+	// DecodeReplace replaces each invalid byte with the Unicode replacement character.
+	DecodeReplace
+	// DecodeStrip removes invalid bytes.
+	DecodeStrip
+)
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to their Unicode code points. The
+// bytes left undefined by Windows-1252 map to the replacement character. Every other byte has
+// the same value as its code point.
+var windows1252 = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
+
+// decodeLine converts a raw log line into a valid UTF-8 string according to decoding.
+// sanitized reports whether the line was not valid UTF-8 to begin with.
+func decodeLine(line []byte, decoding Decoding) (decoded string, sanitized bool) {
+	if utf8.Valid(line) {
+		return string(line), false
+	}
+
+	switch decoding {
+	case DecodeReplace:
+		return strings.ToValidUTF8(string(line), string(utf8.RuneError)), true
+	case DecodeStrip:
+		return strings.ToValidUTF8(string(line), ""), true
+	}
+
+	builder := strings.Builder{}
+	builder.Grow(len(line) + len(line)/2)
+	for _, b := range line {
+		switch {
+		case b < utf8.RuneSelf:
+			builder.WriteByte(b)
+		case b < 0xA0:
+			builder.WriteRune(windows1252[b-0x80])
+		default:
+			builder.WriteRune(rune(b))
+		}
+	}
+	return builder.String(), true
+}
+
+// scanLines is a bufio.SplitFunc like bufio.ScanLines, which also accepts a lone carriage
+// return as a line ending, as written by some old Mac tools. A "\r\n" pair is a single line
+// ending, even when it is split across two reads.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		// the next byte is needed to tell "\r" from "\r\n"
+		return 0, nil, nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,61 @@
+package qlp
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeLine(t *testing.T) {
+	latin1 := []byte("Jo\xe3o \x80\x81")
+
+	decoded, sanitized := decodeLine(latin1, DecodeWindows1252)
+	assert.Equal(t, "João €�", decoded)
+	assert.True(t, sanitized)
+	decoded, _ = decodeLine(latin1, DecodeReplace)
+	assert.Equal(t, "Jo�o �", decoded)
+	decoded, _ = decodeLine(latin1, DecodeStrip)
+	assert.Equal(t, "Joo ", decoded)
+
+	decoded, sanitized = decodeLine([]byte("Jõão"), DecodeStrip)
+	assert.Equal(t, "Jõão", decoded, "valid UTF-8 must be kept")
+	assert.False(t, sanitized)
+}
+
+func TestScanLines(t *testing.T) {
+	input := "a\nb\r\nc\rd\r\r\ne"
+
+	// one byte reads make sure "\r\n" pairs split across reads are handled
+	for _, reader := range []io.Reader{strings.NewReader(input), iotest.OneByteReader(strings.NewReader(input))} {
+		scanner := bufio.NewScanner(reader)
+		scanner.Split(scanLines)
+
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		assert.NoError(t, scanner.Err())
+		assert.Equal(t, []string{"a", "b", "c", "d", "", "e"}, lines)
+	}
+}
+
+func TestParseLogLineEndingsAndEncoding(t *testing.T) {
+	log := "  0:00 InitGame:\r\n" +
+		"  0:01 Kill: 0 1 2: Jo\xe3o killed Mocinha by MOD_ROCKET\r" +
+		"  0:02 " + matchSeparator + "\r\n"
+
+	matches, warnings, err := ParseLogWithOptions(strings.NewReader(log), Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"João", "Mocinha"}, matches[0].Players)
+	assert.Equal(t, 1, matches[0].Kills["João"])
+
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, 2, warnings[0].Line)
+	assert.ErrorIs(t, warnings[0], ErrInvalidUTF8)
+	assert.True(t, utf8.ValidString(warnings[0].Text))
+}
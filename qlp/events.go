@@ -0,0 +1,56 @@
+package qlp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// knownEvents holds the event types written by the Quake III Arena server (ioquake3's game
+// module), whether or not the parser extracts data from them.
+var knownEvents = map[string]struct{}{
+	"InitGame":              {},
+	"Warmup":                {},
+	"ShutdownGame":          {},
+	"Exit":                  {},
+	"ClientConnect":         {},
+	"ClientUserinfoChanged": {},
+	"ClientBegin":           {},
+	"ClientDisconnect":      {},
+	"Item":                  {},
+	"Kill":                  {},
+	"say":                   {},
+	"sayteam":               {},
+	"tell":                  {},
+	"score":                 {},
+	"red":                   {},
+	separatorEvent:          {},
+}
+
+// separatorEvent is the type of the match separator lines, which consist of dashes.
+const separatorEvent = "---"
+
+// eventType returns the type of an event, which is the text before its first colon, e.g.
+// "Kill" for "Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT". Events without
+// a colon have their first word as the type. Blank events have an empty type.
+func eventType(event string) string {
+	if strings.HasPrefix(event, separatorEvent) {
+		return separatorEvent
+	}
+
+	end := strings.IndexAny(event, ": \t")
+	if end < 0 {
+		return event
+	}
+	return event[:end]
+}
+
+// UnknownEventError is the reason of the warnings issued in strict mode for events whose
+// type is not known to the parser.
+type UnknownEventError struct {
+	Type string
+}
+
+// Error implements the error interface.
+func (e UnknownEventError) Error() string {
+	return fmt.Sprintf("unknown event type %q", e.Type)
+}
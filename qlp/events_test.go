@@ -0,0 +1,55 @@
+package qlp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventType(t *testing.T) {
+	assert.Equal(t, "Kill", eventType("Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT"))
+	assert.Equal(t, "InitGame", eventType("InitGame: \\sv_floodProtect\\1"))
+	assert.Equal(t, "ShutdownGame", eventType("ShutdownGame:"))
+	assert.Equal(t, "red", eventType("red:8  blue:6"))
+	assert.Equal(t, "---", eventType(matchSeparator))
+	assert.Equal(t, "Vote", eventType("Vote passed"))
+	assert.Equal(t, "", eventType(""))
+}
+
+func TestStrictMode(t *testing.T) {
+	log := "  0:00 InitGame:\n  0:01 Callvote: 2 map q3dm6\n  0:02 Vote passed\n  0:03 Callvote: 1 kick Zeh\n  0:04 " + matchSeparator
+
+	_, warnings, err := ParseLogWithOptions(strings.NewReader(log), Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, warnings, "unknown events must only be reported in strict mode")
+
+	_, warnings, err = ParseLogWithOptions(strings.NewReader(log), Options{Strict: true})
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 3)
+	assert.Equal(t, 2, warnings[0].Line)
+	assert.Equal(t, UnknownEventError{Type: "Callvote"}, warnings[0].Reason)
+	assert.Equal(t, UnknownEventError{Type: "Vote"}, warnings[1].Reason)
+
+	_, warnings, err = ParseLogWithOptions(bytes.NewReader(testLogFile), Options{Strict: true})
+	assert.NoError(t, err)
+	assert.Empty(t, warnings, "the test log only has known events")
+}
+
+func TestEventCounts(t *testing.T) {
+	counts := make(map[string]int)
+	_, _, err := ParseLogWithOptions(bytes.NewReader(testLogFile), Options{EventCounts: counts})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1069, counts["Kill"])
+	assert.Equal(t, 21, counts["InitGame"])
+	assert.Equal(t, 41, counts["---"])
+	assert.Equal(t, 2, counts["say"])
+	assert.NotContains(t, counts, "")
+
+	parallelCounts := make(map[string]int)
+	_, err = ParseLogParallel(bytes.NewReader(testLogFile), int64(len(testLogFile)), Options{EventCounts: parallelCounts}, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, counts, parallelCounts)
+}
@@ -0,0 +1,117 @@
+package qlp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// followPoll is how often a followed log is checked for new lines once its end is reached.
+const followPoll = 500 * time.Millisecond
+
+// Follow parses the log read from an io.Reader as it grows, like tail -f, sending each match
+// on the returned channel as soon as it ends. It is Parser.Follow with the default Options.
+func Follow(ctx context.Context, log io.Reader) (<-chan Match, <-chan error) {
+	return NewParser(Options{}).Follow(ctx, log)
+}
+
+// Follow parses the log read from an io.Reader as it grows, like tail -f, sending each match
+// on the first channel returned as soon as it ends. Once the end of the log is reached, it
+// waits for more lines instead of stopping, until ctx is cancelled or reading or parsing the
+// log fails. The first channel is then closed, and the error which stopped following, which
+// is ctx.Err() once ctx is cancelled, is sent on the second one. A read of the log which
+// blocks, as on a pipe, is not interrupted by ctx.
+//
+// If the log is an *os.File, following survives log rotation: the file is read over from its
+// start when it is truncated, and the file at its path is opened when it is replaced.
+//
+// The Parser must not be used for anything else until following stops.
+func (p *Parser) Follow(ctx context.Context, log io.Reader) (<-chan Match, <-chan error) {
+	matches := make(chan Match)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(matches)
+		r := &followReader{ctx: ctx, r: log}
+		defer r.close()
+
+		errs <- p.ParseFunc(r, func(match Match) error {
+			select {
+			case matches <- match:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return matches, errs
+}
+
+// followReader reads a log as it grows. At the end of the log, reads wait for more lines
+// instead of returning io.EOF, until the context is cancelled.
+type followReader struct {
+	ctx context.Context
+	r   io.Reader
+	// reopened is set once the file being followed was replaced, as it is then opened, and
+	// closed, by the followReader.
+	reopened bool
+}
+
+// Read reads the next bytes of the log, waiting for them if needed.
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		if file, ok := f.r.(*os.File); ok {
+			if err := f.reopenIfRotated(file); err != nil {
+				return 0, err
+			}
+		}
+
+		select {
+		case <-f.ctx.Done():
+			return 0, f.ctx.Err()
+		case <-time.After(followPoll):
+		}
+	}
+}
+
+// reopenIfRotated starts reading the log over when it was truncated, and opens the new file
+// when it was replaced. It is called at the end of the file, so no line is missed.
+func (f *followReader) reopenIfRotated(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if info.Size() < offset {
+		_, err := file.Seek(0, io.SeekStart)
+		return err
+	}
+
+	// the new file may not have been created yet, in which case the old one is kept
+	current, err := os.Stat(file.Name())
+	if err != nil || os.SameFile(info, current) {
+		return nil
+	}
+	replacement, err := os.Open(file.Name())
+	if err != nil {
+		return nil
+	}
+	f.close()
+	f.r, f.reopened = replacement, true
+	return nil
+}
+
+// close closes the file being read if it was opened by the followReader.
+func (f *followReader) close() {
+	if f.reopened {
+		f.r.(*os.File).Close()
+	}
+}
@@ -0,0 +1,62 @@
+package qlp
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollow(t *testing.T) {
+	r, w := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	matches, errs := Follow(ctx, r)
+	go func() {
+		io.WriteString(w, "  0:00 InitGame: \\mapname\\q3dm17\n"+
+			"  0:10 Kill: 2 3 6: Isgalamido killed Zeh by MOD_ROCKET\n"+
+			"  0:20 Exit: Fraglimit hit.\n"+
+			"  0:20 "+matchSeparator+"\n"+
+			"  0:20 InitGame: \\mapname\\q3dm6\n")
+		// the end of the pipe is taken as the end of the log so far
+		w.Close()
+	}()
+
+	match := <-matches
+	assert.Equal(t, "q3dm17", match.MapName)
+	assert.Equal(t, 1, match.TotalKills)
+
+	// the match still open is not sent, as the log may go on
+	cancel()
+	_, ok := <-matches
+	assert.False(t, ok)
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
+
+func TestFollowFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.log")
+	first := "  0:00 InitGame: \\mapname\\q3dm17\n  0:20 Exit: Fraglimit hit.\n  0:20 " + matchSeparator + "\n"
+	assert.NoError(t, os.WriteFile(path, []byte(first), 0o644))
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	matches, errs := Follow(ctx, file)
+	assert.Equal(t, "q3dm17", (<-matches).MapName)
+
+	// the log is rotated: replaced by a new file at the same path
+	second := "  0:00 InitGame: \\mapname\\q3dm6\n  0:20 Exit: Fraglimit hit.\n  0:20 " + matchSeparator + "\n"
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, os.WriteFile(path, []byte(second), 0o644))
+	assert.Equal(t, "q3dm6", (<-matches).MapName)
+
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
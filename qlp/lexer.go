@@ -0,0 +1,301 @@
+package qlp
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The functions in this file split log lines by hand instead of using regular expressions.
+// They run for every line of the log, and slicing the line avoids the allocations made by
+// regexp submatches: the returned strings all share the memory of the line.
+
+// lineHeaderEnd returns the index where the header of a log line ends, or -1 if the line has
+// no header. The header is usually the timestamp, e.g. " 0:00 " in " 0:00 InitGame: ". It also
+// handles a special case of the log format found at the example, which is
+//
+//	26  0:00 ------------------------------------------------------------
+//
+// where every leading digit, space and colon is considered part of the header.
+func lineHeaderEnd(line string) int {
+	start := skipSpaces(line, 0)
+	colon := skipDigits(line, start)
+	if colon > start && colon < len(line) && line[colon] == ':' {
+		end := skipDigits(line, colon+1)
+		if end > colon+1 && end < len(line) && isSpace(line[end]) {
+			return end + 1
+		}
+	}
+
+	end := 0
+	for end < len(line) && (isDigit(line[end]) || isSpace(line[end]) || line[end] == ':') {
+		end++
+	}
+	if end == 0 {
+		return -1
+	}
+	return end
+}
+
+// parseTimestamp parses the timestamp of a line from its header, e.g. "20:37" into 20 minutes
+// and 37 seconds. Only the last field of the header is considered, so that "26  0:00" is
+// read as "0:00". ok is false if the header holds no timestamp.
+func parseTimestamp(header string) (timestamp time.Duration, ok bool) {
+	field := header[strings.LastIndexAny(header, " \t")+1:]
+	minutes, seconds, found := strings.Cut(field, ":")
+	if !found {
+		return 0, false
+	}
+
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 {
+		return 0, false
+	}
+	s, err := strconv.Atoi(seconds)
+	if err != nil || s < 0 || s > 59 {
+		return 0, false
+	}
+
+	return time.Duration(m)*time.Minute + time.Duration(s)*time.Second, true
+}
+
+// parseInfoString splits a Quake info string, such as the settings of InitGame events, into
+// its keys and values, e.g. `\mapname\q3dm17\gamename\baseq3` into mapname=q3dm17 and
+// gamename=baseq3.
+func parseInfoString(info string) map[string]string {
+	fields := strings.Split(strings.TrimPrefix(strings.TrimSpace(info), `\`), `\`)
+
+	values := make(map[string]string, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		values[fields[i]] = fields[i+1]
+	}
+	return values
+}
+
+// infoValue returns the value of key in a Quake info string, like parseInfoString but without
+// splitting the whole string, which matters for the events of every client.
+func infoValue(info, key string) (string, bool) {
+	rest := strings.TrimPrefix(strings.TrimSpace(info), `\`)
+	for rest != "" {
+		field, next, ok := strings.Cut(rest, `\`)
+		if !ok {
+			return "", false
+		}
+		value, after, _ := strings.Cut(next, `\`)
+		if field == key {
+			return value, true
+		}
+		rest = after
+	}
+	return "", false
+}
+
+// parseKill splits a Kill event, such as
+//
+//	Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT
+//
+// into the killer, the victim and the means of death. ok is false if the event is not a
+// well-formed Kill event. Since names may contain " killed " and " by " themselves, the last
+// occurrences of the separators are used.
+func parseKill(event string) (killer, killed, killedBy string, ok bool) {
+	rest, ok := strings.CutPrefix(event, "Kill:")
+	if !ok {
+		return "", "", "", false
+	}
+
+	// the three client and means of death numbers, e.g. " 1022 2 22: "
+	for i := 0; i < 3; i++ {
+		if len(rest) == 0 || !isSpace(rest[0]) {
+			return "", "", "", false
+		}
+		end := skipDigits(rest, 1)
+		if end == 1 {
+			return "", "", "", false
+		}
+		rest = rest[end:]
+	}
+	rest, ok = strings.CutPrefix(rest, ": ")
+	if !ok {
+		return "", "", "", false
+	}
+
+	for by := strings.LastIndex(rest, " by "); by > 0; by = strings.LastIndex(rest[:by], " by ") {
+		meansEnd := by + len(" by ")
+		for meansEnd < len(rest) && isWordChar(rest[meansEnd]) {
+			meansEnd++
+		}
+		if meansEnd == by+len(" by ") {
+			continue
+		}
+
+		names := rest[:by]
+		for sep := strings.LastIndex(names, " killed "); sep > 0; sep = strings.LastIndex(names[:sep], " killed ") {
+			if sep+len(" killed ") < len(names) {
+				return names[:sep], names[sep+len(" killed "):], rest[by+len(" by ") : meansEnd], true
+			}
+		}
+	}
+
+	return "", "", "", false
+}
+
+// parseKillClients returns the client numbers of the killer and of the victim of a kill event,
+// such as "Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT", which parseKill
+// leaves out. ok is false if the event does not start with them.
+func parseKillClients(event string) (killer, killed string, ok bool) {
+	rest, ok := strings.CutPrefix(event, "Kill:")
+	if !ok {
+		return "", "", false
+	}
+	start := skipSpaces(rest, 0)
+	end := skipDigits(rest, start)
+	killer = rest[start:end]
+	start = skipSpaces(rest, end)
+	end = skipDigits(rest, start)
+	killed = rest[start:end]
+	if killer == "" || killed == "" {
+		return "", "", false
+	}
+	return killer, killed, true
+}
+
+// parseClientEvent splits an event about a client, such as
+//
+//	Item: 2 item_quad
+//
+// into the client number and the rest of the event, given its prefix, e.g. "Item:". ok is
+// false if the event does not have the prefix followed by a client number.
+func parseClientEvent(event, prefix string) (client, rest string, ok bool) {
+	rest, ok = strings.CutPrefix(event, prefix)
+	if !ok {
+		return "", "", false
+	}
+	start := skipSpaces(rest, 0)
+	end := skipDigits(rest, start)
+	if end == start || end < len(rest) && !isSpace(rest[end]) {
+		return "", "", false
+	}
+	return rest[start:end], strings.TrimSpace(rest[end:]), true
+}
+
+// parseScore splits a score event, such as
+//
+//	score: 20  ping: 4  client: 4 Zeh
+//
+// into the score, the ping, in milliseconds, the client number and the name of the player. ok
+// is false if the event is not a well-formed score event.
+func parseScore(event string) (score, ping int, client, player string, ok bool) {
+	rest, ok := strings.CutPrefix(event, "score:")
+	if !ok {
+		return 0, 0, "", "", false
+	}
+	numbers, clientText, ok := strings.Cut(rest, "client:")
+	if !ok {
+		return 0, 0, "", "", false
+	}
+	scoreText, pingText, ok := strings.Cut(numbers, "ping:")
+	if !ok {
+		return 0, 0, "", "", false
+	}
+
+	score, err := strconv.Atoi(strings.TrimSpace(scoreText))
+	if err != nil {
+		return 0, 0, "", "", false
+	}
+	ping, err = strconv.Atoi(strings.TrimSpace(pingText))
+	if err != nil {
+		return 0, 0, "", "", false
+	}
+	// the client number, e.g. " 4 "
+	start := skipSpaces(clientText, 0)
+	end := skipDigits(clientText, start)
+	if end == start || end == len(clientText) || !isSpace(clientText[end]) {
+		return 0, 0, "", "", false
+	}
+	return score, ping, clientText[start:end], clientText[end+1:], true
+}
+
+// parseWeaponStat splits a weapon of a Weapon_Stats event of OSP and CPMA, such as
+//
+//	Weapon_Stats: 2 MachineGun:1367:267:0:0 Shotgun:1168:157:19:9 Given:5296 Recvd:4980
+//
+// into the name of the weapon, the shots fired and the hits, which are followed by the kills
+// and the deaths. ok is false for the totals of the event, such as Given, which have a single
+// number.
+func parseWeaponStat(field string) (weapon string, shots, hits int, ok bool) {
+	weapon, rest, ok := strings.Cut(field, ":")
+	if !ok || weapon == "" {
+		return "", 0, 0, false
+	}
+	shotsText, rest, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", 0, 0, false
+	}
+	hitsText, _, _ := strings.Cut(rest, ":")
+
+	shots, err := strconv.Atoi(shotsText)
+	if err != nil {
+		return "", 0, 0, false
+	}
+	hits, err = strconv.Atoi(hitsText)
+	if err != nil {
+		return "", 0, 0, false
+	}
+	return weapon, shots, hits, true
+}
+
+// findIP returns the IP address in the rest of a client event, without its port, or an empty
+// string if there is none. The address is either the "ip" key of an info string, as in
+// `\ip\10.0.0.1:27960\name\Zeh`, or a standalone word, as in "(10.0.0.1:27960)".
+func findIP(rest string) string {
+	if strings.HasPrefix(rest, `\`) {
+		rest = parseInfoString(rest)["ip"]
+	}
+	for _, word := range strings.Fields(rest) {
+		word = strings.Trim(word, "()[],")
+		if addrPort, err := netip.ParseAddrPort(word); err == nil {
+			return addrPort.Addr().String()
+		}
+		if addr, err := netip.ParseAddr(word); err == nil {
+			return addr.String()
+		}
+	}
+	return ""
+}
+
+// cutPrefixFold is like strings.CutPrefix, but matches the prefix regardless of case.
+func cutPrefixFold(s, prefix string) (after string, found bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// skipSpaces returns the index of the first non-space byte of s at or after start.
+func skipSpaces(s string, start int) int {
+	for start < len(s) && isSpace(s[start]) {
+		start++
+	}
+	return start
+}
+
+// skipDigits returns the index of the first non-digit byte of s at or after start.
+func skipDigits(s string, start int) int {
+	for start < len(s) && isDigit(s[start]) {
+		start++
+	}
+	return start
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isWordChar(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
@@ -0,0 +1,229 @@
+package qlp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Regular expressions which the lexer replaced. They are kept as a reference for its behavior
+// and as the baseline of the benchmarks.
+var (
+	lineHeaderExpr = regexp.MustCompile(`^\s*\d+:\d+\s|^\s*[\d\s:]+`)
+	killExpr       = regexp.MustCompile(`^Kill:\s\d+\s\d+\s\d+:\s(.+)\skilled\s(.+)\sby\s(\w+)`)
+)
+
+const killEvent = "Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT"
+
+// testLogLines returns the lines of the test log along with a few edge cases.
+func testLogLines() []string {
+	lines := []string{
+		"Kill: 2 3 7: Dono da Bola killed Mocinha by MOD_ROCKET_SPLASH",
+		"Kill: 2 3 7: Isgalamido killed Mocinha by MOD_ROCKET by MOD_RAILGUN",
+		"Kill: 2 3 7: a killed b killed c by MOD_SHOTGUN",
+		"Kill: 2 3 7: killed by MOD_SHOTGUN",
+		"Kill: 2 3 7: Zeh killed Zeh by ",
+		"Kill: 2 3: Zeh killed Zeh by MOD_ROCKET",
+		"say: Kill",
+		"26  0:00 ---",
+		"0:00 12 InitGame:",
+		"InitGame:",
+		"",
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(testLogFile))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestLineHeaderEnd(t *testing.T) {
+	for _, line := range testLogLines() {
+		expected := -1
+		if indexes := lineHeaderExpr.FindStringIndex(line); indexes != nil {
+			expected = indexes[1]
+		}
+		assert.Equal(t, expected, lineHeaderEnd(line), line)
+	}
+}
+
+func TestParseKill(t *testing.T) {
+	for _, line := range testLogLines() {
+		event := line
+		if end := lineHeaderEnd(line); end >= 0 {
+			event = line[end:]
+		}
+
+		killer, killed, killedBy, ok := parseKill(event)
+		groups := killExpr.FindStringSubmatch(event)
+		if groups == nil {
+			assert.False(t, ok, event)
+			continue
+		}
+
+		assert.True(t, ok, event)
+		assert.Equal(t, groups[1:], []string{killer, killed, killedBy}, event)
+	}
+}
+
+func TestInfoValue(t *testing.T) {
+	info := `n\Dono da Bola\t\0\model\sarge`
+	for key, value := range parseInfoString(info) {
+		got, ok := infoValue(info, key)
+		assert.True(t, ok, key)
+		assert.Equal(t, value, got, key)
+	}
+	_, ok := infoValue(info, "hmodel")
+	assert.False(t, ok)
+	_, ok = infoValue(`\n`, "n")
+	assert.False(t, ok)
+}
+
+func TestParseKillClients(t *testing.T) {
+	killer, killed, ok := parseKillClients("Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT")
+	assert.True(t, ok)
+	assert.Equal(t, "1022", killer)
+	assert.Equal(t, "2", killed)
+
+	for _, event := range []string{"Kill: <world> killed Isgalamido by MOD_FALLING", "Kill: 3", "Item: 2 3"} {
+		_, _, ok = parseKillClients(event)
+		assert.False(t, ok, event)
+	}
+}
+
+func TestParseKillAllocations(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		parseKill(killEvent)
+		lineHeaderEnd(" 20:54 " + killEvent)
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkParseKill(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		parseKill(killEvent)
+	}
+}
+
+func BenchmarkParseKillRegexp(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		killExpr.FindStringSubmatch(killEvent)
+	}
+}
+
+func BenchmarkParseLog(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(testLogFile)))
+	parser := NewParser(Options{})
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.Parse(bytes.NewReader(testLogFile)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseLogLarge parses a log of hundreds of megabytes, the test log repeated, as the
+// logs of busy servers grow to, without holding it in memory.
+func BenchmarkParseLogLarge(b *testing.B) {
+	const size = 256 << 20
+	repeats := size/len(testLogFile) + 1
+	b.ReportAllocs()
+	b.SetBytes(int64(repeats * len(testLogFile)))
+	parser := NewParser(Options{})
+	for i := 0; i < b.N; i++ {
+		logs := make([]io.Reader, repeats)
+		for j := range logs {
+			logs[j] = bytes.NewReader(testLogFile)
+		}
+		err := parser.ParseFunc(io.MultiReader(logs...), func(Match) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	timestamp, ok := parseTimestamp("20:37")
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Minute+37*time.Second, timestamp)
+
+	timestamp, ok = parseTimestamp("26  0:05")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, timestamp)
+
+	timestamp, ok = parseTimestamp("1022:00")
+	assert.True(t, ok)
+	assert.Equal(t, 1022*time.Minute, timestamp)
+
+	for _, header := range []string{"", "26", "0:60", "a:00", "-1:00"} {
+		_, ok = parseTimestamp(header)
+		assert.False(t, ok, header)
+	}
+}
+
+func TestParseClientEvent(t *testing.T) {
+	client, rest, ok := parseClientEvent("Item: 2 item_quad", "Item:")
+	assert.True(t, ok)
+	assert.Equal(t, "2", client)
+	assert.Equal(t, "item_quad", rest)
+
+	client, rest, ok = parseClientEvent(`ClientUserinfoChanged: 12 n\Zeh\t\0`, "ClientUserinfoChanged:")
+	assert.True(t, ok)
+	assert.Equal(t, "12", client)
+	assert.Equal(t, `n\Zeh\t\0`, rest)
+
+	for _, event := range []string{"Item: item_quad", "Item: 2x item_quad", "Kill: 2 item_quad", "Item:"} {
+		_, _, ok = parseClientEvent(event, "Item:")
+		assert.False(t, ok, event)
+	}
+}
+
+func TestParseScore(t *testing.T) {
+	score, ping, client, player, ok := parseScore("score: 20  ping: 4  client: 4 Zeh")
+	assert.True(t, ok)
+	assert.Equal(t, 20, score)
+	assert.Equal(t, 4, ping)
+	assert.Equal(t, "4", client)
+	assert.Equal(t, "Zeh", player)
+
+	score, _, client, player, ok = parseScore("score: -3  ping: 999  client: 12 Assasinu Credi")
+	assert.True(t, ok)
+	assert.Equal(t, -3, score)
+	assert.Equal(t, "12", client)
+	assert.Equal(t, "Assasinu Credi", player)
+
+	for _, event := range []string{
+		"score: 20  ping: 4", "score: x  ping: 4  client: 4 Zeh", "score: 20  client: 4 Zeh",
+		"score: 20  ping: 4  client: Zeh", "Kill: 20  ping: 4  client: 4 Zeh",
+	} {
+		_, _, _, _, ok = parseScore(event)
+		assert.False(t, ok, event)
+	}
+}
+
+func TestParseWeaponStat(t *testing.T) {
+	weapon, shots, hits, ok := parseWeaponStat("MachineGun:1367:267:0:0")
+	assert.True(t, ok)
+	assert.Equal(t, "MachineGun", weapon)
+	assert.Equal(t, 1367, shots)
+	assert.Equal(t, 267, hits)
+
+	weapon, shots, hits, ok = parseWeaponStat("R.Launcher:40:12")
+	assert.True(t, ok)
+	assert.Equal(t, "R.Launcher", weapon)
+	assert.Equal(t, 40, shots)
+	assert.Equal(t, 12, hits)
+
+	for _, field := range []string{"Given:5296", "Shotgun:x:3:0:0", "Shotgun:3:y:0:0", ":1:1:0:0", "Railgun"} {
+		_, _, _, ok = parseWeaponStat(field)
+		assert.False(t, ok, field)
+	}
+}
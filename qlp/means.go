@@ -0,0 +1,57 @@
+package qlp
+
+// Categories of means of death, as returned by MeansCategory.
+const (
+	CategoryHitscan       = "hitscan"
+	CategoryExplosive     = "explosive"
+	CategoryEnvironmental = "environmental"
+	CategoryMelee         = "melee"
+	CategoryOther         = "other"
+)
+
+// meansCategories maps the means of death of Quake III Arena and Team Arena to their
+// categories.
+var meansCategories = map[string]string{
+	"MOD_MACHINEGUN":     CategoryHitscan,
+	"MOD_SHOTGUN":        CategoryHitscan,
+	"MOD_LIGHTNING":      CategoryHitscan,
+	"MOD_RAILGUN":        CategoryHitscan,
+	"MOD_CHAINGUN":       CategoryHitscan,
+	"MOD_GRENADE":        CategoryExplosive,
+	"MOD_GRENADE_SPLASH": CategoryExplosive,
+	"MOD_ROCKET":         CategoryExplosive,
+	"MOD_ROCKET_SPLASH":  CategoryExplosive,
+	"MOD_PLASMA":         CategoryExplosive,
+	"MOD_PLASMA_SPLASH":  CategoryExplosive,
+	"MOD_BFG":            CategoryExplosive,
+	"MOD_BFG_SPLASH":     CategoryExplosive,
+	"MOD_PROXIMITY_MINE": CategoryExplosive,
+	"MOD_KAMIKAZE":       CategoryExplosive,
+	"MOD_WATER":          CategoryEnvironmental,
+	"MOD_SLIME":          CategoryEnvironmental,
+	"MOD_LAVA":           CategoryEnvironmental,
+	"MOD_CRUSH":          CategoryEnvironmental,
+	"MOD_FALLING":        CategoryEnvironmental,
+	"MOD_TARGET_LASER":   CategoryEnvironmental,
+	"MOD_TRIGGER_HURT":   CategoryEnvironmental,
+	"MOD_GAUNTLET":       CategoryMelee,
+}
+
+// MeansCategory returns the category of a means of death, such as CategoryExplosive for
+// "MOD_ROCKET_SPLASH". Means which fit none of the categories, such as telefrags, suicides
+// and those introduced by mods, are in CategoryOther.
+func MeansCategory(means string) string {
+	if category, ok := meansCategories[means]; ok {
+		return category
+	}
+	return CategoryOther
+}
+
+// specialMeans holds the means of death counted in Match.SpecialDeaths.
+var specialMeans = map[string]bool{
+	"MOD_TELEFRAG": true,
+	"MOD_CRUSH":    true,
+	"MOD_LAVA":     true,
+	"MOD_SLIME":    true,
+	"MOD_FALLING":  true,
+}
@@ -0,0 +1,66 @@
+package qlp
+
+// DedupeMode controls how Merge treats matches whose MatchHash has already been seen.
+type DedupeMode int
+
+const (
+	// DedupeOff keeps every match, even if it was already seen.
+	DedupeOff DedupeMode = iota
+	// DedupeDrop removes matches that were already seen.
+	DedupeDrop
+	// DedupeFlag keeps matches that were already seen, but marks them as duplicates.
+	DedupeFlag
+)
+
+// MergeOptions holds the options for Merge.
+type MergeOptions struct {
+	Dedupe DedupeMode
+}
+
+// Merge concatenates the matches of several sources, in the order they are given. Sources are
+// usually the results of parsing overlapping or rotated logs of the same server, in which case
+// the same game may show up more than once; opts.Dedupe decides what happens to the repeats.
+// The first occurrence of a match is never considered a duplicate.
+func Merge(sources []Matches, opts MergeOptions) Matches {
+	var merged Matches
+	deduper := NewDeduper(opts.Dedupe)
+
+	for _, matches := range sources {
+		for _, match := range matches {
+			if deduper.Filter(&match) {
+				merged = append(merged, match)
+			}
+		}
+	}
+
+	return merged
+}
+
+// Deduper applies a DedupeMode to a stream of matches, for callers that do not hold every
+// match in memory at once. Only the hashes of the matches are retained.
+type Deduper struct {
+	mode DedupeMode
+	seen map[string]struct{}
+}
+
+// NewDeduper returns a Deduper which has not seen any match yet.
+func NewDeduper(mode DedupeMode) *Deduper {
+	return &Deduper{mode: mode, seen: make(map[string]struct{})}
+}
+
+// Filter records the match as seen and reports whether it should be kept. With DedupeFlag,
+// repeated matches are kept and have their Duplicate field set.
+func (d *Deduper) Filter(match *Match) bool {
+	_, duplicate := d.seen[match.MatchHash]
+	d.seen[match.MatchHash] = struct{}{}
+
+	switch {
+	case !duplicate || d.mode == DedupeOff:
+		return true
+	case d.mode == DedupeDrop:
+		return false
+	default:
+		match.Duplicate = true
+		return true
+	}
+}
@@ -0,0 +1,25 @@
+package qlp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	first := Matches{{MatchHash: "a"}, {MatchHash: "b"}}
+	second := Matches{{MatchHash: "b"}, {MatchHash: "c"}}
+	sources := []Matches{first, second}
+
+	merged := Merge(sources, MergeOptions{})
+	assert.Len(t, merged, 4)
+
+	merged = Merge(sources, MergeOptions{Dedupe: DedupeDrop})
+	assert.Equal(t, Matches{{MatchHash: "a"}, {MatchHash: "b"}, {MatchHash: "c"}}, merged)
+
+	merged = Merge(sources, MergeOptions{Dedupe: DedupeFlag})
+	assert.Len(t, merged, 4)
+	assert.False(t, merged[1].Duplicate)
+	assert.True(t, merged[2].Duplicate)
+	assert.False(t, second[0].Duplicate, "sources must not be modified")
+}
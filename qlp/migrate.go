@@ -0,0 +1,122 @@
+package qlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON representation of Match. It is increased whenever
+// the representation changes in a way which breaks its consumers, such as a renamed field, and
+// MigrateMatch upgrades matches written with earlier versions.
+const SchemaVersion = 1
+
+// migrations upgrade the JSON object of a match from a schema version to the next: the one at
+// index i upgrades matches of version i. Version 0 is the one of the outputs written before
+// schema_version was added.
+var migrations = [SchemaVersion]func(match map[string]json.RawMessage) error{
+	migrateFromV0,
+}
+
+// MigrateMatch decodes the JSON object of a match written with any schema version up to
+// SchemaVersion, upgrading it to the current one. Data which earlier versions did not record
+// and which cannot be derived from what they did is left empty.
+func MigrateMatch(data []byte) (Match, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return Match{}, err
+	}
+
+	version := 0
+	if raw, ok := object["schema_version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return Match{}, fmt.Errorf("invalid schema_version: %w", err)
+		}
+	}
+	if version < 0 || version > SchemaVersion {
+		return Match{}, fmt.Errorf("unsupported schema_version %d, the latest being %d", version, SchemaVersion)
+	}
+	for _, migrate := range migrations[version:] {
+		if err := migrate(object); err != nil {
+			return Match{}, err
+		}
+	}
+
+	upgraded, err := json.Marshal(object)
+	if err != nil {
+		return Match{}, err
+	}
+	var match Match
+	if err := json.Unmarshal(upgraded, &match); err != nil {
+		return Match{}, err
+	}
+	match.SchemaVersion = SchemaVersion
+	return match, nil
+}
+
+// MigrateMatches decodes the JSON object of Matches, keyed "game_1", "game_2", etc., upgrading
+// each match as MigrateMatch does. The matches are returned in the order of their keys.
+func MigrateMatches(data []byte) (Matches, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(object))
+	numbers := make(map[string]int, len(object))
+	for key := range object {
+		number, err := strconv.Atoi(strings.TrimPrefix(key, "game_"))
+		if err != nil || !strings.HasPrefix(key, "game_") {
+			return nil, fmt.Errorf("unexpected key %q, expected game_N", key)
+		}
+		keys = append(keys, key)
+		numbers[key] = number
+	}
+	slices.SortFunc(keys, func(a, b string) int { return numbers[a] - numbers[b] })
+
+	matches := make(Matches, 0, len(keys))
+	for _, key := range keys {
+		match, err := MigrateMatch(object[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// migrateFromV0 derives the fields added before schema_version which only depend on fields
+// the first outputs already had: player_count and competitiveness.
+func migrateFromV0(match map[string]json.RawMessage) error {
+	var players []string
+	if err := unmarshalField(match, "players", &players); err != nil {
+		return err
+	}
+	kills := make(map[string]int)
+	if err := unmarshalField(match, "kills", &kills); err != nil {
+		return err
+	}
+
+	if _, ok := match["player_count"]; !ok {
+		match["player_count"], _ = json.Marshal(len(players))
+	}
+	if _, ok := match["competitiveness"]; !ok {
+		match["competitiveness"], _ = json.Marshal(competitiveness(players, kills))
+	}
+	return nil
+}
+
+// unmarshalField decodes the field of the match with the given key into v, leaving v untouched
+// if the match has no such field.
+func unmarshalField(match map[string]json.RawMessage, key string, v any) error {
+	raw, ok := match[key]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return nil
+}
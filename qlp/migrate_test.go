@@ -0,0 +1,47 @@
+package qlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateMatch(t *testing.T) {
+	v0 := `{"total_kills":3,"players":["Isgalamido","Mocinha"],"kills":{"Isgalamido":2,"Mocinha":1},"kills_by_means":{"MOD_ROCKET":3}}`
+	match, err := MigrateMatch([]byte(v0))
+	assert.NoError(t, err)
+	assert.Equal(t, Match{
+		SchemaVersion:   SchemaVersion,
+		TotalKills:      3,
+		Players:         []string{"Isgalamido", "Mocinha"},
+		PlayerCount:     2,
+		Kills:           map[string]int{"Isgalamido": 2, "Mocinha": 1},
+		KillsByMeans:    map[string]int{"MOD_ROCKET": 3},
+		Competitiveness: 0.5,
+	}, match)
+
+	matches, err := ParseLog(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+	current, err := json.Marshal(matches[0])
+	assert.NoError(t, err)
+	match, err = MigrateMatch(current)
+	assert.NoError(t, err)
+	assert.Equal(t, matches[0], match)
+
+	_, err = MigrateMatch([]byte(`{"schema_version":99}`))
+	assert.Error(t, err)
+}
+
+func TestMigrateMatches(t *testing.T) {
+	data := `{"game_10":{"map_name":"q3dm6"},"game_2":{"map_name":"q3dm17"}}`
+	matches, err := MigrateMatches([]byte(data))
+	assert.NoError(t, err)
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "q3dm17", matches[0].MapName)
+	assert.Equal(t, "q3dm6", matches[1].MapName)
+
+	_, err = MigrateMatches([]byte(`{"matches":{}}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package qlp
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// unnamedPlayer is the name Quake III Arena gives players without one, which NameRules give
+// the players whose names they leave empty.
+const unnamedPlayer = "UnnamedPlayer"
+
+// NameRules normalize the names of players as they are read from the log, before anything is
+// counted under them, so that published results show neither offensive names nor names made
+// to pass for those of other players. The zero value leaves names as they are.
+type NameRules struct {
+	// Trim holds the characters stripped from both ends of names, such as "-_.*", which
+	// players pad their names with to stand out.
+	Trim string
+	// CollapseSpaces replaces every run of whitespace in names with a single space and strips
+	// it from their ends, so that "Dono da Bola" and " Dono  da Bola" are the same player.
+	CollapseSpaces bool
+	// Banned matches the words not shown in names, whose characters are replaced with '*'.
+	Banned *regexp.Regexp
+}
+
+// NewNameRules creates and returns NameRules whose Banned matches any of the banned words,
+// regardless of case.
+func NewNameRules(trim string, collapseSpaces bool, banned []string) NameRules {
+	rules := NameRules{Trim: trim, CollapseSpaces: collapseSpaces}
+	if len(banned) > 0 {
+		words := make([]string, len(banned))
+		for i, word := range banned {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		rules.Banned = regexp.MustCompile(`(?i)` + strings.Join(words, "|"))
+	}
+	return rules
+}
+
+// apply returns the name normalized by the rules. A name left empty by them becomes
+// unnamedPlayer.
+func (r NameRules) apply(name string) string {
+	normalized := strings.TrimFunc(name, func(c rune) bool {
+		return strings.ContainsRune(r.Trim, c) || r.CollapseSpaces && unicode.IsSpace(c)
+	})
+	if r.CollapseSpaces {
+		normalized = strings.Join(strings.Fields(normalized), " ")
+	}
+	if r.Banned != nil {
+		normalized = r.Banned.ReplaceAllStringFunc(normalized, func(word string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(word))
+		})
+	}
+	if normalized == "" && name != "" {
+		return unnamedPlayer
+	}
+	return normalized
+}
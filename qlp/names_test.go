@@ -0,0 +1,45 @@
+package qlp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameRulesApply(t *testing.T) {
+	rules := NewNameRules("-_*", true, []string{"noob", "x.y"})
+
+	for name, want := range map[string]string{
+		"Zeh":               "Zeh",
+		"--Zeh--":           "Zeh",
+		" _ Dono  da\tBola": "Dono da Bola",
+		"NoOb Slayer":       "**** Slayer",
+		"xzy":               "xzy",
+		"x.y":               "***",
+		"---":               "UnnamedPlayer",
+		"":                  "",
+	} {
+		assert.Equal(t, want, rules.apply(name), name)
+	}
+
+	assert.Equal(t, "  --Zeh ", NameRules{}.apply("  --Zeh "))
+}
+
+func TestNameRules(t *testing.T) {
+	log := "  0:00 InitGame:\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\--Zeh--\\t\\0\n" +
+		"  0:02 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:03 Kill: 2 3 7: --Zeh-- killed Zeh by MOD_ROCKET_SPLASH\n" +
+		"  0:04 Kill: 1022 4 22: <world> killed  Noob  Mocinha by MOD_TRIGGER_HURT\n" +
+		"  0:05 " + matchSeparator + "\n"
+
+	opts := Options{NameRules: NewNameRules("-", true, []string{"noob"})}
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+
+	// both clients are connected under the same name, so the second is given an alias
+	assert.ElementsMatch(t, []string{"Zeh", "Zeh (2)", "**** Mocinha"}, matches[0].Players)
+	assert.Equal(t, 1, matches[0].Kills["Zeh"])
+	assert.Equal(t, -1, matches[0].Kills["**** Mocinha"])
+}
@@ -0,0 +1,103 @@
+package qlp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// IsCompressed reports whether the log file at path is compressed, as told by the extension
+// of its name: .gz, .bz2 or .zst.
+func IsCompressed(path string) bool {
+	switch filepath.Ext(path) {
+	case ".gz", ".bz2", ".zst":
+		return true
+	}
+	return false
+}
+
+// OpenLog opens the log file at path for reading. Logs compressed with gzip, bzip2 or
+// zstd, as told by IsCompressed, are decompressed as they are read, as archived logs usually
+// are compressed. Closing the returned reader closes the file.
+func OpenLog(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !IsCompressed(path) {
+		return file, nil
+	}
+
+	decoder, err := newDecoder(file, filepath.Ext(path))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return compressedLog{ReadCloser: decoder, file: file}, nil
+}
+
+// NewLogReader returns a reader of the log read from r which decompresses it if it is
+// compressed with gzip, bzip2 or zstd, as told by the magic number it starts with. It is
+// meant for logs without a name, such as the standard input. Closing the returned reader does
+// not close r.
+func NewLogReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var ext string
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		ext = ".gz"
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		ext = ".bz2"
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		ext = ".zst"
+	default:
+		return io.NopCloser(buffered), nil
+	}
+	return newDecoder(buffered, ext)
+}
+
+// newDecoder returns a reader decompressing r, compressed as told by the extension ext: .gz,
+// .bz2 or .zst.
+func newDecoder(r io.Reader, ext string) (io.ReadCloser, error) {
+	switch ext {
+	case ".gz":
+		decoder, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder, nil
+	case ".bz2":
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case ".zst":
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unknown compression %s", ext)
+}
+
+// compressedLog reads a compressed log file through its decoder.
+type compressedLog struct {
+	io.ReadCloser
+	file *os.File
+}
+
+// Close releases the decoder and closes the file.
+func (l compressedLog) Close() error {
+	l.ReadCloser.Close()
+	return l.file.Close()
+}
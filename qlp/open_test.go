@@ -0,0 +1,106 @@
+package qlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenLog(t *testing.T) {
+	log, err := os.ReadFile("test_log.txt")
+	assert.NoError(t, err)
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "games.log")
+	assert.NoError(t, os.WriteFile(plain, log, 0o644))
+
+	gzipped := filepath.Join(dir, "games.log.gz")
+	file, err := os.Create(gzipped)
+	assert.NoError(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	gzipWriter.Write(log)
+	assert.NoError(t, gzipWriter.Close())
+	assert.NoError(t, file.Close())
+
+	zstded := filepath.Join(dir, "games.log.zst")
+	file, err = os.Create(zstded)
+	assert.NoError(t, err)
+	zstdWriter, err := zstd.NewWriter(file)
+	assert.NoError(t, err)
+	zstdWriter.Write(log)
+	assert.NoError(t, zstdWriter.Close())
+	assert.NoError(t, file.Close())
+
+	for _, path := range []string{plain, gzipped, zstded} {
+		r, err := OpenLog(path)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err, path)
+		assert.Equal(t, log, data, path)
+		assert.NoError(t, r.Close(), path)
+	}
+
+	// a plain log named as compressed fails to decompress
+	misnamed := filepath.Join(dir, "plain.log.gz")
+	assert.NoError(t, os.WriteFile(misnamed, log, 0o644))
+	_, err = OpenLog(misnamed)
+	assert.Error(t, err)
+
+	_, err = OpenLog(filepath.Join(dir, "missing.log"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestIsCompressed(t *testing.T) {
+	assert.True(t, IsCompressed("games.log.gz"))
+	assert.True(t, IsCompressed("games.log.bz2"))
+	assert.True(t, IsCompressed("/var/log/games.log.zst"))
+	assert.False(t, IsCompressed("games.log"))
+	assert.False(t, IsCompressed("games.gz.log"))
+}
+
+func TestNewLogReader(t *testing.T) {
+	log, err := os.ReadFile("test_log.txt")
+	assert.NoError(t, err)
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(log)
+	assert.NoError(t, gzipWriter.Close())
+
+	var zstded bytes.Buffer
+	zstdWriter, err := zstd.NewWriter(&zstded)
+	assert.NoError(t, err)
+	zstdWriter.Write(log)
+	assert.NoError(t, zstdWriter.Close())
+
+	for name, data := range map[string][]byte{"plain": log, "gzip": gzipped.Bytes(), "zstd": zstded.Bytes()} {
+		r, err := NewLogReader(bytes.NewReader(data))
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		read, err := io.ReadAll(r)
+		assert.NoError(t, err, name)
+		assert.Equal(t, log, read, name)
+		assert.NoError(t, r.Close(), name)
+	}
+
+	// logs shorter than any magic number are read as they are
+	r, err := NewLogReader(strings.NewReader("0:"))
+	assert.NoError(t, err)
+	read, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "0:", string(read))
+
+	// a log starting as gzip which is not fails to decompress
+	_, err = NewLogReader(bytes.NewReader([]byte{0x1f, 0x8b, 0, 0}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package qlp
+
+import (
+	"slices"
+	"time"
+)
+
+// Options holds the optional settings of the parser. The zero value parses logs the same way
+// as ParseLog.
+//
+// Memory usage: the parser keeps only the state of the match currently open, which consists of
+// aggregated counters (and of the kill feed, if enabled), so it grows with the number of
+// distinct players and means of death of a single match rather than with the size of the log.
+// ParseLog and ParseLogWithOptions additionally retain every finished match; ParseLogFunc does
+// not. MaxLineLength and MaxMatchEntries put hard upper bounds on what remains, for hosts with
+// very little memory.
+type Options struct {
+	// MaxLineLength caps the length, in bytes, of a single log line. Parsing fails on longer
+	// lines. Zero means bufio.MaxScanTokenSize.
+	MaxLineLength int
+
+	// MaxMatchEntries caps how many entries each per-match collection (players, kills by
+	// means, kill feed, ...) retains. Entries beyond the cap are dropped and the match is
+	// marked as truncated. Zero means no limit.
+	MaxMatchEntries int
+
+	// KillFeed enables Match.KillFeed, which lists every kill along with its time. It is
+	// needed by analyses of how matches unfold, at the cost of memory proportional to the
+	// number of kills of a match.
+	KillFeed bool
+
+	// Powerups enables Match.Powerups, which lists every powerup picked up along with its
+	// time, so that kills can be correlated with the powerups.
+	Powerups bool
+
+	// ItemControl enables Match.ItemPickups, which counts the pickups of the Mega Health and
+	// of the armors by each player, telling who controlled the map in duels.
+	ItemControl bool
+
+	// Votes enables Match.Votes, which lists the votes called in the match, with their
+	// ballots and results, for servers which log them.
+	Votes bool
+
+	// Raw enables Match.Raw, which holds the lines of the log each match was parsed from.
+	Raw bool
+
+	// AdminActions enables Match.AdminActions, which lists the kicks, bans and mutes logged
+	// by servers and admin mods.
+	AdminActions bool
+
+	// Pings enables Match.Pings, which summarizes the pings of the players as logged in the
+	// score lines.
+	Pings bool
+
+	// Accuracy enables Match.Accuracy, which holds the accuracy of the players with each
+	// weapon, for logs of mods such as OSP and CPMA which write it at the end of matches.
+	Accuracy bool
+
+	// ClientIPs enables Match.PlayerIPs, for logs of server builds which write the address
+	// of clients when they connect. Addresses are personal data, so it is off by default.
+	ClientIPs bool
+
+	// MeansCategories enables Match.KillsByCategory, which groups the kills by means of death
+	// into coarse categories such as hitscan and explosive.
+	MeansCategories bool
+
+	// Decoding selects how lines which are not valid UTF-8 are decoded. Every string in the
+	// parsed matches is valid UTF-8 regardless, and a warning is issued for each such line.
+	Decoding Decoding
+
+	// Strict enables a warning, with an UnknownEventError as its reason, for every event
+	// whose type is not one of those written by Quake III Arena. It helps verifying whether
+	// the parser covers the dialect of a new log.
+	Strict bool
+
+	// Resync makes the parser recover from corrupted regions of a log, such as binary garbage
+	// left by a crash, instead of failing. A region starts at a line which is malformed or
+	// longer than MaxLineLength and ends right before the next InitGame event, and a warning
+	// with a CorruptRegionError as its reason reports it. The match open when a region starts
+	// is dropped, as its data is incomplete.
+	Resync bool
+
+	// Lenient makes the parser skip malformed lines, those without a timestamp, instead of
+	// failing, with a warning with ErrMalformedLine as its reason for each of them. Unlike
+	// with Resync, the rest of the match is still parsed. Resync takes precedence.
+	Lenient bool
+
+	// MaxErrors makes parsing fail with ErrTooManyErrors once more than MaxErrors lines were
+	// skipped for being corrupted or malformed, which guards against parsing a file of a
+	// completely different format into an empty result. Zero means no limit. ParseLogParallel
+	// applies the limit to each chunk separately.
+	MaxErrors int
+
+	// Scoring selects how kills, kills of teammates, suicides and deaths by <world> are
+	// scored in Match.Kills, such as ClassicScoring, the default, or Q3Scoring.
+	Scoring ScoreModel
+
+	// WorldDeaths selects whether the deaths of players killed by <world>, such as by falling
+	// into the void, take a kill off them, which is what Quake III Arena does with the score,
+	// are counted in Match.WorldDeaths, or both.
+	WorldDeaths WorldDeathMode
+
+	// WorldEntities lists the killers of the Kill events which stand for the world besides
+	// "<world>", for the mods which name it differently, such as "<environment>", keyed by
+	// dialect: the gamename of the InitGame event of the matches. The list of a dialect
+	// replaces its list in DefaultWorldEntities, and the list under AllDialects applies to
+	// every dialect on top. Their kills are deaths by the world, recorded as kills by "<world>"
+	// in the matches.
+	WorldEntities map[string][]string
+
+	// Restarts selects whether matches restarted with map_restart are kept separate from the
+	// match they restart, which is the default, or merged into it.
+	Restarts RestartMode
+
+	// NameRules normalizes the names of the players, such as by replacing banned words.
+	NameRules NameRules
+
+	// EventRules describes extra events to be captured into Match.Custom.
+	EventRules []EventRule
+
+	// EventCounts, when not nil, is incremented for the type of every event in the log (such
+	// as "InitGame", "Kill", "Item" or "say"), which shows what data a log has to offer. Blank
+	// events are not counted.
+	EventCounts map[string]int
+
+	// TimeZone is the time zone of the g_timestamp setting of the InitGame events, and the
+	// one Match.StartedAt and the other wall-clock times are written in. Nil means time.Local.
+	TimeZone *time.Location
+
+	// OnWarning, when set, is called with every warning found while parsing.
+	OnWarning func(ParseWarning)
+}
+
+// worldEntity is the killer of the deaths by the world in Quake III Arena logs and in the
+// parsed matches.
+const worldEntity = "<world>"
+
+// AllDialects is the key of Options.WorldEntities whose list applies to every dialect.
+const AllDialects = ""
+
+// DefaultWorldEntities lists the killers which stand for the world besides "<world>" in the
+// logs of each dialect, keyed by gamename, unless Options.WorldEntities says otherwise. The
+// game code of Quake III Arena, which OSP and CPMA build on, names the entities which kill
+// without being players, such as the shooters of some maps, "<non-client>".
+var DefaultWorldEntities = map[string][]string{
+	"baseq3": {"<non-client>"},
+	"osp":    {"<non-client>"},
+	"cpma":   {"<non-client>"},
+}
+
+// WorldDeathMode controls how the deaths of players killed by <world> are counted.
+type WorldDeathMode int
+
+const (
+	// WorldDeathsDecrement scores the world deaths in Match.Kills, taking a kill off the
+	// players for each of them unless Options.Scoring says otherwise, so that Match.Kills
+	// may go below zero.
+	WorldDeathsDecrement WorldDeathMode = iota
+	// WorldDeathsCount counts the world deaths in Match.WorldDeaths instead, so that
+	// Match.Kills is never negative.
+	WorldDeathsCount
+	// WorldDeathsBoth takes a kill off the players and counts the deaths in
+	// Match.WorldDeaths.
+	WorldDeathsBoth
+)
+
+// isWorld reports whether the killer of a Kill event of a match of the given dialect, its
+// gamename, stands for the world.
+func (o Options) isWorld(gameName, killer string) bool {
+	if killer == worldEntity || slices.Contains(o.WorldEntities[AllDialects], killer) {
+		return true
+	}
+	entities, ok := o.WorldEntities[gameName]
+	if !ok {
+		entities = DefaultWorldEntities[gameName]
+	}
+	return slices.Contains(entities, killer)
+}
+
+// roomFor reports whether a per-match collection currently holding size entries may grow.
+func (o Options) roomFor(size int) bool {
+	return o.MaxMatchEntries <= 0 || size < o.MaxMatchEntries
+}
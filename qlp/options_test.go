@@ -0,0 +1,81 @@
+package qlp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxMatchEntries(t *testing.T) {
+	log := "  0:00 InitGame:\n" +
+		"  0:01 Kill: 0 1 2: Isgalamido killed Mocinha by MOD_ROCKET\n" +
+		"  0:02 Kill: 0 1 2: Zeh killed Isgalamido by MOD_RAILGUN\n" +
+		"  0:03 Kill: 1022 1 22: <world> killed Zeh by MOD_TRIGGER_HURT\n" +
+		"  0:04 ------------------------------------------------------------\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{MaxMatchEntries: 2})
+	assert.NoError(t, err)
+
+	match := matches[0]
+	assert.True(t, match.Truncated)
+	assert.Equal(t, 3, match.TotalKills)
+	assert.Equal(t, []string{"Isgalamido", "Mocinha"}, match.Players)
+	assert.Equal(t, map[string]int{"Isgalamido": 1, "Mocinha": 0}, match.Kills)
+	assert.Equal(t, map[string]int{"MOD_ROCKET": 1, "MOD_RAILGUN": 1}, match.KillsByMeans)
+
+	matches, _, err = ParseLogWithOptions(strings.NewReader(log), Options{})
+	assert.NoError(t, err)
+	assert.False(t, matches[0].Truncated)
+	assert.Len(t, matches[0].Players, 3)
+}
+
+func TestMaxLineLength(t *testing.T) {
+	log := "  0:00 InitGame: \\" + strings.Repeat("x", 200) + "\n"
+
+	_, _, err := ParseLogWithOptions(strings.NewReader(log), Options{MaxLineLength: 100})
+	assert.Error(t, err)
+}
+
+func TestWorldEntities(t *testing.T) {
+	log := "  0:00 InitGame:\n" +
+		"  0:01 Kill: 1022 2 22: <environment> killed Zeh by MOD_TRIGGER_HURT\n" +
+		"  0:02 Kill: 1022 2 22: <world> killed Zeh by MOD_TRIGGER_HURT\n" +
+		"  0:03 Kill: 3 2 7: Mocinha killed Zeh by MOD_ROCKET_SPLASH\n" +
+		"  0:04 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{KillFeed: true})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"<environment>", "Zeh", "Mocinha"}, matches[0].Players)
+	assert.Equal(t, 1, matches[0].Kills["<environment>"])
+
+	opts := Options{KillFeed: true, WorldEntities: map[string][]string{AllDialects: {"<environment>"}}}
+	matches, _, err = ParseLogWithOptions(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Zeh", "Mocinha"}, matches[0].Players)
+	assert.Equal(t, -2, matches[0].Kills["Zeh"])
+	assert.Equal(t, "<world>", matches[0].KillFeed[0].Killer)
+}
+
+func TestWorldEntitiesDialects(t *testing.T) {
+	match := func(gameName string) string {
+		return "  0:00 InitGame: \\gamename\\" + gameName + "\n" +
+			"  0:01 Kill: 3 2 22: <non-client> killed Zeh by MOD_ROCKET\n" +
+			"  0:02 Kill: 1022 2 22: <environment> killed Zeh by MOD_TRIGGER_HURT\n" +
+			"  0:03 " + matchSeparator + "\n"
+	}
+	log := match("baseq3") + match("q3ut4")
+	opts := Options{WorldEntities: map[string][]string{"q3ut4": {"<environment>"}}}
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	// baseq3 takes its defaults, while q3ut4 has a list of its own
+	assert.Equal(t, []string{"<environment>", "Zeh"}, matches[0].Players)
+	assert.Equal(t, []string{"<non-client>", "Zeh"}, matches[1].Players)
+
+	// the list of a dialect replaces its defaults
+	opts.WorldEntities["baseq3"] = nil
+	matches, _, err = ParseLogWithOptions(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"<non-client>", "<environment>", "Zeh"}, matches[0].Players)
+}
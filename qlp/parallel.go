@@ -0,0 +1,174 @@
+package qlp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ParseLogParallel parses a log of the given size in bytes on several goroutines, returning the
+// same Matches as ParseLogWithOptions would. It is meant for multi-gigabyte archives, where a
+// single goroutine is the bottleneck. With workers below 1, runtime.GOMAXPROCS(0) goroutines
+// are used.
+//
+// The log is split into chunks of roughly equal size, each one starting right after a match
+// separator line. The parser is always waiting for the next InitGame after such a line, so
+// chunks can be parsed independently of each other and their matches concatenated in order.
+func ParseLogParallel(log io.ReaderAt, size int64, opts Options, workers int) (Matches, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	bounds, err := chunkBounds(log, size, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := len(bounds) - 1
+	results := make([]Matches, chunks)
+	warnings := make([][]ParseWarning, chunks)
+	eventCounts := make([]map[string]int, chunks)
+	lines := make([]int, chunks)
+	errs := make([]error, chunks)
+
+	var wg sync.WaitGroup
+	for i := 0; i < chunks; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// warnings are buffered, so that the callback is neither called concurrently
+			// nor out of order
+			chunkOpts := opts
+			chunkOpts.OnWarning = func(warning ParseWarning) {
+				warnings[i] = append(warnings[i], warning)
+			}
+			if opts.EventCounts != nil {
+				eventCounts[i] = make(map[string]int)
+				chunkOpts.EventCounts = eventCounts[i]
+			}
+
+			parser := NewParser(chunkOpts)
+			chunk := io.NewSectionReader(log, bounds[i], bounds[i+1]-bounds[i])
+			results[i], errs[i] = parser.Parse(chunk)
+			lines[i] = parser.lines
+		}(i)
+	}
+	wg.Wait()
+
+	var matches Matches
+	firstLine := 0
+	for i := 0; i < chunks; i++ {
+		if opts.OnWarning != nil {
+			for _, warning := range warnings[i] {
+				warning.Line += firstLine
+				if region, ok := warning.Reason.(CorruptRegionError); ok {
+					region.Start += bounds[i]
+					region.End += bounds[i]
+					warning.Reason = region
+				}
+				opts.OnWarning(warning)
+			}
+		}
+		if errs[i] != nil {
+			return nil, fmt.Errorf("chunk starting at byte %d: %w", bounds[i], errs[i])
+		}
+		for typ, count := range eventCounts[i] {
+			opts.EventCounts[typ] += count
+		}
+
+		matches = append(matches, results[i]...)
+		firstLine += lines[i]
+	}
+
+	return matches, nil
+}
+
+// chunkBounds splits the log into at most n chunks. It returns the offsets where each chunk
+// starts, followed by the size of the log.
+func chunkBounds(log io.ReaderAt, size int64, n int) ([]int64, error) {
+	bounds := []int64{0}
+	for i := 1; i < n; i++ {
+		target := size * int64(i) / int64(n)
+		if target <= bounds[len(bounds)-1] {
+			continue
+		}
+
+		bound, err := nextChunkStart(log, target, size)
+		if err != nil {
+			return nil, err
+		}
+		if bound >= size {
+			break
+		}
+		bounds = append(bounds, bound)
+	}
+
+	return append(bounds, size), nil
+}
+
+// nextChunkStart returns the offset of the first line following a match separator line
+// which starts after the given offset, or size if there is none. Separators which may be
+// followed by a restart of their match are skipped, as telling restarts apart takes the
+// match which ended.
+func nextChunkStart(log io.ReaderAt, offset, size int64) (int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(log, offset, size-offset))
+
+	// offset most likely falls in the middle of a line, which cannot be told apart from a
+	// separator line
+	skipped, err := reader.ReadString('\n')
+	offset += int64(len(skipped))
+
+	// exited is set once the match being read logged an Exit event, which is unknown for the
+	// match the offset falls in
+	exited := false
+	// start is the offset following a separator, where a chunk starts unless the match is
+	// restarted, and startTime the timestamp of the separator
+	start, startTime := int64(-1), ""
+	for err == nil {
+		var line string
+		line, err = reader.ReadString('\n')
+		offset += int64(len(line))
+		if err != nil {
+			break
+		}
+
+		headerEnd := lineHeaderEnd(line)
+		if headerEnd < 0 {
+			if start >= 0 {
+				return start, nil
+			}
+			continue
+		}
+		timestamp, event := strings.TrimSpace(line[:headerEnd]), line[headerEnd:]
+		switch {
+		case strings.HasPrefix(event, "---"):
+			if exited {
+				return offset, nil
+			}
+			if start < 0 {
+				start, startTime = offset, timestamp
+			}
+		case start >= 0:
+			// only an InitGame event right after the separators, at the same time, restarts
+			// the match
+			if !strings.HasPrefix(event, "InitGame:") || timestamp != startTime {
+				return start, nil
+			}
+			start, exited = -1, false
+		case strings.HasPrefix(event, "Exit:"):
+			exited = true
+		case strings.HasPrefix(event, "InitGame:"):
+			exited = false
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		return size, nil
+	}
+	return 0, err
+}
@@ -0,0 +1,40 @@
+package qlp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogParallel(t *testing.T) {
+	expected, err := ParseLog(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+
+	for _, workers := range []int{0, 1, 2, 3, 8, 64, 10000} {
+		matches, err := ParseLogParallel(bytes.NewReader(testLogFile), int64(len(testLogFile)), Options{}, workers)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, matches, "workers: %d", workers)
+	}
+}
+
+func TestParseLogParallelError(t *testing.T) {
+	log := "  0:00 InitGame:\n  0:01 " + matchSeparator + "\n" + strings.Repeat("  0:02 Item: 2 ammo_rockets\n", 100) +
+		"BadLine\n  0:03 " + matchSeparator + "\n"
+
+	_, err := ParseLogParallel(strings.NewReader(log), int64(len(log)), Options{}, 4)
+	assert.ErrorContains(t, err, "is malformed")
+}
+
+func TestParseLogParallelWarnings(t *testing.T) {
+	log := strings.Repeat("  0:00 InitGame:\n  0:01 "+matchSeparator+"\n", 50) + "  0:02 say: Jo\xe3o\n"
+
+	var warnings []ParseWarning
+	opts := Options{OnWarning: func(warning ParseWarning) { warnings = append(warnings, warning) }}
+	_, err := ParseLogParallel(strings.NewReader(log), int64(len(log)), opts, 4)
+	assert.NoError(t, err)
+
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, 101, warnings[0].Line)
+}
@@ -0,0 +1,263 @@
+package qlp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"sync/atomic"
+)
+
+// Parser parses logs according to a set of Options. A Parser can be reused for any number of
+// logs, one after the other, which saves the allocations of its line buffer and per-match
+// state; long-lived services parsing many logs should keep one around instead of calling
+// ParseLog every time.
+//
+// A Parser holds the state of the log it is parsing, so it parses one log at a time and must
+// not be used by more than one goroutine at once; doing so panics rather than racing. Services
+// parsing logs concurrently should give each goroutine a Parser of its own, which Clone makes.
+// The package level functions, such as ParseLog, create a Parser per call and are safe for
+// concurrent use.
+type Parser struct {
+	opts   Options
+	state  *logParser
+	buffer []byte
+	// lines is the number of lines read from the latest log.
+	lines int
+	// onEvent, when set, is called with every event before it is parsed.
+	onEvent func(event string)
+	// busy is set while a log is being parsed, to catch concurrent use.
+	busy atomic.Bool
+}
+
+// NewParser creates and returns a Parser which parses logs according to opts.
+func NewParser(opts Options) *Parser {
+	return &Parser{opts: opts, state: newLogParser()}
+}
+
+// Clone returns a new Parser with the Options of p and none of its state, which can be used
+// concurrently with p. The Options are copied as they are, so the OnWarning callback is called
+// from every clone and must be safe for concurrent use, and EventCounts, a map shared by every
+// clone, must not be set on Parsers used concurrently.
+func (p *Parser) Clone() *Parser {
+	return NewParser(p.opts)
+}
+
+// Reset discards the state left by the previous log, such as a match that was still open when
+// parsing failed, while keeping the allocations for reuse. Parse and ParseFunc reset the
+// Parser before they start, so calling Reset is only needed to release that state early.
+func (p *Parser) Reset() {
+	*p.state = logParser{evParser: lookingForGameParser{}, match: p.state.match}
+}
+
+// OpenMatch returns the match which was still open when the latest parse stopped, such as
+// when the log ended in the middle of a match or reading it failed, marked as InProgress.
+// With RestartMerge, a match which ended without an Exit event but could still be restarted
+// is open as well. It reports false if no match was open.
+func (p *Parser) OpenMatch() (Match, bool) {
+	var m *matchParser
+	switch parser := p.state.evParser.(type) {
+	case *matchParser:
+		m = parser
+	case endedParser:
+		m = parser.match
+	default:
+		return Match{}, false
+	}
+
+	match := m.match()
+	match.anchorTimes(p.opts.TimeZone)
+	match.InProgress = true
+	return match, true
+}
+
+// Parse reads and parses the log from an io.Reader, returning a slice of Matches or an error.
+func (p *Parser) Parse(log io.Reader) (Matches, error) {
+	var matches Matches
+	err := p.ParseFunc(log, func(match Match) error {
+		matches = append(matches, match)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// errStopped is returned to ParseFunc by the iterators of ParseSeq once their loop is broken
+// out of.
+var errStopped = errors.New("iteration stopped")
+
+// ParseSeq returns an iterator over the matches of the log read from an io.Reader, parsed as
+// the loop asks for them, so that, as with ParseFunc, memory usage does not grow with the size
+// of the log. If parsing fails, the error is yielded along with a zero Match as the last
+// element. Breaking out of the loop stops reading the log.
+func (p *Parser) ParseSeq(log io.Reader) iter.Seq2[Match, error] {
+	return func(yield func(Match, error) bool) {
+		err := p.ParseFunc(log, func(match Match) error {
+			if !yield(match, nil) {
+				return errStopped
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopped) {
+			yield(Match{}, err)
+		}
+	}
+}
+
+// checkEvent issues a warning if the type of the event is not known.
+func (p *Parser) checkEvent(event string) {
+	typ := eventType(event)
+	if _, ok := knownEvents[typ]; ok || typ == "" {
+		return
+	}
+	p.state.warn(UnknownEventError{Type: typ})
+}
+
+// ParseFunc reads and parses the log from an io.Reader, calling fn with each match as soon as
+// it is finished. If fn returns an error, parsing stops and the error is returned.
+//
+// ParseFunc panics if the Parser is already parsing a log, such as from another goroutine or
+// from fn.
+func (p *Parser) ParseFunc(log io.Reader, fn func(Match) error) error {
+	if !p.busy.CompareAndSwap(false, true) {
+		panic("qlp: Parser used to parse more than one log at a time; use Parser.Clone for each goroutine")
+	}
+	defer p.busy.Store(false)
+
+	p.Reset()
+	p.state.opts = p.opts
+	p.state.onMatch = fn
+
+	maxLineLength := p.opts.MaxLineLength
+	if maxLineLength <= 0 {
+		maxLineLength = bufio.MaxScanTokenSize
+	}
+	if p.buffer == nil {
+		p.buffer = make([]byte, 0, min(maxLineLength, 4096))
+	}
+
+	// offset and lineStart track the position of the lines in the log, which corrupted
+	// regions are reported with
+	var offset, lineStart int64
+	// overlong is set when the current token is a piece of a line longer than maxLineLength,
+	// which is only handed over in pieces with Options.Resync
+	overlong := false
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(p.buffer, maxLineLength)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := scanLines(data, atEOF)
+		overlong = false
+		if p.opts.Resync && token == nil && err == nil && !atEOF && len(data) >= maxLineLength {
+			advance, token, overlong = len(data), data, true
+		}
+		lineStart, offset = offset, offset+int64(advance)
+		return advance, token, err
+	})
+
+	var region *CorruptRegionError
+	var regionLine int
+	var regionText string
+	continued := false // whether the current token continues an overlong line
+	failedLines := 0
+	fail := func(line int) error {
+		failedLines++
+		if p.opts.MaxErrors > 0 && failedLines > p.opts.MaxErrors {
+			return fmt.Errorf("line %d: %w (more than %d)", line, ErrTooManyErrors, p.opts.MaxErrors)
+		}
+		return nil
+	}
+
+	p.lines = 0
+	for scanner.Scan() {
+		if !continued {
+			p.lines++
+		}
+		currentLine := p.lines
+
+		line, sanitized := decodeLine(scanner.Bytes(), p.opts.Decoding)
+		p.state.line, p.state.lineNumber, p.state.rawLine = line, currentLine, scanner.Bytes()
+		headerEnd := lineHeaderEnd(line)
+
+		if p.opts.Resync {
+			corrupt := overlong || continued || headerEnd < 0
+			continued = overlong
+			if region == nil && corrupt {
+				region = &CorruptRegionError{Start: lineStart}
+				regionLine, regionText = currentLine, line
+				if ended, ok := p.state.evParser.(endedParser); ok {
+					if err := ended.flush(p.state); err != nil {
+						return fmt.Errorf("failed to parse event: %w", err)
+					}
+				}
+				p.state.evParser, p.state.ended = lookingForGameParser{}, nil
+			}
+			if region != nil && (corrupt || !strings.HasPrefix(line[headerEnd:], "InitGame:")) {
+				if lines := currentLine - regionLine + 1; lines > region.Lines {
+					region.Lines = lines
+					if err := fail(currentLine); err != nil {
+						return err
+					}
+				}
+				region.End = offset
+				continue
+			}
+			if region != nil {
+				p.state.warnAt(regionLine, regionText, *region)
+				region = nil
+			}
+		}
+
+		if sanitized {
+			p.state.warn(ErrInvalidUTF8)
+		}
+		if headerEnd < 0 && p.opts.Lenient {
+			p.state.warn(ErrMalformedLine)
+			if err := fail(currentLine); err != nil {
+				return err
+			}
+			continue
+		} else if headerEnd < 0 {
+			return fmt.Errorf("line %d is malformed", currentLine)
+		}
+
+		p.state.timestamp = strings.TrimSpace(line[:headerEnd])
+		event := line[headerEnd:]
+		if p.opts.Strict {
+			p.checkEvent(event)
+		}
+		if p.onEvent != nil {
+			p.onEvent(event)
+		}
+		if p.opts.EventCounts != nil {
+			if typ := eventType(event); typ != "" {
+				p.opts.EventCounts[typ]++
+			}
+		}
+		if err := p.state.parseEvent(event); err != nil {
+			return fmt.Errorf("failed to parse event: %w", err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if region != nil {
+		p.state.warnAt(regionLine, regionText, *region)
+	}
+
+	if _, ok := p.state.evParser.(*matchParser); ok {
+		return ErrUnfinishedMatch
+	}
+	if ended, ok := p.state.evParser.(endedParser); ok {
+		if err := ended.flush(p.state); err != nil {
+			return fmt.Errorf("failed to parse event: %w", err)
+		}
+	}
+
+	return nil
+}
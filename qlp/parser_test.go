@@ -0,0 +1,198 @@
+package qlp
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParserReuse(t *testing.T) {
+	expected, err := ParseLog(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+
+	parser := NewParser(Options{})
+	first, err := parser.Parse(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+	second, err := parser.Parse(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+
+	assert.Equal(t, expected, first, "matches must not be changed by later parses")
+	assert.Equal(t, expected, second)
+}
+
+func TestParserClone(t *testing.T) {
+	expected, err := ParseLog(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+
+	parser := NewParser(Options{})
+	results := make([]Matches, 4)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(parser *Parser) {
+			defer wg.Done()
+			matches, err := parser.Parse(bytes.NewReader(testLogFile))
+			assert.NoError(t, err)
+			results[i] = matches
+		}(parser.Clone())
+	}
+	wg.Wait()
+
+	for _, matches := range results {
+		assert.Equal(t, expected, matches)
+	}
+}
+
+func TestParserConcurrentUse(t *testing.T) {
+	parser := NewParser(Options{})
+	err := parser.ParseFunc(bytes.NewReader(testLogFile), func(Match) error {
+		assert.Panics(t, func() { parser.Parse(bytes.NewReader(testLogFile)) })
+		return errStopped
+	})
+	assert.ErrorIs(t, err, errStopped)
+
+	// the Parser can be used again once parsing stopped
+	_, err = parser.Parse(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+}
+
+func TestParseLogSeq(t *testing.T) {
+	expected, err := ParseLog(bytes.NewReader(testLogFile))
+	assert.NoError(t, err)
+
+	var matches Matches
+	for match, err := range ParseLogSeq(bytes.NewReader(testLogFile)) {
+		assert.NoError(t, err)
+		matches = append(matches, match)
+	}
+	assert.Equal(t, expected, matches)
+
+	// breaking out of the loop stops parsing
+	count := 0
+	for range ParseLogSeq(bytes.NewReader(testLogFile)) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, count)
+
+	var errs []error
+	for match, err := range ParseLogSeq(strings.NewReader("  0:00 InitGame:\n  0:01 " + matchSeparator + "\nnot a line\n")) {
+		if err != nil {
+			assert.Zero(t, match)
+		}
+		errs = append(errs, err)
+	}
+	assert.Len(t, errs, 2)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+}
+
+func TestParserResetAfterFailure(t *testing.T) {
+	parser := NewParser(Options{})
+	_, err := parser.Parse(strings.NewReader("  0:00 InitGame:\n  0:01 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET"))
+	assert.Error(t, err)
+
+	parser.Reset()
+	assert.IsType(t, lookingForGameParser{}, parser.state.evParser)
+
+	matches, err := parser.Parse(strings.NewReader("  0:00 InitGame:\n  0:01 " + matchSeparator))
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, 0, matches[0].TotalKills)
+	assert.Empty(t, matches[0].Players)
+}
+
+func TestParserLenient(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm17\n" +
+		"  0:01 Kill: 0 1 2: Isgalamido killed Mocinha by MOD_ROCKET\n" +
+		"\x00\x00garbage\n" +
+		"  0:02 Kill: 0 1 2: Isgalamido killed Mocinha by MOD_ROCKET\n" +
+		"  0:05 " + matchSeparator + "\n"
+
+	_, _, err := ParseLogWithOptions(strings.NewReader(log), Options{})
+	assert.Error(t, err)
+
+	matches, warnings, err := ParseLogWithOptions(strings.NewReader(log), Options{Lenient: true})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, 2, matches[0].Kills["Isgalamido"], "the rest of the match must still be parsed")
+	assert.Equal(t, []ParseWarning{{Line: 3, Text: "\x00\x00garbage", Reason: ErrMalformedLine}}, warnings)
+
+	_, _, err = ParseLogWithOptions(strings.NewReader(log+log), Options{Lenient: true, MaxErrors: 1})
+	assert.ErrorIs(t, err, ErrTooManyErrors)
+}
+
+func TestParserResync(t *testing.T) {
+	log := "  0:00 InitGame:\n" +
+		"  0:01 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"\x00\x01garbage\n" +
+		strings.Repeat("\xff", 150) + "\n" +
+		"  0:02 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  0:03 InitGame:\n" +
+		"  0:04 Kill: 0 1 2: Mocinha killed Zeh by MOD_ROCKET\n" +
+		"  0:05 " + matchSeparator + "\n"
+
+	var warnings []ParseWarning
+	parser := NewParser(Options{
+		MaxLineLength: 100,
+		Resync:        true,
+		OnWarning:     func(w ParseWarning) { warnings = append(warnings, w) },
+	})
+	matches, err := parser.Parse(strings.NewReader(log))
+	assert.NoError(t, err)
+
+	assert.Len(t, matches, 1, "the match open when the region started must be dropped")
+	assert.Equal(t, 1, matches[0].Kills["Mocinha"])
+
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, 3, warnings[0].Line)
+	start, end := int64(strings.Index(log, "\x00")), int64(strings.Index(log, "  0:03"))
+	assert.Equal(t, CorruptRegionError{Start: start, End: end, Lines: 3}, warnings[0].Reason)
+	assert.Equal(t, 8, parser.lines, "pieces of overlong lines must not be counted as lines")
+}
+
+func TestParserResyncAtEnd(t *testing.T) {
+	var warnings []ParseWarning
+	parser := NewParser(Options{
+		Resync:    true,
+		OnWarning: func(w ParseWarning) { warnings = append(warnings, w) },
+	})
+	matches, err := parser.Parse(strings.NewReader("  0:00 InitGame:\ngarbage"))
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, CorruptRegionError{Start: 17, End: 24, Lines: 1}, warnings[0].Reason)
+}
+
+func TestParserMaxErrors(t *testing.T) {
+	log := "  0:00 InitGame:\ngarbage\ngarbage\n  0:01 InitGame:\n  0:02 " + matchSeparator + "\n"
+
+	matches, err := NewParser(Options{Resync: true, MaxErrors: 2}).Parse(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	_, err = NewParser(Options{Resync: true, MaxErrors: 1}).Parse(strings.NewReader(log))
+	assert.ErrorIs(t, err, ErrTooManyErrors)
+	assert.ErrorContains(t, err, "line 3")
+}
+
+func TestParserOpenMatch(t *testing.T) {
+	parser := NewParser(Options{})
+	_, ok := parser.OpenMatch()
+	assert.False(t, ok)
+
+	_, err := parser.Parse(strings.NewReader("  0:00 InitGame:\n  0:01 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET"))
+	assert.ErrorIs(t, err, ErrUnfinishedMatch)
+
+	match, ok := parser.OpenMatch()
+	assert.True(t, ok)
+	assert.True(t, match.InProgress)
+	assert.Equal(t, 1, match.TotalKills)
+	assert.Equal(t, []string{"Mocinha", "Zeh"}, match.Players)
+}
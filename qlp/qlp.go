@@ -3,23 +3,307 @@
 package qlp
 
 import (
-	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"hash"
 	"io"
-	"regexp"
+	"iter"
+	"math"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Match represents the information for a single match.
 type Match struct {
-	TotalKills   int            `json:"total_kills"`
-	Players      []string       `json:"players"`
-	Kills        map[string]int `json:"kills"`
-	KillsByMeans map[string]int `json:"kills_by_means"`
+	// SchemaVersion is the version of the JSON representation of the match, which is
+	// SchemaVersion for matches parsed by this package; see MigrateMatch.
+	SchemaVersion int `json:"schema_version"`
+
+	// MatchHash is a content hash of the match's events, including their timestamps. The same
+	// game found in overlapping or rotated logs always yields the same hash.
+	MatchHash string `json:"match_hash"`
+	MapName   string `json:"map_name,omitempty"`
+	Server    Server `json:"server"`
+	// GameType is the game type of the match, from g_gametype, as one of the names of
+	// gameTypes or, for the game types of mods, the number itself.
+	GameType string `json:"game_type,omitempty"`
+	// FragLimit and TimeLimit are the limits the match was played with, in kills and minutes,
+	// zero meaning none.
+	FragLimit int `json:"fraglimit,omitempty"`
+	TimeLimit int `json:"timelimit,omitempty"`
+	// Settings holds every setting of the InitGame event of the match, such as "g_gametype",
+	// "fraglimit" and "sv_hostname", keyed by name.
+	Settings     map[string]string `json:"settings,omitempty"`
+	TotalKills   int               `json:"total_kills"`
+	Players      []string          `json:"players"`
+	PlayerCount  int               `json:"player_count"`
+	Kills        map[string]int    `json:"kills"`
+	KillsByMeans map[string]int    `json:"kills_by_means"`
+
+	// Frags counts the players each player killed, teammates included and suicides left out,
+	// whatever Options.Scoring makes of them in Kills.
+	Frags map[string]int `json:"frags"`
+	// Deaths counts every death of each player, including suicides and deaths by <world>.
+	Deaths map[string]int `json:"deaths"`
+	// DeathsByWorld counts the deaths of each player killed by <world>, whatever
+	// Options.WorldDeaths, unlike WorldDeaths. It is nil if there were none.
+	DeathsByWorld map[string]int `json:"deaths_by_world,omitempty"`
+
+	// ExitReason is why the match ended, as told by its Exit event, such as "Fraglimit hit."
+	// or "Timelimit hit.". It is empty for matches which ended without one.
+	ExitReason string `json:"exit_reason,omitempty"`
+	// FinalScores holds the score of each player as the game itself counted it, from the score
+	// lines logged when the match ended, which are authoritative where Kills is reconstructed
+	// from the kills. It is nil for matches without score lines.
+	FinalScores map[string]int `json:"final_scores,omitempty"`
+
+	// WorldDeaths counts the deaths of each player killed by <world>. It is only filled in
+	// when Options.WorldDeaths is WorldDeathsCount or WorldDeathsBoth.
+	WorldDeaths map[string]int `json:"world_deaths,omitempty"`
+
+	// KillsByCategory groups KillsByMeans by the category of the means, as told by
+	// MeansCategory. It is only filled in when Options.MeansCategories is set.
+	KillsByCategory map[string]int `json:"kills_by_category,omitempty"`
+
+	// Competitiveness is the score of the runner-up relative to the one of the winner, as
+	// counted in Kills: 1 for a tie at the top, close to 1 for nail-biters and close to 0 for
+	// stomps. It is 0 when fewer than two players took part or when the runner-up's score is
+	// not positive, unless tied with the winner.
+	Competitiveness float64 `json:"competitiveness"`
+
+	// SpecialDeaths counts the deaths by telefrag, crushing, lava, slime and falling, which
+	// are otherwise hard to tell apart from other deaths per player. It is nil if there were
+	// none.
+	SpecialDeaths *SpecialDeaths `json:"special_deaths,omitempty"`
+
+	// FragParticipation is, for each player, the share of the match's kills in which they
+	// took part either as the killer or as the victim.
+	FragParticipation map[string]float64 `json:"frag_participation"`
+
+	// Completeness tells which optional data the match's events carried, so consumers know
+	// how much to trust the statistics derived from them.
+	Completeness Completeness `json:"completeness"`
+
+	// StartTime is the timestamp of the InitGame event, in seconds since the server started,
+	// as logged.
+	StartTime int `json:"start_time"`
+	// Duration is the number of seconds between the InitGame event and the last event of
+	// the match.
+	Duration int `json:"duration"`
+	// StartedAt and EndedAt are when the match started and when its last event was logged,
+	// in RFC 3339, for logs whose InitGame event has the g_timestamp setting. They are in
+	// Options.TimeZone.
+	StartedAt string `json:"started_at,omitempty"`
+	EndedAt   string `json:"ended_at,omitempty"`
+	// KillFeed lists every kill of the match in order. It is only filled in when
+	// Options.KillFeed is set.
+	KillFeed []Kill `json:"kill_feed,omitempty"`
+
+	// Pings summarizes the ping of each player, from the score lines of the match, which
+	// helps settling disputes about lag. It is only filled in when Options.Pings is set, and
+	// is nil for matches without score lines.
+	Pings map[string]PingStats `json:"pings,omitempty"`
+
+	// Accuracy holds the accuracy of each player with each weapon, keyed by the weapon names
+	// of the mod, from the Weapon_Stats lines OSP and CPMA write at the end of matches. It is
+	// only filled in when Options.Accuracy is set, and is nil for logs without such lines.
+	Accuracy map[string]map[string]WeaponAccuracy `json:"accuracy,omitempty"`
+
+	// PlayerIPs maps the players to the IP address they connected from, for logs which
+	// record it. It is only filled in when Options.ClientIPs is set.
+	PlayerIPs map[string]string `json:"player_ips,omitempty"`
+
+	// Powerups lists every powerup picked up in the match in order. It is only filled in when
+	// Options.Powerups is set.
+	Powerups []Powerup `json:"powerups,omitempty"`
+
+	// Votes lists the votes called in the match in order. It is only filled in when
+	// Options.Votes is set.
+	Votes []Vote `json:"votes,omitempty"`
+
+	// AdminActions lists the kicks, bans and other admin actions taken against players in the
+	// match in order. It is only filled in when Options.AdminActions is set.
+	AdminActions []AdminAction `json:"admin_actions,omitempty"`
+
+	// Raw holds the lines of the log the match was parsed from, as read, each ended by a
+	// newline, so that the output can be checked against the log without it. It is only
+	// filled in when Options.Raw is set, and is base64 encoded in JSON.
+	Raw []byte `json:"raw,omitempty"`
+
+	// ItemPickups counts how many times each player picked up each of the items which decide
+	// duels, the Mega Health ("item_health_mega"), the Red Armor ("item_armor_body") and the
+	// Yellow Armor ("item_armor_combat"). It is only filled in when Options.ItemControl is
+	// set.
+	ItemPickups map[string]map[string]int `json:"item_pickups,omitempty"`
+
+	// Custom holds the aggregations of the events captured by Options.EventRules, by target.
+	Custom map[string]map[string]int `json:"custom,omitempty"`
+
+	// Truncated is set when some of the match's data was dropped because of
+	// Options.MaxMatchEntries.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Duplicate is set by Merge when the match was already seen in a previous source.
+	Duplicate bool `json:"duplicate,omitempty"`
+
+	// Restart is set on matches started by a map_restart of the previous match, which are
+	// kept separate from it with RestartSeparate, the default Options.Restarts.
+	Restart bool `json:"restart,omitempty"`
+	// Restarts is the number of times the match was restarted with map_restart, each restart
+	// having been merged into it with RestartMerge.
+	Restarts int `json:"restarts,omitempty"`
+
+	// InProgress is set on matches returned by Parser.OpenMatch, which had not finished when
+	// parsing stopped.
+	InProgress bool `json:"in_progress,omitempty"`
+}
+
+// gameTypes names the values of g_gametype of Quake III Arena and Team Arena.
+var gameTypes = map[string]string{
+	"0": "ffa",
+	"1": "tournament",
+	"2": "single_player",
+	"3": "tdm",
+	"4": "ctf",
+	"5": "one_flag_ctf",
+	"6": "overload",
+	"7": "harvester",
+}
+
+// gameType returns the name of the game type of the given g_gametype, or g_gametype itself if
+// it is not one of gameTypes.
+func gameType(value string) string {
+	if name, ok := gameTypes[value]; ok {
+		return name
+	}
+	return value
+}
+
+// settingInt returns the integer value of a setting, or zero if it is missing or not an
+// integer.
+func settingInt(settings map[string]string, key string) int {
+	value, _ := strconv.Atoi(settings[key])
+	return value
+}
+
+// Server identifies the server a match was played on, from the settings of its InitGame
+// event. Settings missing from the event are left empty.
+type Server struct {
+	GameName string `json:"gamename"`
+	Version  string `json:"version"`
+	Protocol string `json:"protocol"`
+	Hostname string `json:"sv_hostname"`
+}
+
+// Completeness tells which optional data was available in a match. Logs vary with the server,
+// the mod and how the match ended: a match interrupted by a crash has no Exit reason, for
+// instance.
+type Completeness struct {
+	Timestamps bool `json:"timestamps"` // events had valid timestamps
+	Scores     bool `json:"scores"`     // the final score lines were logged
+	ExitReason bool `json:"exit_reason"`
+	Userinfo   bool `json:"userinfo"` // player names were announced by ClientUserinfoChanged
+}
+
+// SpecialDeaths counts the deaths of a match by MOD_TELEFRAG, MOD_CRUSH, MOD_LAVA, MOD_SLIME
+// and MOD_FALLING.
+type SpecialDeaths struct {
+	// Total counts the deaths by means of death.
+	Total map[string]int `json:"total"`
+	// ByPlayer counts the deaths of each player, who is the victim, by means of death.
+	ByPlayer map[string]map[string]int `json:"by_player"`
+}
+
+// Kill represents a single kill event.
+type Kill struct {
+	Time   int    `json:"time"`         // seconds since the server started, as logged
+	At     string `json:"at,omitempty"` // Time in RFC 3339, for matches with Match.StartedAt
+	Killer string `json:"killer"`
+	Victim string `json:"victim"`
+	Means  string `json:"means"`
+}
+
+// PingStats summarizes the pings of a player in a match, in milliseconds.
+type PingStats struct {
+	Min     int     `json:"min"`
+	Average float64 `json:"average"`
+	Max     int     `json:"max"`
+	Samples int     `json:"samples"`
+}
+
+// WeaponAccuracy is the accuracy of a player with a weapon.
+type WeaponAccuracy struct {
+	Shots int `json:"shots"`
+	Hits  int `json:"hits"`
+	// Accuracy is the share of the shots which hit, 0 without shots.
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Powerup represents a single pickup of a powerup, such as "item_quad" for Quad Damage or
+// "item_enviro" for the Battle Suit.
+type Powerup struct {
+	Time   int    `json:"time"`         // seconds since the server started, as logged
+	At     string `json:"at,omitempty"` // Time in RFC 3339, for matches with Match.StartedAt
+	Player string `json:"player"`
+	Item   string `json:"item"`
+}
+
+// powerupItems holds the items recorded in Match.Powerups.
+var powerupItems = map[string]bool{
+	"item_quad":   true,
+	"item_enviro": true,
+	"item_haste":  true,
+	"item_invis":  true,
+	"item_regen":  true,
+	"item_flight": true,
+}
+
+// Vote represents a vote called by a player, from the callvote and vote lines of servers
+// which log them.
+type Vote struct {
+	Time   int    `json:"time"`         // seconds since the server started, as logged
+	At     string `json:"at,omitempty"` // Time in RFC 3339, for matches with Match.StartedAt
+	Caller string `json:"caller"`       // empty if the client calling the vote is unknown
+	// Vote is what the vote was for, such as "map q3dm6" or "kick Zeh".
+	Vote string `json:"vote"`
+	Yes  int    `json:"yes"`
+	No   int    `json:"no"`
+	// Result is "passed" or "failed", or "unknown" if the log does not tell.
+	Result string `json:"result"`
+}
+
+// AdminAction represents a kick, ban or other admin action taken against a player, from the
+// lines of servers and admin mods which log them.
+type AdminAction struct {
+	Time   int    `json:"time"`         // seconds since the server started, as logged
+	At     string `json:"at,omitempty"` // Time in RFC 3339, for matches with Match.StartedAt
+	Action string `json:"action"`       // one of the values of adminActions
+	Player string `json:"player"`
+	// Reason is whatever the line gives after the client number, usually the reason the
+	// admin gave.
+	Reason string `json:"reason,omitempty"`
+}
+
+// adminActions maps the event types of the admin actions logged by servers and admin mods,
+// lower-cased, to the AdminAction.Action they are reported as.
+var adminActions = map[string]string{
+	"kick":       "kick",
+	"clientkick": "kick",
+	"ban":        "ban",
+	"tempban":    "tempban",
+	"mute":       "mute",
+}
+
+// controlItems holds the items counted in Match.ItemPickups.
+var controlItems = map[string]bool{
+	"item_health_mega":  true,
+	"item_armor_body":   true,
+	"item_armor_combat": true,
 }
 
 // Matches implements a custom JSON marshaler interface in order to return the grouped
@@ -51,54 +335,99 @@ func (matches Matches) MarshalJSON() ([]byte, error) {
 	return buff.Bytes(), nil
 }
 
-// lineHeaderExpr is a regular expression to match the header of each line in the log file.
-// It handles a special case of the log format found at the example, which is
-//
-//	26  0:00 ------------------------------------------------------------
-//
-// e.g. " 0:00 InitGame: \n", it matches " 0:00 ".
-var lineHeaderExpr = regexp.MustCompile(`^\s*\d+:\d+\s|^\s*[\d\s:]+`)
-
 // ParseLog reads and parses the log from an io.Reader, returning a slice of Matches or an error.
 func ParseLog(log io.Reader) (Matches, error) {
-	scanner := bufio.NewScanner(log)
-	parser := newLogParser()
-
-	currentLine := 0
-	for scanner.Scan() {
-		currentLine++
-
-		line := scanner.Text()
-
-		indexes := lineHeaderExpr.FindStringIndex(line)
-		if indexes == nil {
-			return nil, fmt.Errorf("line %d is malformed", currentLine)
-		}
+	return NewParser(Options{}).Parse(log)
+}
 
-		event := line[indexes[1]:]
-		nextParser, err := parser.evParser.parseEvent(parser, event)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse event: %w", err)
+// ParseLogWithOptions is like ParseLog, but parses the log according to opts. Along with the
+// matches, it returns every warning found, which are also passed to opts.OnWarning.
+func ParseLogWithOptions(log io.Reader, opts Options) (Matches, []ParseWarning, error) {
+	var warnings []ParseWarning
+	onWarning := opts.OnWarning
+	opts.OnWarning = func(warning ParseWarning) {
+		warnings = append(warnings, warning)
+		if onWarning != nil {
+			onWarning(warning)
 		}
-
-		parser.evParser = nextParser
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	matches, err := NewParser(opts).Parse(log)
+	if err != nil {
+		return nil, warnings, err
 	}
 
-	if _, ok := parser.evParser.(*matchParser); ok {
-		return nil, errors.New("log entries ended while a match was still open")
-	}
+	return matches, warnings, nil
+}
+
+// ParseLogFunc reads and parses the log from an io.Reader according to opts, calling fn with
+// each match as soon as it is finished. Unlike ParseLog, matches are not retained, so memory
+// usage does not grow with the size of the log. If fn returns an error, parsing stops and the
+// error is returned.
+func ParseLogFunc(log io.Reader, opts Options, fn func(Match) error) error {
+	return NewParser(opts).ParseFunc(log, fn)
+}
 
-	return parser.matches, nil
+// ParseLogSeq returns an iterator over the matches of the log read from an io.Reader, for use
+// in range loops. Matches are parsed one at a time as the loop asks for them, so, as with
+// ParseLogFunc, memory usage does not grow with the size of the log. If parsing fails, the
+// error is yielded with a zero Match as the last element:
+//
+//	for match, err := range qlp.ParseLogSeq(log) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(match.MapName, match.TotalKills)
+//	}
+func ParseLogSeq(log io.Reader) iter.Seq2[Match, error] {
+	return NewParser(Options{}).ParseSeq(log)
 }
 
 // logParser is an internal type that holds the state of the parsing process.
 type logParser struct {
 	evParser eventParser
 	matches  Matches
+	opts     Options
+
+	// line and lineNumber hold the line currently being parsed.
+	line       string
+	lineNumber int
+	// rawLine holds the line currently being parsed as read, before decoding. It is only
+	// valid until the next line is read.
+	rawLine []byte
+	// timestamp holds the header of the line currently being parsed, e.g. "20:37".
+	timestamp string
+	// onMatch, when set, receives finished matches instead of them being appended to matches.
+	onMatch func(Match) error
+	// match is the matchParser of the latest match, which is reused by the next one.
+	match *matchParser
+	// ended is set while the latest match, which ended without an Exit event, may still be
+	// restarted by the next event; see RestartMode.
+	ended *endedMatch
+}
+
+// warn reports a warning about the line currently being parsed to the OnWarning callback,
+// if any.
+func (p *logParser) warn(reason error) {
+	p.warnAt(p.lineNumber, p.line, reason)
+}
+
+// warnAt reports a warning about the given line to the OnWarning callback, if any.
+func (p *logParser) warnAt(lineNumber int, line string, reason error) {
+	if p.opts.OnWarning != nil {
+		p.opts.OnWarning(ParseWarning{Line: lineNumber, Text: line, Reason: reason})
+	}
+}
+
+// emitMatch hands a finished match over to onMatch, or stores it if there is no callback.
+func (p *logParser) emitMatch(match Match) error {
+	match.anchorTimes(p.opts.TimeZone)
+	if p.onMatch != nil {
+		return p.onMatch(match)
+	}
+
+	p.matches = append(p.matches, match)
+	return nil
 }
 
 // newLogParser creates and returns a new instance of logParser.
@@ -106,6 +435,17 @@ func newLogParser() *logParser {
 	return &logParser{evParser: lookingForGameParser{}}
 }
 
+// startMatch returns a matchParser ready for a new match. The matchParser of the previous
+// match is reused, so that its allocations are not thrown away.
+func (p *logParser) startMatch() *matchParser {
+	if p.match == nil {
+		p.match = newMatchParser()
+	} else {
+		p.match.reset()
+	}
+	return p.match
+}
+
 // parseEvent processes an event string with the current event parser, updating the parser's
 // state accordingly.
 func (p *logParser) parseEvent(event string) error {
@@ -131,11 +471,33 @@ type lookingForGameParser struct{}
 // parseEvent checks if the event is the "InitGame" event. If it is, it returns a new
 // matchParser, otherwise it returns itself.
 func (lfg lookingForGameParser) parseEvent(p *logParser, event string) (eventParser, error) {
+	if strings.HasPrefix(event, "Kill:") {
+		p.warn(ErrKillOutsideMatch)
+	}
+	if strings.HasPrefix(event, "---") {
+		return lfg, nil
+	}
+	ended := p.ended
+	p.ended = nil
 	if !strings.HasPrefix(event, "InitGame:") {
 		return lfg, nil
 	}
 
-	matchParser := newMatchParser()
+	matchParser := p.startMatch()
+	matchParser.hashEvent(p, event)
+	matchParser.recordLine(p)
+	info := parseInfoString(strings.TrimPrefix(event, "InitGame:"))
+	matchParser.mapName = info["mapname"]
+	matchParser.settings = info
+	matchParser.server = Server{
+		GameName: info["gamename"],
+		Version:  info["version"],
+		Protocol: info["protocol"],
+		Hostname: info["sv_hostname"],
+	}
+	matchParser.startTime, matchParser.completeness.Timestamps = parseTimestamp(p.timestamp)
+	matchParser.lastTime = matchParser.startTime
+	matchParser.restart = ended != nil && ended.restartedBy(p, event)
 	return matchParser, nil
 }
 
@@ -143,10 +505,68 @@ func (lfg lookingForGameParser) parseEvent(p *logParser, event string) (eventPar
 // the expected data, and when the "ShutdownGame" event is found, it creates a Match object
 // and appends it to the list of matches. After that, it returns to the lookingForGameParser.
 type matchParser struct {
+	mapName string
+	server  Server
+	// settings is not reused by the next match, as it is handed over to Match.Settings.
+	settings     map[string]string
 	totalKills   int
 	players      map[string]struct{}
 	kills        map[string]int
+	frags        map[string]int
+	deaths       map[string]int
+	involvement  map[string]int // kills each player took part in, as the killer or the victim
 	killsByMeans map[string]int
+	hash         hash.Hash
+	hashBuffer   []byte
+	truncated    bool
+	// startTime and lastTime are the timestamps of the InitGame event and of the latest
+	// event of the match.
+	startTime time.Duration
+	lastTime  time.Duration
+	killFeed  []Kill
+	// completeness is updated as events are seen.
+	completeness Completeness
+	// custom holds the aggregations of Options.EventRules. It is only allocated when used.
+	custom map[string]map[string]int
+	// killsByCategory is only allocated with Options.MeansCategories.
+	killsByCategory map[string]int
+	// worldDeaths and deathsByWorld are only allocated once such a death is counted.
+	worldDeaths   map[string]int
+	deathsByWorld map[string]int
+	// specialDeaths is only allocated once such a death happens.
+	specialDeaths *SpecialDeaths
+	// clientNames and clientIPs map the numbers of the connected clients to player names, as
+	// announced by ClientUserinfoChanged, and to IP addresses, which are only kept with
+	// Options.ClientIPs.
+	clientNames map[string]string
+	clientIPs   map[string]string
+	// clientTeams maps the numbers of the connected clients which are on the red or the blue
+	// team to it, "1" or "2", from the t key of ClientUserinfoChanged.
+	clientTeams map[string]string
+	// aliases maps the clients which took the name of another connected client to the name
+	// telling them apart, such as "Zeh (2)". It is only allocated once that happens.
+	aliases   map[string]string
+	playerIPs map[string]string
+	powerups  []Powerup
+	votes     []Vote
+	// openVote is the index in votes of the vote being voted on, or -1.
+	openVote     int
+	adminActions []AdminAction
+	// itemPickups is only allocated once an item of controlItems is picked up.
+	itemPickups map[string]map[string]int
+	exitReason  string
+	// finalScores is only allocated once a score line is found.
+	finalScores map[string]int
+	// pings holds the sum of the pings of each player in Average until the match is built.
+	pings map[string]PingStats
+	// accuracy is only allocated once a Weapon_Stats line is found.
+	accuracy map[string]map[string]WeaponAccuracy
+	// restart is set when the match restarts the previous one, and restarts counts the
+	// restarts merged into the match; see RestartMode.
+	restart  bool
+	restarts int
+	// raw is not reused by the next match, as it is handed over to Match.Raw.
+	raw []byte
 }
 
 // newMatchParser creates and returns a new instance of matchParser.
@@ -154,48 +574,456 @@ func newMatchParser() *matchParser {
 	return &matchParser{
 		players:      make(map[string]struct{}),
 		kills:        make(map[string]int),
+		frags:        make(map[string]int),
+		deaths:       make(map[string]int),
+		involvement:  make(map[string]int),
 		killsByMeans: make(map[string]int),
+		clientNames:  make(map[string]string),
+		clientIPs:    make(map[string]string),
+		clientTeams:  make(map[string]string),
+		openVote:     -1,
+		hash:         sha256.New(),
 	}
 }
 
-// killExpr matches the Kill events. It captures constante elements such as "Kill",
-// "killed" and "by" in non capturing groups. The capturing groups output the killer,
-// the victim and the means of death.
-var killExpr = regexp.MustCompile(`(?:Kill:\s\d+\s\d+\s\d+:\s)(.+)(?:\skilled\s)(.+)(?:\sby\s)([\w]+)`)
+// reset prepares the matchParser for a new match. Maps that are handed over to a Match are
+// replaced rather than cleared, since the Match still references them.
+func (m *matchParser) reset() {
+	m.mapName = ""
+	m.server = Server{}
+	m.settings = nil
+	m.totalKills = 0
+	clear(m.players)
+	m.kills = make(map[string]int)
+	m.frags = make(map[string]int)
+	m.deaths = make(map[string]int)
+	clear(m.involvement)
+	m.killsByMeans = make(map[string]int)
+	m.hash.Reset()
+	m.truncated = false
+	m.startTime, m.lastTime = 0, 0
+	m.killFeed = nil
+	m.completeness = Completeness{}
+	m.custom = nil
+	m.killsByCategory = nil
+	m.worldDeaths, m.deathsByWorld = nil, nil
+	m.specialDeaths = nil
+	clear(m.clientNames)
+	clear(m.clientIPs)
+	clear(m.clientTeams)
+	m.aliases = nil
+	m.playerIPs = nil
+	m.powerups = nil
+	m.itemPickups = nil
+	m.votes, m.openVote = nil, -1
+	m.adminActions = nil
+	m.exitReason, m.finalScores = "", nil
+	m.pings = nil
+	m.accuracy = nil
+	m.restart, m.restarts = false, 0
+	m.raw = nil
+}
+
+// hashEvent feeds an event, along with its timestamp, into the match's content hash.
+func (m *matchParser) hashEvent(p *logParser, event string) {
+	// the line is assembled in a reused buffer, as converting the strings would allocate
+	m.hashBuffer = append(m.hashBuffer[:0], p.timestamp...)
+	m.hashBuffer = append(m.hashBuffer, ' ')
+	m.hashBuffer = append(m.hashBuffer, event...)
+	m.hashBuffer = append(m.hashBuffer, '\n')
+	m.hash.Write(m.hashBuffer)
+}
+
+// recordLine appends the line currently being parsed, as read, to the raw text of the match if
+// Options.Raw is set.
+func (m *matchParser) recordLine(p *logParser) {
+	if !p.opts.Raw {
+		return
+	}
+	m.raw = append(m.raw, p.rawLine...)
+	m.raw = append(m.raw, '\n')
+}
 
 func (m *matchParser) parseEvent(p *logParser, event string) (eventParser, error) {
 	// this is used instead of ShutdownGame to match the issue at the example log at line
 	// 97
 	if strings.HasPrefix(event, "---") {
-		finishedMatch := Match{
-			TotalKills:   m.totalKills,
-			Players:      m.getPlayerList(),
-			Kills:        m.kills,
-			KillsByMeans: m.killsByMeans,
+		m.recordLine(p)
+		if !m.completeness.ExitReason {
+			// the match may be restarted, which only the next event tells
+			ended := endedMatch{timestamp: p.timestamp, mapName: m.mapName}
+			if p.opts.Restarts == RestartMerge {
+				return endedParser{endedMatch: ended, match: m}, nil
+			}
+			p.ended = &ended
+		}
+		if err := p.emitMatch(m.match()); err != nil {
+			return nil, err
 		}
-		p.matches = append(p.matches, finishedMatch)
 		return lookingForGameParser{}, nil
 	}
 
-	matchingGroups := killExpr.FindStringSubmatch(event)
-	if len(matchingGroups) == 0 {
+	m.hashEvent(p, event)
+	m.recordLine(p)
+	if strings.HasPrefix(event, "InitGame:") {
+		p.warn(ErrMatchInterrupted)
+	}
+	if timestamp, ok := parseTimestamp(p.timestamp); ok {
+		if timestamp < m.lastTime {
+			p.warn(ErrTimestampBackwards)
+		}
+		m.lastTime = timestamp
+		m.completeness.Timestamps = true
+	}
+	switch {
+	case strings.HasPrefix(event, "score:"):
+		m.completeness.Scores = true
+		m.registerScore(p.opts, event)
+		if p.opts.Pings {
+			m.registerPing(p.opts, event)
+		}
+	case strings.HasPrefix(event, "Weapon_Stats:"):
+		if p.opts.Accuracy {
+			m.registerAccuracy(p.opts, event)
+		}
+	case strings.HasPrefix(event, "Exit:"):
+		m.completeness.ExitReason = true
+		m.exitReason = strings.TrimSpace(strings.TrimPrefix(event, "Exit:"))
+	case strings.HasPrefix(event, "ClientUserinfoChanged:"):
+		m.completeness.Userinfo = true
+	}
+	if p.opts.Votes {
+		m.trackVote(p.opts, event)
+	}
+	if p.opts.AdminActions {
+		m.trackAdminAction(p.opts, event)
+	}
+	if len(p.opts.EventRules) > 0 {
+		m.applyRules(p, event)
+	}
+	m.trackClients(p, event)
+
+	if !strings.HasPrefix(event, "Kill:") {
 		return m, nil
 	}
+	killer, killed, killedBy, ok := parseKill(event)
+	if !ok {
+		p.warn(ErrMalformedKill)
+		return m, nil
+	}
+	if p.opts.isWorld(m.server.GameName, killer) {
+		killer = worldEntity
+	} else {
+		killer = p.opts.NameRules.apply(killer)
+	}
+	killed = p.opts.NameRules.apply(killed)
+	teamkill := false
+	if len(m.aliases) > 0 || len(m.clientTeams) > 0 {
+		if killerClient, killedClient, ok := parseKillClients(event); ok {
+			killer, killed = m.alias(killerClient, killer), m.alias(killedClient, killed)
+			teamkill = m.teammates(killerClient, killedClient)
+		}
+	}
 
-	killer, killed, killedBy := matchingGroups[1], matchingGroups[2], matchingGroups[3]
-	m.registerKill(killer, killed, killedBy)
+	m.registerKill(p.opts, killer, killed, killedBy, teamkill)
 
 	return m, nil
 }
 
+// match returns the Match holding the data gathered so far.
+func (m *matchParser) match() Match {
+	players := m.getPlayerList()
+	return Match{
+		MatchHash:         hex.EncodeToString(m.hash.Sum(nil)),
+		SchemaVersion:     SchemaVersion,
+		MapName:           m.mapName,
+		Server:            m.server,
+		GameType:          gameType(m.settings["g_gametype"]),
+		FragLimit:         settingInt(m.settings, "fraglimit"),
+		TimeLimit:         settingInt(m.settings, "timelimit"),
+		Settings:          m.settings,
+		TotalKills:        m.totalKills,
+		Players:           players,
+		PlayerCount:       len(players),
+		Kills:             m.kills,
+		Frags:             m.frags,
+		Deaths:            m.deaths,
+		DeathsByWorld:     m.deathsByWorld,
+		FragParticipation: m.fragParticipation(),
+		Competitiveness:   competitiveness(players, m.kills),
+		Completeness:      m.completeness,
+		ExitReason:        m.exitReason,
+		FinalScores:       m.finalScores,
+		KillsByMeans:      m.killsByMeans,
+		WorldDeaths:       m.worldDeaths,
+		KillsByCategory:   m.killsByCategory,
+		SpecialDeaths:     m.specialDeaths,
+		StartTime:         int(m.startTime / time.Second),
+		Duration:          int((m.lastTime - m.startTime) / time.Second),
+		KillFeed:          m.killFeed,
+		Pings:             m.pingStats(),
+		Accuracy:          m.accuracy,
+		PlayerIPs:         m.playerIPs,
+		Powerups:          m.powerups,
+		ItemPickups:       m.itemPickups,
+		Votes:             m.votes,
+		AdminActions:      m.adminActions,
+		Custom:            m.custom,
+		Truncated:         m.truncated,
+		Restart:           m.restart,
+		Restarts:          m.restarts,
+		Raw:               m.raw,
+	}
+}
+
+// trackClients keeps track of the names and addresses of the clients, recording the powerups
+// they pick up and the addresses of the players as enabled by the options.
+func (m *matchParser) trackClients(p *logParser, event string) {
+	opts := p.opts
+	if client, userinfo, ok := parseClientEvent(event, "ClientUserinfoChanged:"); ok {
+		if name, ok := infoValue(userinfo, "n"); ok {
+			m.nameClient(p, client, name)
+			m.linkIP(opts, client)
+		}
+		if team, _ := infoValue(userinfo, "t"); team == "1" || team == "2" {
+			m.clientTeams[client] = team
+		} else {
+			delete(m.clientTeams, client)
+		}
+		return
+	}
+	if client, _, ok := parseClientEvent(event, "ClientDisconnect:"); ok {
+		delete(m.clientNames, client)
+		delete(m.clientIPs, client)
+		delete(m.clientTeams, client)
+		delete(m.aliases, client)
+		return
+	}
+	if opts.ClientIPs {
+		for _, prefix := range [...]string{"ClientConnect:", "ClientUserinfo:"} {
+			if client, rest, ok := parseClientEvent(event, prefix); ok {
+				if ip := findIP(rest); ip != "" {
+					m.clientIPs[client] = ip
+					m.linkIP(opts, client)
+				}
+				return
+			}
+		}
+	}
+	if opts.Powerups {
+		m.trackPowerup(opts, event)
+	}
+	if opts.ItemControl {
+		m.trackItemPickup(opts, event)
+	}
+}
+
+// nameClient records the name a client announced. Should another connected client already go
+// by that name, the client is given an alias suffixed with the lowest free number, as in
+// "Zeh (2)", so that the kills of both players are not merged.
+func (m *matchParser) nameClient(p *logParser, client, name string) {
+	name = p.opts.NameRules.apply(name)
+	if previous, ok := m.clientNames[client]; ok && previous == name {
+		return
+	}
+	m.clientNames[client] = name
+	delete(m.aliases, client)
+	if !m.nameTaken(client, name) {
+		return
+	}
+
+	alias := name
+	for n := 2; m.nameTaken(client, alias); n++ {
+		alias = fmt.Sprintf("%s (%d)", name, n)
+	}
+	if m.aliases == nil {
+		m.aliases = make(map[string]string)
+	}
+	m.aliases[client] = alias
+	p.warn(ErrDuplicatePlayer)
+}
+
+// nameTaken reports whether a connected client other than client goes by name.
+func (m *matchParser) nameTaken(client, name string) bool {
+	for other := range m.clientNames {
+		if other != client && m.playerName(other) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// playerName returns the name a connected client goes by in the match.
+func (m *matchParser) playerName(client string) string {
+	if alias, ok := m.aliases[client]; ok {
+		return alias
+	}
+	return m.clientNames[client]
+}
+
+// alias returns the name which the player named name in an event about client goes by in the
+// match, which differs from name for the clients given an alias by nameClient.
+func (m *matchParser) alias(client, name string) string {
+	if alias, ok := m.aliases[client]; ok && m.clientNames[client] == name {
+		return alias
+	}
+	return name
+}
+
+// teammates reports whether two different clients are on the same team.
+func (m *matchParser) teammates(client, other string) bool {
+	team, ok := m.clientTeams[client]
+	return ok && client != other && m.clientTeams[other] == team
+}
+
+// linkIP records the address of the player using a client, once both are known.
+func (m *matchParser) linkIP(opts Options, client string) {
+	name, ip := m.playerName(client), m.clientIPs[client]
+	if name == "" || ip == "" {
+		return
+	}
+	if m.playerIPs == nil {
+		m.playerIPs = make(map[string]string)
+	}
+	if _, ok := m.playerIPs[name]; !ok && !opts.roomFor(len(m.playerIPs)) {
+		m.truncated = true
+		return
+	}
+	m.playerIPs[name] = ip
+}
+
+// trackPowerup records the powerup picked up by an Item event, if any.
+func (m *matchParser) trackPowerup(opts Options, event string) {
+	client, item, ok := parseClientEvent(event, "Item:")
+	if !ok || !powerupItems[item] {
+		return
+	}
+	if _, ok := m.clientNames[client]; !ok {
+		return
+	}
+	name := m.playerName(client)
+	if !opts.roomFor(len(m.powerups)) {
+		m.truncated = true
+		return
+	}
+	m.powerups = append(m.powerups, Powerup{Time: int(m.lastTime / time.Second), Player: name, Item: item})
+}
+
+// trackItemPickup counts the item picked up by an Item event, if it is one of controlItems.
+func (m *matchParser) trackItemPickup(opts Options, event string) {
+	client, item, ok := parseClientEvent(event, "Item:")
+	if !ok || !controlItems[item] {
+		return
+	}
+	if _, ok := m.clientNames[client]; !ok {
+		return
+	}
+	player := m.playerName(client)
+
+	if m.itemPickups == nil {
+		m.itemPickups = make(map[string]map[string]int)
+	}
+	if _, ok := m.itemPickups[player]; !ok {
+		if !opts.roomFor(len(m.itemPickups)) {
+			m.truncated = true
+			return
+		}
+		m.itemPickups[player] = make(map[string]int)
+	}
+	m.itemPickups[player][item]++
+}
+
+// trackVote records the vote called by a callvote event, the ballots of the vote events and
+// the result of the vote, logged as "Vote passed" or "Vote failed". Event types are matched
+// regardless of case, as mods spell them differently.
+func (m *matchParser) trackVote(opts Options, event string) {
+	if rest, ok := cutPrefixFold(event, "callvote:"); ok {
+		client, vote, ok := parseClientEvent(rest, "")
+		if !ok {
+			return
+		}
+		if !opts.roomFor(len(m.votes)) {
+			m.truncated = true
+			m.openVote = -1
+			return
+		}
+		caller := ""
+		if _, ok := m.clientNames[client]; ok {
+			caller = m.playerName(client)
+		}
+		vote = strings.Trim(strings.TrimSpace(vote), `"`)
+		m.votes = append(m.votes, Vote{Time: int(m.lastTime / time.Second), Caller: caller, Vote: vote, Result: "unknown"})
+		m.openVote = len(m.votes) - 1
+		return
+	}
+	if m.openVote < 0 {
+		return
+	}
+
+	vote := &m.votes[m.openVote]
+	if rest, ok := cutPrefixFold(event, "vote:"); ok {
+		if _, ballot, ok := parseClientEvent(rest, ""); ok {
+			switch strings.ToLower(strings.TrimSpace(ballot)) {
+			case "yes", "y", "1":
+				vote.Yes++
+			case "no", "n", "0":
+				vote.No++
+			}
+		}
+		return
+	}
+	if _, ok := cutPrefixFold(event, "vote passed"); ok {
+		vote.Result = "passed"
+		m.openVote = -1
+	} else if _, ok := cutPrefixFold(event, "vote failed"); ok {
+		vote.Result = "failed"
+		m.openVote = -1
+	}
+}
+
+// trackAdminAction records the admin action of an event such as "Kick: 3 cheating", whose type
+// is one of adminActions regardless of case. Actions against clients not known to be in the
+// match are ignored, as there is no name to report them under.
+func (m *matchParser) trackAdminAction(opts Options, event string) {
+	typ := eventType(event)
+	action, ok := adminActions[strings.ToLower(typ)]
+	if !ok {
+		return
+	}
+	client, reason, ok := parseClientEvent(event, typ+":")
+	if !ok {
+		return
+	}
+	if _, ok := m.clientNames[client]; !ok {
+		return
+	}
+	if !opts.roomFor(len(m.adminActions)) {
+		m.truncated = true
+		return
+	}
+	m.adminActions = append(m.adminActions, AdminAction{
+		Time:   int(m.lastTime / time.Second),
+		Action: action,
+		Player: m.playerName(client),
+		Reason: reason,
+	})
+}
+
 // registerKill registers a kill event in the matchParser's state. It increments the total
-// kills, updates the kills count for the killer and the killed player, and increments the
-// count for the means of death.
-func (m *matchParser) registerKill(killer, killed, killedBy string) {
+// kills, updates the scores of the killer and the killed player as opts.Scoring says, and
+// increments the count for the means of death. teamkill tells whether both players are on the
+// same team. Players and means of death which do not fit within opts.MaxMatchEntries are left
+// out.
+func (m *matchParser) registerKill(opts Options, killer, killed, killedBy string, teamkill bool) {
 	m.totalKills++
 
 	for _, player := range [...]string{killer, killed} {
-		if player == "<world>" {
+		if player == worldEntity {
+			continue
+		}
+
+		if _, ok := m.players[player]; !ok && !opts.roomFor(len(m.players)) {
+			m.truncated = true
 			continue
 		}
 
@@ -203,17 +1031,221 @@ func (m *matchParser) registerKill(killer, killed, killedBy string) {
 		// in the match info
 		if _, ok := m.kills[player]; !ok {
 			m.kills[player] = 0
+			m.frags[player] = 0
+			m.deaths[player] = 0
 		}
 		m.players[player] = struct{}{}
 	}
 
-	if killer == "<world>" {
-		m.kills[killed]--
-	} else if killer != killed {
-		m.kills[killer]++
+	if opts.KillFeed {
+		if opts.roomFor(len(m.killFeed)) {
+			kill := Kill{Time: int(m.lastTime / time.Second), Killer: killer, Victim: killed, Means: killedBy}
+			m.killFeed = append(m.killFeed, kill)
+		} else {
+			m.truncated = true
+		}
+	}
+
+	if _, ok := m.players[killed]; ok {
+		m.involvement[killed]++
+		m.deaths[killed]++
+	}
+	if killer == worldEntity {
+		if _, ok := m.players[killed]; ok {
+			m.registerWorldDeath(opts, killed)
+		}
+	} else if _, ok := m.players[killer]; ok && killer == killed {
+		m.kills[killer] += opts.scoring().Suicide
+	} else if ok && teamkill {
+		m.kills[killer] += opts.scoring().Teamkill
+		m.frags[killer]++
+		m.involvement[killer]++
+	} else if ok {
+		m.kills[killer] += opts.scoring().Kill
+		m.frags[killer]++
+		m.involvement[killer]++
+	}
+
+	if _, ok := m.killsByMeans[killedBy]; ok || opts.roomFor(len(m.killsByMeans)) {
+		m.killsByMeans[killedBy]++
+	} else {
+		m.truncated = true
+	}
+
+	if specialMeans[killedBy] {
+		m.registerSpecialDeath(killed, killedBy)
 	}
 
-	m.killsByMeans[killedBy]++
+	if opts.MeansCategories {
+		if m.killsByCategory == nil {
+			m.killsByCategory = make(map[string]int)
+		}
+		m.killsByCategory[MeansCategory(killedBy)]++
+	}
+}
+
+// registerWorldDeath counts a death of killed by <world> as selected by opts.WorldDeaths.
+func (m *matchParser) registerWorldDeath(opts Options, killed string) {
+	if m.deathsByWorld == nil {
+		m.deathsByWorld = make(map[string]int)
+	}
+	m.deathsByWorld[killed]++
+	if opts.WorldDeaths != WorldDeathsCount {
+		m.kills[killed] += opts.scoring().WorldDeath
+	}
+	if opts.WorldDeaths != WorldDeathsDecrement {
+		if m.worldDeaths == nil {
+			m.worldDeaths = make(map[string]int)
+		}
+		m.worldDeaths[killed]++
+	}
+}
+
+// registerSpecialDeath counts a death of killed by one of the specialMeans. Players left out
+// of the match because of Options.MaxMatchEntries only count towards the total.
+func (m *matchParser) registerSpecialDeath(killed, killedBy string) {
+	if m.specialDeaths == nil {
+		m.specialDeaths = &SpecialDeaths{Total: make(map[string]int), ByPlayer: make(map[string]map[string]int)}
+	}
+	m.specialDeaths.Total[killedBy]++
+
+	if _, ok := m.players[killed]; !ok {
+		return
+	}
+	if m.specialDeaths.ByPlayer[killed] == nil {
+		m.specialDeaths.ByPlayer[killed] = make(map[string]int)
+	}
+	m.specialDeaths.ByPlayer[killed][killedBy]++
+}
+
+// registerScore records the score of the player of a score event, replacing what earlier
+// events of the player recorded.
+func (m *matchParser) registerScore(opts Options, event string) {
+	score, _, client, player, ok := parseScore(event)
+	if !ok {
+		return
+	}
+	player = m.alias(client, opts.NameRules.apply(player))
+	if m.finalScores == nil {
+		m.finalScores = make(map[string]int)
+	}
+	if _, ok := m.finalScores[player]; !ok && !opts.roomFor(len(m.finalScores)) {
+		m.truncated = true
+		return
+	}
+	m.finalScores[player] = score
+}
+
+// registerPing adds the ping of a score event to the samples of its player.
+func (m *matchParser) registerPing(opts Options, event string) {
+	_, ping, client, player, ok := parseScore(event)
+	if !ok {
+		return
+	}
+	player = m.alias(client, opts.NameRules.apply(player))
+	if m.pings == nil {
+		m.pings = make(map[string]PingStats)
+	}
+
+	stats, ok := m.pings[player]
+	switch {
+	case !ok && !opts.roomFor(len(m.pings)):
+		m.truncated = true
+		return
+	case !ok:
+		stats = PingStats{Min: ping, Max: ping}
+	}
+	stats.Min, stats.Max = min(stats.Min, ping), max(stats.Max, ping)
+	stats.Average += float64(ping)
+	stats.Samples++
+	m.pings[player] = stats
+}
+
+// registerAccuracy records the accuracy of the player of a Weapon_Stats event with each of
+// the weapons it lists, replacing what earlier events of the player recorded.
+func (m *matchParser) registerAccuracy(opts Options, event string) {
+	client, weapons, ok := parseClientEvent(event, "Weapon_Stats:")
+	if !ok {
+		return
+	}
+	if _, ok := m.clientNames[client]; !ok {
+		return
+	}
+	player := m.playerName(client)
+
+	if m.accuracy == nil {
+		m.accuracy = make(map[string]map[string]WeaponAccuracy)
+	}
+	if _, ok := m.accuracy[player]; !ok {
+		if !opts.roomFor(len(m.accuracy)) {
+			m.truncated = true
+			return
+		}
+		m.accuracy[player] = make(map[string]WeaponAccuracy)
+	}
+	for _, field := range strings.Fields(weapons) {
+		weapon, shots, hits, ok := parseWeaponStat(field)
+		if !ok {
+			continue
+		}
+		accuracy := WeaponAccuracy{Shots: shots, Hits: hits}
+		if shots > 0 {
+			accuracy.Accuracy = float64(hits) / float64(shots)
+		}
+		m.accuracy[player][weapon] = accuracy
+	}
+}
+
+// pingStats returns the ping statistics of the players, turning the sums of their pings into
+// averages.
+func (m *matchParser) pingStats() map[string]PingStats {
+	if m.pings == nil {
+		return nil
+	}
+	pings := make(map[string]PingStats, len(m.pings))
+	for player, stats := range m.pings {
+		stats.Average /= float64(stats.Samples)
+		pings[player] = stats
+	}
+	return pings
+}
+
+// competitiveness returns the score of the runner-up relative to the one of the winner among
+// players, whose scores are counted in kills.
+func competitiveness(players []string, kills map[string]int) float64 {
+	if len(players) < 2 {
+		return 0
+	}
+
+	first, second := math.MinInt, math.MinInt
+	for _, player := range players {
+		score := kills[player]
+		if score > first {
+			first, second = score, first
+		} else if score > second {
+			second = score
+		}
+	}
+	switch {
+	case first == second:
+		return 1
+	case second <= 0:
+		return 0
+	}
+	return float64(second) / float64(first)
+}
+
+// fragParticipation returns the share of the match's kills each player took part in.
+func (m *matchParser) fragParticipation() map[string]float64 {
+	participation := make(map[string]float64, len(m.players))
+	for player := range m.players {
+		if m.totalKills > 0 {
+			participation[player] = float64(m.involvement[player]) / float64(m.totalKills)
+		} else {
+			participation[player] = 0
+		}
+	}
+	return participation
 }
 
 // getPlayerList returns a slice with the names of the players in the match, sorted alphabetically.
@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -54,6 +55,28 @@ func TestKillEventWithWorldKiller(t *testing.T) {
 	assert.Contains(t, match.Players, "Mocinha")
 	assert.Equal(t, 1, match.TotalKills)
 	assert.Equal(t, 1, match.KillsByMeans["MOD_ROCKET"])
+	assert.Nil(t, match.WorldDeaths)
+}
+
+func TestWorldDeaths(t *testing.T) {
+	for mode, expected := range map[WorldDeathMode]struct {
+		kills       map[string]int
+		worldDeaths map[string]int
+	}{
+		WorldDeathsDecrement: {map[string]int{"Mocinha": -2, "Zeh": 1}, nil},
+		WorldDeathsCount:     {map[string]int{"Mocinha": 0, "Zeh": 1}, map[string]int{"Mocinha": 2}},
+		WorldDeathsBoth:      {map[string]int{"Mocinha": -2, "Zeh": 1}, map[string]int{"Mocinha": 2}},
+	} {
+		p := logParser{evParser: lookingForGameParser{}, opts: Options{WorldDeaths: mode}}
+		p.parseEvent("InitGame:")
+		p.parseEvent("Kill: 1022 3 22: <world> killed Mocinha by MOD_TRIGGER_HURT")
+		p.parseEvent("Kill: 2 3 6: Zeh killed Mocinha by MOD_ROCKET")
+		p.parseEvent("Kill: 1022 3 19: <world> killed Mocinha by MOD_FALLING")
+		p.parseEvent(matchSeparator)
+
+		assert.Equal(t, expected.kills, p.matches[0].Kills, mode)
+		assert.Equal(t, expected.worldDeaths, p.matches[0].WorldDeaths, mode)
+	}
 }
 
 func TestMultipleMatches(t *testing.T) {
@@ -88,6 +111,300 @@ func TestKillByMeansCounting(t *testing.T) {
 	assert.Equal(t, 2, match.KillsByMeans["MOD_TRIGGER_HURT"])
 }
 
+func TestKillByCategoryCounting(t *testing.T) {
+	p := logParser{evParser: lookingForGameParser{}, opts: Options{MeansCategories: true}}
+	p.parseEvent("InitGame:")
+	p.parseEvent("Kill: 0 1 2: Isgalamido killed Mocinha by MOD_ROCKET")
+	p.parseEvent("Kill: 0 1 2: Isgalamido killed Mocinha by MOD_RAILGUN_SPLASH")
+	p.parseEvent("Kill: 0 1 2: Isgalamido killed Mocinha by MOD_GRENADE_SPLASH")
+	p.parseEvent("Kill: 0 1 2: <world> killed Mocinha by MOD_TRIGGER_HURT")
+	p.parseEvent("Kill: 0 1 2: Mocinha killed Isgalamido by MOD_GAUNTLET")
+	p.parseEvent(matchSeparator)
+	p.parseEvent("InitGame:")
+	p.parseEvent(matchSeparator)
+
+	assert.Equal(t, map[string]int{
+		CategoryExplosive:     2,
+		CategoryOther:         1,
+		CategoryEnvironmental: 1,
+		CategoryMelee:         1,
+	}, p.matches[0].KillsByCategory)
+	assert.Nil(t, p.matches[1].KillsByCategory)
+}
+
+func TestSpecialDeaths(t *testing.T) {
+	p := logParser{evParser: lookingForGameParser{}}
+	p.parseEvent("InitGame:")
+	p.parseEvent("Kill: 0 1 18: Isgalamido killed Mocinha by MOD_TELEFRAG")
+	p.parseEvent("Kill: 1022 1 19: <world> killed Mocinha by MOD_FALLING")
+	p.parseEvent("Kill: 1022 0 16: <world> killed Isgalamido by MOD_LAVA")
+	p.parseEvent("Kill: 0 1 6: Isgalamido killed Mocinha by MOD_ROCKET")
+	p.parseEvent(matchSeparator)
+	p.parseEvent("InitGame:")
+	p.parseEvent("Kill: 0 1 6: Isgalamido killed Mocinha by MOD_ROCKET")
+	p.parseEvent(matchSeparator)
+
+	assert.Equal(t, &SpecialDeaths{
+		Total: map[string]int{"MOD_TELEFRAG": 1, "MOD_FALLING": 1, "MOD_LAVA": 1},
+		ByPlayer: map[string]map[string]int{
+			"Mocinha":    {"MOD_TELEFRAG": 1, "MOD_FALLING": 1},
+			"Isgalamido": {"MOD_LAVA": 1},
+		},
+	}, p.matches[0].SpecialDeaths)
+	assert.Nil(t, p.matches[1].SpecialDeaths)
+}
+
+func TestPowerups(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm6\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\n" +
+		"  0:02 Item: 2 item_quad\n" +
+		"  0:03 Item: 2 weapon_rocketlauncher\n" +
+		"  0:04 Item: 3 item_enviro\n" +
+		"  0:05 ClientUserinfoChanged: 2 n\\Zeh\\t\\0\n" +
+		"  0:40 Item: 2 item_enviro\n" +
+		"  0:50 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{Powerups: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []Powerup{
+		{Time: 2, Player: "Isgalamido", Item: "item_quad"},
+		{Time: 40, Player: "Zeh", Item: "item_enviro"},
+	}, matches[0].Powerups)
+
+	matches, err = ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].Powerups)
+}
+
+func TestItemPickups(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm13\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\n" +
+		"  0:01 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:02 Item: 2 item_armor_body\n" +
+		"  0:03 Item: 3 item_health_mega\n" +
+		"  0:04 Item: 3 weapon_railgun\n" +
+		"  0:30 Item: 2 item_armor_body\n" +
+		"  0:35 Item: 2 item_armor_combat\n" +
+		"  0:50 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{ItemControl: true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]int{
+		"Isgalamido": {"item_armor_body": 2, "item_armor_combat": 1},
+		"Zeh":        {"item_health_mega": 1},
+	}, matches[0].ItemPickups)
+
+	matches, err = ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].ItemPickups)
+}
+
+func TestVotes(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm17\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\n" +
+		"  0:01 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:10 callvote: 2 \"map q3dm6\"\n" +
+		"  0:11 vote: 2 yes\n" +
+		"  0:12 vote: 3 no\n" +
+		"  0:40 Vote failed.\n" +
+		"  1:00 vote: 3 yes\n" +
+		"  1:10 CallVote: 3 kick Isgalamido\n" +
+		"  1:12 Vote: 3 y\n" +
+		"  1:30 Vote passed.\n" +
+		"  2:00 callvote: 7 g_gametype 1\n" +
+		"  2:50 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{Votes: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []Vote{
+		{Time: 10, Caller: "Isgalamido", Vote: "map q3dm6", Yes: 1, No: 1, Result: "failed"},
+		{Time: 70, Caller: "Zeh", Vote: "kick Isgalamido", Yes: 1, Result: "passed"},
+		{Time: 120, Vote: "g_gametype 1", Result: "unknown"},
+	}, matches[0].Votes)
+
+	matches, err = ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].Votes)
+}
+
+func TestAdminActions(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm17\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\n" +
+		"  0:01 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:10 Mute: 3 spamming the chat\n" +
+		"  0:20 Kick: 7 no such client\n" +
+		"  1:00 KICK: 3\n" +
+		"  1:00 ClientDisconnect: 3\n" +
+		"  1:30 TempBan: 2 aimbot\n" +
+		"  2:50 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{AdminActions: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []AdminAction{
+		{Time: 10, Action: "mute", Player: "Zeh", Reason: "spamming the chat"},
+		{Time: 60, Action: "kick", Player: "Zeh"},
+		{Time: 90, Action: "tempban", Player: "Isgalamido", Reason: "aimbot"},
+	}, matches[0].AdminActions)
+
+	matches, err = ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].AdminActions)
+}
+
+func TestRaw(t *testing.T) {
+	first := "  0:00 InitGame: \\mapname\\q3dm17\n" +
+		"  0:10 Kill: 2 3 6: Isgalamido killed Zeh by MOD_ROCKET\n" +
+		"  0:50 " + matchSeparator + "\n"
+	second := "  0:00 InitGame: \\mapname\\q3dm6\n" +
+		"  0:05 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(first+"  0:50 say: gg\n"+second), Options{Raw: true})
+	assert.NoError(t, err)
+	assert.Equal(t, first, string(matches[0].Raw))
+	assert.Equal(t, second, string(matches[1].Raw))
+
+	matches, err = ParseLog(strings.NewReader(first))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].Raw)
+}
+
+func TestSettings(t *testing.T) {
+	log := "  0:00 InitGame: \\sv_hostname\\Code Miner Server\\g_gametype\\4\\fraglimit\\20\\timelimit\\15\\mapname\\q3wctf1\n" +
+		"  0:50 " + matchSeparator + "\n" +
+		"  0:50 InitGame: \\g_gametype\\12\\mapname\\q3dm17\n" +
+		"  0:55 " + matchSeparator + "\n" +
+		"  0:55 InitGame:\n" +
+		"  0:59 " + matchSeparator + "\n"
+
+	matches, err := ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "ctf", matches[0].GameType)
+	assert.Equal(t, 20, matches[0].FragLimit)
+	assert.Equal(t, 15, matches[0].TimeLimit)
+	assert.Equal(t, map[string]string{
+		"sv_hostname": "Code Miner Server",
+		"g_gametype":  "4",
+		"fraglimit":   "20",
+		"timelimit":   "15",
+		"mapname":     "q3wctf1",
+	}, matches[0].Settings)
+
+	assert.Equal(t, "12", matches[1].GameType, "game types of mods are kept as numbers")
+	assert.Zero(t, matches[1].FragLimit)
+
+	assert.Empty(t, matches[2].GameType)
+	assert.Empty(t, matches[2].Settings)
+}
+
+func TestCompetitiveness(t *testing.T) {
+	for _, test := range []struct {
+		kills    []string
+		expected float64
+	}{
+		{nil, 0},
+		{[]string{"Isgalamido killed Mocinha"}, 0},
+		{[]string{"Isgalamido killed Mocinha", "Mocinha killed Isgalamido"}, 1},
+		{[]string{"Isgalamido killed Mocinha", "Isgalamido killed Zeh", "Zeh killed Mocinha"}, 0.5},
+		{[]string{"<world> killed Mocinha", "<world> killed Isgalamido"}, 1},
+		{[]string{"<world> killed Mocinha", "<world> killed Mocinha", "<world> killed Isgalamido"}, 0},
+	} {
+		p := logParser{evParser: lookingForGameParser{}}
+		p.parseEvent("InitGame:")
+		for _, kill := range test.kills {
+			p.parseEvent("Kill: 0 1 6: " + kill + " by MOD_ROCKET")
+		}
+		p.parseEvent(matchSeparator)
+		assert.Equal(t, test.expected, p.matches[0].Competitiveness, test.kills)
+	}
+}
+
+func TestPlayerIPs(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm6\n" +
+		"  0:01 ClientConnect: 2 (10.0.0.2:27960)\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\n" +
+		"  0:02 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:02 ClientUserinfo: 3 \\ip\\2001:db8::1\\name\\Zeh\n" +
+		"  0:03 ClientConnect: 4\n" +
+		"  0:03 ClientUserinfoChanged: 4 n\\Mocinha\\t\\0\n" +
+		"  0:50 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{ClientIPs: true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Isgalamido": "10.0.0.2", "Zeh": "2001:db8::1"}, matches[0].PlayerIPs)
+
+	matches, err = ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].PlayerIPs)
+}
+
+func TestDuplicatePlayers(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm6\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Zeh\\t\\0\n" +
+		"  0:02 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:03 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:04 Kill: 2 3 6: Zeh killed Zeh by MOD_ROCKET\n" +
+		"  0:05 Kill: 3 2 6: Zeh killed Zeh by MOD_ROCKET\n" +
+		"  0:06 Kill: 3 2 6: Zeh killed Zeh by MOD_ROCKET\n" +
+		"  0:07 score: 1  ping: 40  client: 2 Zeh\n" +
+		"  0:07 score: 2  ping: 90  client: 3 Zeh\n" +
+		"  0:08 ClientDisconnect: 2\n" +
+		"  0:09 ClientUserinfoChanged: 4 n\\Zeh\\t\\0\n" +
+		"  0:10 Kill: 4 3 6: Zeh killed Zeh by MOD_ROCKET\n" +
+		"  0:50 " + matchSeparator + "\n"
+
+	matches, warnings, err := ParseLogWithOptions(strings.NewReader(log), Options{Pings: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Zeh", "Zeh (2)"}, matches[0].Players)
+	assert.Equal(t, map[string]int{"Zeh": 2, "Zeh (2)": 2}, matches[0].Kills)
+	assert.Equal(t, 40, matches[0].Pings["Zeh"].Max)
+	assert.Equal(t, 90, matches[0].Pings["Zeh (2)"].Max)
+	assert.Len(t, warnings, 1)
+	assert.ErrorIs(t, warnings[0], ErrDuplicatePlayer)
+	assert.Equal(t, 3, warnings[0].Line)
+}
+
+func TestPings(t *testing.T) {
+	p := logParser{evParser: lookingForGameParser{}, opts: Options{Pings: true}}
+	p.parseEvent("InitGame:")
+	p.parseEvent("score: 20  ping: 40  client: 4 Zeh")
+	p.parseEvent("score: 3  ping: 110  client: 5 Mocinha")
+	p.parseEvent("score: 21  ping: 60  client: 4 Zeh")
+	p.parseEvent(matchSeparator)
+	p.parseEvent("InitGame:")
+	p.parseEvent(matchSeparator)
+
+	assert.Equal(t, map[string]PingStats{
+		"Zeh":     {Min: 40, Average: 50, Max: 60, Samples: 2},
+		"Mocinha": {Min: 110, Average: 110, Max: 110, Samples: 1},
+	}, p.matches[0].Pings)
+	assert.Nil(t, p.matches[1].Pings)
+}
+
+func TestAccuracy(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm6\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\n" +
+		"  0:01 ClientUserinfoChanged: 3 n\\Zeh\\t\\0\n" +
+		"  0:40 Weapon_Stats: 2 MachineGun:200:50:1:0 Railgun:10:0:0:2 Given:1200 Recvd:900\n" +
+		"  0:40 Weapon_Stats: 3 Gauntlet:0:0:0:0\n" +
+		"  0:40 Weapon_Stats: 7 Shotgun:10:5:0:0\n" +
+		"  0:50 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{Accuracy: true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]WeaponAccuracy{
+		"Isgalamido": {
+			"MachineGun": {Shots: 200, Hits: 50, Accuracy: 0.25},
+			"Railgun":    {Shots: 10, Hits: 0, Accuracy: 0},
+		},
+		"Zeh": {"Gauntlet": {}},
+	}, matches[0].Accuracy)
+
+	matches, err = ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].Accuracy)
+}
+
 func TestLogEntriesEndedWhileMatchStillOpen(t *testing.T) {
 	log := "  0:00 ------------------------------------------------------------\n  0:00 InitGame:"
 	_, err := ParseLog(strings.NewReader(log))
@@ -95,6 +412,23 @@ func TestLogEntriesEndedWhileMatchStillOpen(t *testing.T) {
 	assert.ErrorContains(t, err, "log entries ended while a match was still open")
 }
 
+func TestExitAndFinalScores(t *testing.T) {
+	p := logParser{evParser: lookingForGameParser{}}
+	p.parseEvent("InitGame:")
+	p.parseEvent("Exit: Fraglimit hit.")
+	p.parseEvent("score: 19  ping: 40  client: 4 Zeh")
+	p.parseEvent("score: -2  ping: 110  client: 5 Mocinha")
+	p.parseEvent("score: 20  ping: 60  client: 4 Zeh")
+	p.parseEvent(matchSeparator)
+	p.parseEvent("InitGame:")
+	p.parseEvent(matchSeparator)
+
+	assert.Equal(t, "Fraglimit hit.", p.matches[0].ExitReason)
+	assert.Equal(t, map[string]int{"Zeh": 20, "Mocinha": -2}, p.matches[0].FinalScores)
+	assert.Empty(t, p.matches[1].ExitReason)
+	assert.Nil(t, p.matches[1].FinalScores)
+}
+
 func TestTotalKillsCounting(t *testing.T) {
 	p := newLogParser()
 	p.parseEvent("InitGame:")
@@ -112,6 +446,13 @@ func TestParseLog(t *testing.T) {
 	assert.Len(t, matches, 21)
 
 	firstMatch := matches[0]
+	assert.Equal(t, "q3dm17", firstMatch.MapName)
+	assert.Equal(t, Server{
+		GameName: "baseq3",
+		Version:  "ioq3 1.36 linux-x86_64 Apr 12 2009",
+		Protocol: "68",
+		Hostname: "Code Miner Server",
+	}, firstMatch.Server)
 	assert.Equal(t, 0, len(firstMatch.Kills))
 	assert.Equal(t, 0, firstMatch.TotalKills)
 
@@ -120,6 +461,9 @@ func TestParseLog(t *testing.T) {
 	assert.Equal(t, 2, len(secondMatch.Kills))
 	assert.Equal(t, 2, len(secondMatch.Players))
 	assert.Equal(t, -7, secondMatch.Kills["Isgalamido"])
+	assert.Equal(t, 2, secondMatch.PlayerCount)
+	assert.Equal(t, 1.0, secondMatch.FragParticipation["Isgalamido"])
+	assert.InDelta(t, 1.0/11, secondMatch.FragParticipation["Mocinha"], 1e-9)
 
 	thirdMatch := matches[2]
 	assert.Equal(t, 4, thirdMatch.TotalKills)
@@ -139,3 +483,85 @@ func TestParseLog(t *testing.T) {
 		thirdMatch.KillsByMeans,
 	)
 }
+
+func TestMatchHashIsStableAcrossLogs(t *testing.T) {
+	game := "  1:00 InitGame: \\mapname\\q3dm17\n" +
+		"  1:05 Kill: 0 1 2: Isgalamido killed Mocinha by MOD_ROCKET\n" +
+		"  1:10 ------------------------------------------------------------\n"
+	other := "  0:00 InitGame: \\mapname\\q3dm6\n" +
+		"  0:30 ------------------------------------------------------------\n"
+
+	first, err := ParseLog(strings.NewReader(game))
+	assert.NoError(t, err)
+	rotated, err := ParseLog(strings.NewReader(other + game))
+	assert.NoError(t, err)
+
+	assert.Len(t, first[0].MatchHash, 64)
+	assert.Equal(t, first[0].MatchHash, rotated[1].MatchHash)
+	assert.NotEqual(t, rotated[0].MatchHash, rotated[1].MatchHash)
+}
+
+func TestDurationAndKillFeed(t *testing.T) {
+	log := "  1:00 InitGame:\n" +
+		"  1:05 Kill: 0 1 2: Isgalamido killed Mocinha by MOD_ROCKET\n" +
+		"  2:10 Kill: 1022 1 22: <world> killed Isgalamido by MOD_FALLING\n" +
+		"  2:30 ShutdownGame:\n" +
+		"  2:30 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{KillFeed: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 60, matches[0].StartTime)
+	assert.Equal(t, 90, matches[0].Duration)
+	assert.Equal(t, []Kill{
+		{Time: 65, Killer: "Isgalamido", Victim: "Mocinha", Means: "MOD_ROCKET"},
+		{Time: 130, Killer: "<world>", Victim: "Isgalamido", Means: "MOD_FALLING"},
+	}, matches[0].KillFeed)
+
+	matches, err = ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].KillFeed)
+}
+
+func TestWallClockTimes(t *testing.T) {
+	log := "  1:00 InitGame: \\g_timestamp\\2026-03-30 23:59:00\\mapname\\q3dm6\n" +
+		"  1:05 Kill: 0 1 2: Isgalamido killed Mocinha by MOD_ROCKET\n" +
+		"  2:10 Kill: 1022 1 22: <world> killed Isgalamido by MOD_FALLING\n" +
+		"  2:30 ShutdownGame:\n" +
+		"  2:30 " + matchSeparator + "\n"
+
+	zone := time.FixedZone("BRT", -3*60*60)
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{KillFeed: true, TimeZone: zone})
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-03-30T23:59:00-03:00", matches[0].StartedAt)
+	assert.Equal(t, "2026-03-31T00:00:30-03:00", matches[0].EndedAt)
+	assert.Equal(t, []Kill{
+		{Time: 65, At: "2026-03-30T23:59:05-03:00", Killer: "Isgalamido", Victim: "Mocinha", Means: "MOD_ROCKET"},
+		{Time: 130, At: "2026-03-31T00:00:10-03:00", Killer: "<world>", Victim: "Isgalamido", Means: "MOD_FALLING"},
+	}, matches[0].KillFeed)
+
+	matches, _, err = ParseLogWithOptions(strings.NewReader(log), Options{TimeZone: time.UTC})
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-03-30T23:59:00Z", matches[0].StartedAt)
+
+	// logs without the setting keep their relative times only
+	matches, err = ParseLog(strings.NewReader(strings.Replace(log, "g_timestamp", "sv_hostname", 1)))
+	assert.NoError(t, err)
+	assert.Empty(t, matches[0].StartedAt)
+	assert.Empty(t, matches[0].EndedAt)
+}
+
+func TestCompleteness(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm17\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\n" +
+		"  1:00 Exit: Fraglimit hit.\n" +
+		"  1:00 " + matchSeparator + "\n"
+	matches, err := ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Equal(t, Completeness{Timestamps: true, ExitReason: true, Userinfo: true}, matches[0].Completeness)
+
+	p := newLogParser()
+	p.parseEvent("InitGame:")
+	p.parseEvent("score: 20  ping: 4  client: 2 Isgalamido")
+	p.parseEvent(matchSeparator)
+	assert.Equal(t, Completeness{Scores: true}, p.matches[0].Completeness)
+}
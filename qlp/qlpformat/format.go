@@ -0,0 +1,209 @@
+// Package qlpformat implements the output formats selected with --format, such as JSON, CSV
+// and aligned tables, and holds their registry, so that programs embedding the command can
+// register formats of their own.
+package qlpformat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Table is data laid out in rows and columns, which can be written in any tabular format.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Table returns t itself, so that tables can be written as they are.
+func (t Table) Table() Table {
+	return t
+}
+
+// Tabular is implemented by outputs which can be laid out as a table, such as the reports.
+type Tabular interface {
+	Table() Table
+}
+
+// ErrNotTabular is returned by the formatters of tabular formats when asked to write a value
+// which is not Tabular.
+var ErrNotTabular = errors.New("this output cannot be written as a table")
+
+// Formatter writes outputs in a format.
+type Formatter interface {
+	// Format writes v to w. Formatters of tabular formats accept Tabular values only.
+	Format(w io.Writer, v any) error
+}
+
+// FormatterFunc adapts a function into a Formatter.
+type FormatterFunc func(w io.Writer, v any) error
+
+// Format calls f(w, v).
+func (f FormatterFunc) Format(w io.Writer, v any) error {
+	return f(w, v)
+}
+
+// TableFormatter adapts a function writing tables into a Formatter of a tabular format.
+type TableFormatter func(w io.Writer, t Table) error
+
+// Format writes the table of v, which must be Tabular.
+func (f TableFormatter) Format(w io.Writer, v any) error {
+	t, ok := v.(Tabular)
+	if !ok {
+		return ErrNotTabular
+	}
+	return f(w, t.Table())
+}
+
+// format is a registered format.
+type format struct {
+	name      string
+	formatter Formatter
+}
+
+var (
+	registryMu sync.RWMutex
+	// registry lists the formats in the order they were registered, starting with the
+	// built-in ones.
+	registry = []format{
+		{"json", FormatterFunc(writeJSON)},
+		{"csv", TableFormatter(writeCSV)},
+		{"table", TableFormatter(writeAligned)},
+		{"markdown", TableFormatter(writeMarkdown)},
+		{"yaml", FormatterFunc(writeYAML)},
+	}
+)
+
+// Register makes formatter available under name. It panics if a format with that name is
+// already registered, so it is meant to be called from init functions.
+func Register(name string, formatter Formatter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if slices.ContainsFunc(registry, func(f format) bool { return f.name == name }) {
+		panic(fmt.Sprintf("qlpformat: format %q registered twice", name))
+	}
+	registry = append(registry, format{name, formatter})
+}
+
+// Lookup returns the formatter of the format with the given name.
+func Lookup(name string) (Formatter, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	index := slices.IndexFunc(registry, func(f format) bool { return f.name == name })
+	if index < 0 {
+		return nil, false
+	}
+	return registry[index].formatter, true
+}
+
+// Names returns the names of the registered formats, in the order they were registered.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, len(registry))
+	for i, f := range registry {
+		names[i] = f.name
+	}
+	return names
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	jsonOutput, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(jsonOutput)
+	return err
+}
+
+// writeYAML writes v to w as YAML, with the same fields, names and order as in JSON, as the
+// outputs only describe their JSON encoding.
+func writeYAML(w io.Writer, v any) error {
+	jsonOutput, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is YAML, so its document keeps the order of the fields
+	var document yaml.Node
+	if err := yaml.Unmarshal(jsonOutput, &document); err != nil {
+		return err
+	}
+	plainStyle(&document)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// plainStyle clears the JSON style of the node and its children, such as quoted strings and
+// flow mappings, so that they are written in the usual style of YAML.
+func plainStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		plainStyle(child)
+	}
+}
+
+// writeCSV writes t as comma-separated values.
+func writeCSV(w io.Writer, t Table) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write(t.Header)
+	csvWriter.WriteAll(t.Rows)
+	return csvWriter.Error()
+}
+
+// writeAligned writes t as plain text, with its columns aligned by display width.
+func writeAligned(w io.Writer, t Table) error {
+	widths := make([]int, len(t.Header))
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], DisplayWidth(cell))
+		}
+	}
+
+	var b strings.Builder
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		for i, cell := range row {
+			if i == len(row)-1 {
+				b.WriteString(cell)
+			} else {
+				b.WriteString(PadRight(cell, widths[i]+2))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdown writes t as a GitHub Flavored Markdown table.
+func writeMarkdown(w io.Writer, t Table) error {
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for _, cell := range row {
+			b.WriteString(" " + strings.ReplaceAll(cell, "|", `\|`) + " |")
+		}
+		b.WriteByte('\n')
+	}
+
+	writeRow(t.Header)
+	b.WriteString(strings.Repeat("| --- ", len(t.Header)) + "|\n")
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
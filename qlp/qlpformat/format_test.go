@@ -0,0 +1,65 @@
+package qlpformat
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTable = Table{
+	Header: []string{"player", "kills"},
+	Rows:   [][]string{{"Isgalamido", "8"}, {"Zé|Z", "12"}},
+}
+
+func formatted(t *testing.T, name string, v any) string {
+	t.Helper()
+	formatter, ok := Lookup(name)
+	assert.True(t, ok)
+	var b strings.Builder
+	assert.NoError(t, formatter.Format(&b, v))
+	return b.String()
+}
+
+func TestBuiltinFormats(t *testing.T) {
+	assert.Equal(t, []string{"json", "csv", "table", "markdown", "yaml"}, Names()[:5])
+
+	assert.Equal(t, "{\n  \"kills\": 8\n}", formatted(t, "json", map[string]int{"kills": 8}))
+	assert.Equal(t, "player,kills\nIsgalamido,8\nZé|Z,12\n", formatted(t, "csv", testTable))
+	assert.Equal(t, "player      kills\nIsgalamido  8\nZé|Z        12\n", formatted(t, "table", testTable))
+	assert.Equal(t, "| player | kills |\n| --- | --- |\n| Isgalamido | 8 |\n| Zé\\|Z | 12 |\n", formatted(t, "markdown", testTable))
+
+	assert.Equal(t, "player: Zé\nkills:\n  - 8\n  - 12\nteam: null\nnumber: \"12\"\n", formatted(t, "yaml", struct {
+		Player string `json:"player"`
+		Kills  []int  `json:"kills"`
+		Team   *int   `json:"team"`
+		Number string `json:"number"`
+	}{Player: "Zé", Kills: []int{8, 12}, Number: "12"}))
+
+	formatter, _ := Lookup("csv")
+	assert.ErrorIs(t, formatter.Format(io.Discard, map[string]int{}), ErrNotTabular)
+}
+
+func TestRegister(t *testing.T) {
+	Register("lines", TableFormatter(func(w io.Writer, t Table) error {
+		for _, row := range t.Rows {
+			io.WriteString(w, strings.Join(row, " ")+"\n")
+		}
+		return nil
+	}))
+	assert.Contains(t, Names(), "lines")
+	assert.Equal(t, "Isgalamido 8\nZé|Z 12\n", formatted(t, "lines", testTable))
+
+	assert.Panics(t, func() { Register("json", FormatterFunc(writeJSON)) })
+
+	_, ok := Lookup("toml")
+	assert.False(t, ok)
+}
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, 4, DisplayWidth("Zeh!"))
+	assert.Equal(t, 4, DisplayWidth("日本"))
+	assert.Equal(t, 1, DisplayWidth("é"))
+	assert.Equal(t, "日本  |", PadRight("日本", 6)+"|")
+}
@@ -0,0 +1,51 @@
+package qlpformat
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wideRanges holds the East Asian wide and fullwidth characters, which take two columns of a
+// terminal.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1}, // Hangul Jamo
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1}, // CJK radicals and punctuation
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1}, // kana, Bopomofo and CJK compatibility
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1}, // CJK unified ideographs extension A
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1}, // CJK unified ideographs
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1}, // Yi
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1}, // Hangul syllables
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1}, // CJK compatibility ideographs
+		{Lo: 0xfe30, Hi: 0xfe4f, Stride: 1}, // CJK compatibility forms
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1}, // fullwidth forms
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1}, // fullwidth signs
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1}, // pictographs and emoticons
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1}, // supplemental pictographs
+		{Lo: 0x20000, Hi: 0x3fffd, Stride: 1}, // CJK unified ideographs extensions
+	},
+}
+
+// DisplayWidth returns how many terminal columns s takes. Combining marks and format
+// characters take none, while East Asian wide characters take two.
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || unicode.IsControl(r):
+		case unicode.Is(wideRanges, r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// PadRight pads s with spaces up to width columns, like the "%-*s" verb would if every
+// character took a single column.
+func PadRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-DisplayWidth(s), 0))
+}
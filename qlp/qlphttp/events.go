@@ -0,0 +1,86 @@
+package qlphttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// eventsKeepAlive is how often GET /events writes a comment while the matches do not change,
+// so that proxies do not close the connection as idle.
+const eventsKeepAlive = 30 * time.Second
+
+// Notifier is implemented by the stores which tell when their matches change, so that
+// GET /events pushes the changes to its clients. The events of other stores only carry the
+// matches as they were when the client connected.
+type Notifier interface {
+	// Changed returns a channel which is closed once the matches of the store change. It is
+	// called before the matches are read, so that no change is missed.
+	Changed() <-chan struct{}
+}
+
+// scoreboard is the data of the events of GET /events.
+type scoreboard struct {
+	Ranking []qlp.PlayerRank `json:"ranking"`
+	// Game is the number of the latest match, numbered from 1, or 0 without matches.
+	Game   int        `json:"game"`
+	Latest *qlp.Match `json:"latest"`
+}
+
+// newScoreboard returns the scoreboard of the matches.
+func newScoreboard(matches qlp.Matches) scoreboard {
+	board := scoreboard{Ranking: qlp.Rank(matches), Game: len(matches)}
+	if len(matches) > 0 {
+		board.Latest = &matches[len(matches)-1]
+	}
+	return board
+}
+
+// serveEvents serves GET /events: a stream of server-sent events, each named "scoreboard"
+// and holding the ranking and the latest match of the store, sent when the client connects
+// and whenever the matches change.
+func serveEvents(w http.ResponseWriter, r *http.Request, store Store) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	notifier, _ := store.(Notifier)
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		var changed <-chan struct{} // nil, and so never ready, without a notifier
+		if notifier != nil {
+			changed = notifier.Changed()
+		}
+		data, err := json.Marshal(newScoreboard(store.Matches()))
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: scoreboard\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+	wait:
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-changed:
+				break wait
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package qlphttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+// changingStore is a MemoryStore whose matches are replaced by set, notifying of the change.
+type changingStore struct {
+	mu      sync.Mutex
+	store   *MemoryStore
+	changed chan struct{}
+}
+
+func (s *changingStore) Matches() qlp.Matches {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Matches()
+}
+
+func (s *changingStore) Servers() []string { return nil }
+
+func (s *changingStore) Server(string) (Store, bool) { return nil, false }
+
+func (s *changingStore) Changed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+func (s *changingStore) set(matches qlp.Matches) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = &MemoryStore{Logs: matches}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// nextScoreboard reads the next scoreboard event of the stream.
+func nextScoreboard(t *testing.T, stream *bufio.Reader) scoreboard {
+	t.Helper()
+	event := ""
+	for {
+		line, err := stream.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return scoreboard{}
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			event = name
+		} else if data, ok := strings.CutPrefix(line, "data: "); ok && event == "scoreboard" {
+			var board scoreboard
+			assert.NoError(t, json.Unmarshal([]byte(data), &board))
+			return board
+		}
+	}
+}
+
+func TestHandlerEvents(t *testing.T) {
+	store := &changingStore{store: &MemoryStore{}, changed: make(chan struct{})}
+	server := httptest.NewServer(NewHandler(store))
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/events")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer response.Body.Close()
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	stream := bufio.NewReader(response.Body)
+
+	board := nextScoreboard(t, stream)
+	assert.Equal(t, 0, board.Game)
+	assert.Nil(t, board.Latest)
+	assert.Empty(t, board.Ranking)
+
+	store.set(qlp.Matches{
+		{MapName: "q3dm17", Players: []string{"Zeh"}, Frags: map[string]int{"Zeh": 0}},
+		{MapName: "q3dm6", Players: []string{"Zeh", "Mocinha"}, Frags: map[string]int{"Zeh": 1}},
+	})
+	board = nextScoreboard(t, stream)
+	assert.Equal(t, 2, board.Game)
+	assert.Equal(t, "q3dm6", board.Latest.MapName)
+	assert.Equal(t, "Zeh", board.Ranking[0].Player)
+	assert.Equal(t, 1, board.Ranking[0].Kills)
+}
+
+func TestHandlerEventsWithoutNotifier(t *testing.T) {
+	server := httptest.NewServer(NewHandler(&MemoryStore{Sources: map[string]qlp.Matches{
+		"ffa": {{MapName: "q3dm6"}},
+	}}))
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/servers/ffa/events")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer response.Body.Close()
+	board := nextScoreboard(t, bufio.NewReader(response.Body))
+	assert.Equal(t, "q3dm6", board.Latest.MapName)
+
+	response, err = http.Get(server.URL + "/servers/unknown/events")
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	}
+}
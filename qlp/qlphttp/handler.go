@@ -0,0 +1,216 @@
+// Package qlphttp implements the HTTP API over parsed matches which is served by the "serve"
+// subcommand, so that other Go applications can mount it inside their own servers.
+package qlphttp
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// Store provides the matches served by the handler. Its methods are called on every request,
+// possibly concurrently, so they should return quickly and must be safe for concurrent use.
+type Store interface {
+	// Matches returns the matches of the store's own logs.
+	Matches() qlp.Matches
+	// Servers returns the ids of the game servers whose matches are served separately, under
+	// /servers/{id}/.
+	Servers() []string
+	// Server returns the store of the game server with the given id, if there is one.
+	Server(id string) (Store, bool)
+}
+
+// NewHandler returns the handler of the HTTP API over the matches of store. Its routes are:
+//
+//	GET /matches          the matches, as a JSON object keyed "game_1", "game_2", ...
+//	GET /matches/{n}      the n-th match, numbered from 1 as in the keys of /matches
+//	GET /matches/latest   the latest match
+//	GET /players/{name}   the kills, deaths and deaths by the world of a player over every
+//	                      match, as ranked by qlp.Rank, and the numbers of their matches
+//	GET /ranking          every player, as ranked by qlp.Rank, as a JSON array
+//	GET /events           a stream of server-sent events holding the ranking and the latest
+//	                      match, sent as the client connects and whenever the matches change
+//	                      if the store is a Notifier
+//	GET /scoreboard       an HTML page showing the ranking and the latest match, updated by
+//	                      the events
+//	GET /servers          the ids of the game servers, as a JSON array
+//	GET /servers/{id}/... the routes above, such as /servers/{id}/matches, over the matches of
+//	                      a game server
+func NewHandler(store Store) http.Handler {
+	return NewHandlerWithOptions(store, HandlerOptions{})
+}
+
+// NewHandlerWithOptions is like NewHandler, but serves the API according to opts. With
+// opts.Upload, it also has the route:
+//
+//	POST /parse  the matches of the log in the body, raw or gzipped, or in the first file of a
+//	             multipart/form-data body
+//
+// Logs larger than opts.MaxUploadSize are refused with 413 Request Entity Too Large, logs
+// which take longer than opts.ParseTimeout to upload and parse with 408 Request Timeout, and
+// logs which fail to parse with 422 Unprocessable Entity. With opts.Jobs, it also has the
+// routes:
+//
+//	POST /jobs              queues the log, taken as by POST /parse, for parsing in the
+//	                        background, responding with 202 Accepted and the status of the job
+//	GET /jobs/{id}          the status of a job: queued, running, done or failed, its progress,
+//	                        and the path of its result once done
+//	GET /jobs/{id}/result   the matches of a job, once done
+//
+// Jobs are refused with 503 Service Unavailable while opts.MaxQueuedJobs jobs are waiting or
+// their logs take opts.MaxJobDisk bytes, and forgotten opts.JobRetention after they finish,
+// or earlier once their matches, kept on disk, take opts.MaxResultDisk bytes.
+func NewHandlerWithOptions(store Store, opts HandlerOptions) http.Handler {
+	mux := http.NewServeMux()
+	if opts.Upload {
+		mux.HandleFunc("POST /parse", parseUpload(opts))
+	}
+	if opts.Jobs {
+		jobs := newJobQueue(opts)
+		mux.HandleFunc("POST /jobs", jobs.submit)
+		mux.HandleFunc("GET /jobs/{id}", jobs.get)
+		mux.HandleFunc("GET /jobs/{id}/result", jobs.result)
+	}
+	handleStore(mux, "", func(*http.Request) (Store, bool) { return store, true })
+	handleStore(mux, "/servers/{id}", func(r *http.Request) (Store, bool) { return store.Server(r.PathValue("id")) })
+	mux.HandleFunc("GET /servers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, store.Servers())
+	})
+	return mux
+}
+
+// playerStats is the response of GET /players/{name}.
+type playerStats struct {
+	qlp.PlayerRank
+	// Games holds the numbers of the matches the player played, numbered from 1.
+	Games []int `json:"games"`
+}
+
+// handleStore adds the routes over the matches of a store to mux, under prefix. storeOf returns
+// the store of a request, reporting false if it does not exist.
+func handleStore(mux *http.ServeMux, prefix string, storeOf func(r *http.Request) (Store, bool)) {
+	handle := func(route string, fn func(w http.ResponseWriter, r *http.Request, matches qlp.Matches)) {
+		mux.HandleFunc("GET "+prefix+route, func(w http.ResponseWriter, r *http.Request) {
+			store, ok := storeOf(r)
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fn(w, r, store.Matches())
+		})
+	}
+
+	handle("/matches", func(w http.ResponseWriter, r *http.Request, matches qlp.Matches) {
+		writeJSON(w, matches)
+	})
+	handle("/matches/{n}", func(w http.ResponseWriter, r *http.Request, matches qlp.Matches) {
+		n, err := strconv.Atoi(r.PathValue("n"))
+		if err != nil || n < 1 || n > len(matches) {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, matches[n-1])
+	})
+	handle("/matches/latest", func(w http.ResponseWriter, r *http.Request, matches qlp.Matches) {
+		if len(matches) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, matches[len(matches)-1])
+	})
+	handle("/players/{name}", func(w http.ResponseWriter, r *http.Request, matches qlp.Matches) {
+		name := r.PathValue("name")
+		ranking := qlp.Rank(matches)
+		index := slices.IndexFunc(ranking, func(p qlp.PlayerRank) bool { return p.Player == name })
+		if index < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		stats := playerStats{PlayerRank: ranking[index], Games: []int{}}
+		for i, match := range matches {
+			if slices.Contains(match.Players, name) {
+				stats.Games = append(stats.Games, i+1)
+			}
+		}
+		writeJSON(w, stats)
+	})
+	handle("/ranking", func(w http.ResponseWriter, r *http.Request, matches qlp.Matches) {
+		writeJSON(w, qlp.Rank(matches))
+	})
+	mux.HandleFunc("GET "+prefix+"/events", func(w http.ResponseWriter, r *http.Request) {
+		store, ok := storeOf(r)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		serveEvents(w, r, store)
+	})
+	handle("/scoreboard", func(w http.ResponseWriter, r *http.Request, _ qlp.Matches) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(scoreboardPage)
+	})
+}
+
+// scoreboardPage is the page of GET /scoreboard. It subscribes to the events next to it, so
+// that it works under /servers/{id}/ as well.
+//
+//go:embed scoreboard.html
+var scoreboardPage []byte
+
+// writeJSON writes v as the indented JSON response of a request.
+func writeJSON(w http.ResponseWriter, v any) {
+	writeJSONStatus(w, http.StatusOK, v)
+}
+
+// writeJSONStatus writes v as the indented JSON response of a request, with the given status
+// code.
+func writeJSONStatus(w http.ResponseWriter, code int, v any) {
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// MemoryStore is a Store over matches held in memory, which must not be modified while the
+// store is in use.
+type MemoryStore struct {
+	// Logs holds the store's own matches.
+	Logs qlp.Matches
+	// Sources holds the matches of each game server, by id.
+	Sources map[string]qlp.Matches
+}
+
+// Matches implements Store.
+func (s *MemoryStore) Matches() qlp.Matches {
+	if s.Logs == nil {
+		return qlp.Matches{}
+	}
+	return s.Logs
+}
+
+// Servers implements Store.
+func (s *MemoryStore) Servers() []string {
+	ids := make([]string, 0, len(s.Sources))
+	for id := range s.Sources {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Server implements Store.
+func (s *MemoryStore) Server(id string) (Store, bool) {
+	matches, ok := s.Sources[id]
+	if !ok {
+		return nil, false
+	}
+	return &MemoryStore{Logs: matches}, true
+}
@@ -0,0 +1,108 @@
+package qlphttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler(&MemoryStore{
+		Logs: qlp.Matches{{MapName: "q3dm17"}},
+		Sources: map[string]qlp.Matches{
+			"ffa": {{MapName: "q3dm6"}, {MapName: "q3dm7"}},
+			"ctf": {},
+		},
+	})
+
+	response := get(t, handler, "/matches")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+	var matches map[string]qlp.Match
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &matches))
+	assert.Equal(t, "q3dm17", matches["game_1"].MapName)
+
+	response = get(t, handler, "/servers")
+	assert.JSONEq(t, `["ctf", "ffa"]`, response.Body.String())
+
+	response = get(t, handler, "/servers/ffa/matches")
+	matches = nil
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &matches))
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "q3dm7", matches["game_2"].MapName)
+
+	response = get(t, handler, "/servers/ctf/matches")
+	assert.JSONEq(t, `{}`, response.Body.String())
+
+	response = get(t, handler, "/servers/unknown/matches")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestHandlerStats(t *testing.T) {
+	matches := qlp.Matches{
+		{
+			MapName: "q3dm17", Players: []string{"Isgalamido", "Zeh"},
+			Frags:         map[string]int{"Isgalamido": 1, "Zeh": 0},
+			Deaths:        map[string]int{"Isgalamido": 1, "Zeh": 1},
+			DeathsByWorld: map[string]int{"Isgalamido": 1},
+		},
+		{
+			MapName: "q3dm6", Players: []string{"Zeh", "Mocinha"},
+			Frags:  map[string]int{"Zeh": 2, "Mocinha": 0},
+			Deaths: map[string]int{"Zeh": 0, "Mocinha": 2},
+		},
+	}
+	handler := NewHandler(&MemoryStore{Logs: matches, Sources: map[string]qlp.Matches{"ffa": matches[1:]}})
+
+	var match qlp.Match
+	assert.NoError(t, json.Unmarshal(get(t, handler, "/matches/2").Body.Bytes(), &match))
+	assert.Equal(t, "q3dm6", match.MapName)
+	assert.NoError(t, json.Unmarshal(get(t, handler, "/matches/latest").Body.Bytes(), &match))
+	assert.Equal(t, "q3dm6", match.MapName)
+	assert.Equal(t, http.StatusNotFound, get(t, NewHandler(&MemoryStore{}), "/matches/latest").Code)
+	for _, path := range []string{"/matches/0", "/matches/3", "/matches/last", "/servers/ffa/matches/2"} {
+		assert.Equal(t, http.StatusNotFound, get(t, handler, path).Code, path)
+	}
+
+	assert.JSONEq(t, `[
+		{"rank": 1, "player": "Zeh", "kills": 2, "deaths": 1, "world_deaths": 0, "matches": 2},
+		{"rank": 2, "player": "Isgalamido", "kills": 1, "deaths": 1, "world_deaths": 1, "matches": 1},
+		{"rank": 3, "player": "Mocinha", "kills": 0, "deaths": 2, "world_deaths": 0, "matches": 1}
+	]`, get(t, handler, "/ranking").Body.String())
+
+	assert.JSONEq(t, `{"rank": 1, "player": "Zeh", "kills": 2, "deaths": 1, "world_deaths": 0, "matches": 2, "games": [1, 2]}`,
+		get(t, handler, "/players/Zeh").Body.String())
+	assert.JSONEq(t, `{"rank": 1, "player": "Zeh", "kills": 2, "deaths": 0, "world_deaths": 0, "matches": 1, "games": [1]}`,
+		get(t, handler, "/servers/ffa/players/Zeh").Body.String())
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/players/Nobody").Code)
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/servers/unknown/ranking").Code)
+}
+
+func TestHandlerScoreboard(t *testing.T) {
+	handler := NewHandler(&MemoryStore{Sources: map[string]qlp.Matches{"ffa": {}}})
+	for _, path := range []string{"/scoreboard", "/servers/ffa/scoreboard"} {
+		response := get(t, handler, path)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "text/html; charset=utf-8", response.Header().Get("Content-Type"))
+		// the page subscribes to the events next to it
+		assert.Contains(t, response.Body.String(), `new EventSource("events")`)
+	}
+}
+
+func TestHandlerEmptyStore(t *testing.T) {
+	handler := NewHandler(&MemoryStore{})
+	assert.JSONEq(t, `{}`, get(t, handler, "/matches").Body.String())
+	assert.JSONEq(t, `[]`, get(t, handler, "/servers").Body.String())
+	assert.JSONEq(t, `[]`, get(t, handler, "/ranking").Body.String())
+}
@@ -0,0 +1,430 @@
+package qlphttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// Defaults of the job options of HandlerOptions.
+const (
+	DefaultJobConcurrency = 2
+	DefaultJobRetention   = time.Hour
+	DefaultMaxQueuedJobs  = 64
+	DefaultMaxJobDisk     = 1 << 30 // 1 GiB
+	DefaultMaxResultDisk  = 1 << 30 // 1 GiB
+)
+
+// errResultTooLarge fails the jobs whose matches alone take more than MaxResultDisk.
+var errResultTooLarge = errors.New("matches too large to be kept")
+
+// jobRetryAfter is the Retry-After of the responses refusing jobs while the queue is full, in
+// seconds.
+const jobRetryAfter = "60"
+
+// Statuses of a job.
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// jobStatus is the response of GET /jobs/{id}.
+type jobStatus struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // queued, running, done or failed
+	// Progress is the share of the upload parsed so far, from 0 to 1.
+	Progress float64 `json:"progress"`
+	// Matches is the number of matches parsed so far.
+	Matches int    `json:"matches"`
+	Error   string `json:"error,omitempty"`
+	// Result is the path of the matches, once the job is done. It is relative to the URL of
+	// the request, as Location is, so that it resolves under the prefix the handler is mounted
+	// at.
+	Result string `json:"result,omitempty"`
+}
+
+// job is a log uploaded to POST /jobs, which is parsed in the background.
+type job struct {
+	id   string
+	size int64 // of the upload, as sent
+	// file holds the upload until it is parsed.
+	file *os.File
+	// read is the number of bytes of the upload parsed so far.
+	read atomic.Int64
+	// result holds the matches, as GET /jobs/{id}/result serves them, once parsed.
+	result string
+
+	// the fields below are guarded by the mutex of the jobQueue
+	status string
+	// matches is the number of matches parsed so far, and resultSize the number of bytes
+	// result takes.
+	matches    int
+	resultSize int64
+	err        error
+	finished   time.Time
+}
+
+// jobQueue parses the logs uploaded to POST /jobs, a number of them at a time, and keeps
+// their results on disk until they expire.
+type jobQueue struct {
+	opts          HandlerOptions
+	maxSize       int64
+	retention     time.Duration
+	concurrency   int
+	maxQueued     int
+	maxDisk       int64
+	maxResultDisk int64
+
+	mu   sync.Mutex
+	jobs map[string]*job
+	// queued holds the jobs waiting to be parsed, first in first out, and running counts the
+	// jobs being parsed.
+	queued  []*job
+	running int
+	// disk is the number of bytes the logs of jobs take on disk, and results the number of
+	// bytes their matches take.
+	disk    int64
+	results int64
+}
+
+// newJobQueue creates and returns a jobQueue according to opts.
+func newJobQueue(opts HandlerOptions) *jobQueue {
+	q := &jobQueue{
+		opts:          opts,
+		maxSize:       opts.MaxUploadSize,
+		retention:     opts.JobRetention,
+		concurrency:   opts.JobConcurrency,
+		maxQueued:     opts.MaxQueuedJobs,
+		maxDisk:       opts.MaxJobDisk,
+		maxResultDisk: opts.MaxResultDisk,
+		jobs:          make(map[string]*job),
+	}
+	if q.maxSize <= 0 {
+		q.maxSize = DefaultMaxUploadSize
+	}
+	if q.retention <= 0 {
+		q.retention = DefaultJobRetention
+	}
+	if q.concurrency <= 0 {
+		q.concurrency = DefaultJobConcurrency
+	}
+	if q.maxQueued <= 0 {
+		q.maxQueued = DefaultMaxQueuedJobs
+	}
+	if q.maxDisk <= 0 {
+		q.maxDisk = DefaultMaxJobDisk
+	}
+	if q.maxResultDisk <= 0 {
+		q.maxResultDisk = DefaultMaxResultDisk
+	}
+	return q
+}
+
+// submit serves POST /jobs: it saves the log in the body of the request, as POST /parse takes
+// it, to a temporary file, queues it for parsing and responds with the status of the job.
+// While MaxQueuedJobs jobs are waiting, or their logs take MaxJobDisk bytes, new jobs are
+// refused with 503 Service Unavailable.
+func (q *jobQueue) submit(w http.ResponseWriter, r *http.Request) {
+	// the upload is only saved if it may be queued, which is checked again once it is saved
+	q.mu.Lock()
+	full := q.full(0)
+	q.mu.Unlock()
+	if full {
+		refuseJob(w)
+		return
+	}
+
+	body, err := uploadedBody(w, r, q.maxSize)
+	if err != nil {
+		writeUploadError(r.Context(), w, err)
+		return
+	}
+	file, err := os.CreateTemp(q.opts.JobDir, "qlp-job-*")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	size, err := io.Copy(file, body)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		writeUploadError(r.Context(), w, err)
+		return
+	}
+
+	j := &job{id: newJobID(), size: size, file: file, status: jobQueued}
+	q.mu.Lock()
+	if q.full(size) {
+		q.mu.Unlock()
+		file.Close()
+		os.Remove(file.Name())
+		refuseJob(w)
+		return
+	}
+	q.disk += size
+	q.jobs[j.id] = j
+	q.queued = append(q.queued, j)
+	q.start()
+	q.mu.Unlock()
+
+	// the location is relative, so that it resolves against the URL of the request when the
+	// handler is mounted under a prefix
+	w.Header().Set("Location", "jobs/"+j.id)
+	writeJSONStatus(w, http.StatusAccepted, q.status(j, "jobs/"+j.id+"/"))
+}
+
+// full reports whether a job whose upload takes size bytes must be refused, as too many jobs
+// are waiting or their logs take too much disk. It must be called with the mutex held.
+func (q *jobQueue) full(size int64) bool {
+	return len(q.queued) >= q.maxQueued || q.disk+size > q.maxDisk
+}
+
+// refuseJob responds that the job cannot be queued for now.
+func refuseJob(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", jobRetryAfter)
+	http.Error(w, "too many logs waiting to be parsed", http.StatusServiceUnavailable)
+}
+
+// start starts parsing the jobs first in the queue while there are fewer than JobConcurrency
+// being parsed. It must be called with the mutex held.
+func (q *jobQueue) start() {
+	for q.running < q.concurrency && len(q.queued) > 0 {
+		j := q.queued[0]
+		q.queued = q.queued[1:]
+		q.running++
+		j.status = jobRunning
+		go q.run(j)
+	}
+}
+
+// run parses the upload of the job, removing it afterwards, and starts the next job. The job
+// is forgotten once retained for long enough, or once MaxJobs later jobs finished or later
+// results take MaxResultDisk bytes.
+func (q *jobQueue) run(j *job) {
+	err := q.parse(j)
+	j.file.Close()
+	os.Remove(j.file.Name())
+
+	q.mu.Lock()
+	j.status, j.err, j.finished = jobDone, err, time.Now()
+	if err != nil {
+		j.status = jobFailed
+		q.removeResult(j)
+	}
+	q.disk -= j.size
+	q.running--
+	q.prune()
+	q.start()
+	q.mu.Unlock()
+
+	time.AfterFunc(q.retention, func() {
+		q.mu.Lock()
+		q.forget(j)
+		q.mu.Unlock()
+	})
+}
+
+// prune forgets the jobs which finished first while more than MaxJobs jobs are finished, or
+// while the results take more than MaxResultDisk bytes. It must be called with the mutex held.
+func (q *jobQueue) prune() {
+	var finished []*job
+	for _, j := range q.jobs {
+		if !j.finished.IsZero() {
+			finished = append(finished, j)
+		}
+	}
+	slices.SortFunc(finished, func(a, b *job) int { return a.finished.Compare(b.finished) })
+	for len(finished) > 0 && (q.opts.MaxJobs > 0 && len(finished) > q.opts.MaxJobs || q.results > q.maxResultDisk) {
+		q.forget(finished[0])
+		finished = finished[1:]
+	}
+}
+
+// forget forgets the job, removing its result. It must be called with the mutex held.
+func (q *jobQueue) forget(j *job) {
+	if q.jobs[j.id] != j {
+		return
+	}
+	delete(q.jobs, j.id)
+	q.removeResult(j)
+}
+
+// removeResult removes the result of the job from disk. It must be called with the mutex held.
+func (q *jobQueue) removeResult(j *job) {
+	if j.result != "" {
+		os.Remove(j.result)
+		j.result = ""
+	}
+	q.results -= j.resultSize
+	j.resultSize = 0
+}
+
+// parse parses the upload of the job, writing the matches to its result as they are parsed.
+// The job fails once its result alone takes more than MaxResultDisk bytes.
+func (q *jobQueue) parse(j *job) error {
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	log, err := decompress(&progressReader{r: j.file, read: &j.read})
+	if err != nil {
+		return err
+	}
+	result, err := os.CreateTemp(q.opts.JobDir, "qlp-result-*")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+	q.mu.Lock()
+	j.result = result.Name()
+	q.mu.Unlock()
+
+	// the result is indented as writeJSON indents responses
+	counted := &resultWriter{w: result, q: q, j: j}
+	encoder := qlp.NewMatchEncoder(counted, "  ")
+	err = qlp.NewParser(q.opts.ParseOptions).ParseFunc(
+		&uploadReader{ctx: context.Background(), r: log, left: q.maxSize},
+		func(match qlp.Match) error {
+			if err := encoder.Encode(match); err != nil {
+				return err
+			}
+			q.mu.Lock()
+			j.matches++
+			q.mu.Unlock()
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	return result.Close()
+}
+
+// resultWriter writes the result of a job, counting the bytes it takes on disk.
+type resultWriter struct {
+	w io.Writer
+	q *jobQueue
+	j *job
+}
+
+// Write writes p to the result, failing with errResultTooLarge once the result would take more
+// than MaxResultDisk bytes.
+func (w *resultWriter) Write(p []byte) (int, error) {
+	w.q.mu.Lock()
+	tooLarge := w.j.resultSize+int64(len(p)) > w.q.maxResultDisk
+	w.q.mu.Unlock()
+	if tooLarge {
+		return 0, errResultTooLarge
+	}
+
+	n, err := w.w.Write(p)
+	w.q.mu.Lock()
+	w.j.resultSize += int64(n)
+	w.q.results += int64(n)
+	w.q.mu.Unlock()
+	return n, err
+}
+
+// status returns the status of the job, whose URL, relative to the request it responds to,
+// is base, ending in a slash.
+func (q *jobQueue) status(j *job, base string) jobStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := jobStatus{ID: j.id, Status: j.status, Matches: j.matches, Progress: 1}
+	if j.size > 0 {
+		status.Progress = min(float64(j.read.Load())/float64(j.size), 1)
+	}
+	switch j.status {
+	case jobQueued:
+		status.Progress = 0
+	case jobDone:
+		status.Progress, status.Result = 1, base+"result"
+	case jobFailed:
+		status.Error = j.err.Error()
+	}
+	return status
+}
+
+// job returns the job with the given id, or nil if there is none.
+func (q *jobQueue) job(id string) *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.jobs[id]
+}
+
+// get serves GET /jobs/{id}: the status of the job.
+func (q *jobQueue) get(w http.ResponseWriter, r *http.Request) {
+	j := q.job(r.PathValue("id"))
+	if j == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, q.status(j, j.id+"/"))
+}
+
+// result serves GET /jobs/{id}/result: the matches of the job, once it is done.
+func (q *jobQueue) result(w http.ResponseWriter, r *http.Request) {
+	j := q.job(r.PathValue("id"))
+	if j == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the result is opened with the mutex held, so that it is not removed in the meantime
+	q.mu.Lock()
+	status, err := j.status, j.err
+	var result *os.File
+	if status == jobDone {
+		result, err = os.Open(j.result)
+	}
+	q.mu.Unlock()
+	switch status {
+	case jobDone:
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer result.Close()
+		w.Header().Set("Content-Type", "application/json")
+		io.Copy(w, result)
+	case jobFailed:
+		writeUploadError(context.Background(), w, err)
+	default:
+		http.Error(w, "job not done", http.StatusConflict)
+	}
+}
+
+// newJobID returns a random id for a job.
+func newJobID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// progressReader counts the bytes read from a reader.
+type progressReader struct {
+	r    io.Reader
+	read *atomic.Int64
+}
+
+// Read reads from the underlying reader, counting the bytes read.
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read.Add(int64(n))
+	return n, err
+}
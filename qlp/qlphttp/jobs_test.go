@@ -0,0 +1,196 @@
+package qlphttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func submitJob(t *testing.T, handler http.Handler, log string) jobStatus {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(log)))
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+
+	var status jobStatus
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	assert.Equal(t, "jobs/"+status.ID, recorder.Header().Get("Location"))
+	return status
+}
+
+// waitForJob polls the status of the job until it is no longer queued or running.
+func waitForJob(t *testing.T, handler http.Handler, id string) jobStatus {
+	t.Helper()
+	for {
+		response := get(t, handler, "/jobs/"+id)
+		assert.Equal(t, http.StatusOK, response.Code)
+		var status jobStatus
+		assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &status))
+		if status.Status != jobQueued && status.Status != jobRunning {
+			return status
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Jobs: true, JobDir: t.TempDir()})
+
+	submitted := submitJob(t, handler, uploadLog+uploadLog)
+	status := waitForJob(t, handler, submitted.ID)
+	assert.Equal(t, jobStatus{
+		ID: submitted.ID, Status: jobDone, Progress: 1, Matches: 2, Result: submitted.ID + "/result",
+	}, status)
+
+	response := get(t, handler, "/jobs/"+status.Result)
+	assert.Equal(t, http.StatusOK, response.Code)
+	var matches map[string]qlp.Match
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &matches))
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "q3dm17", matches["game_2"].MapName)
+
+	submitted = submitJob(t, handler, "not a log\n")
+	status = waitForJob(t, handler, submitted.ID)
+	assert.Equal(t, jobFailed, status.Status)
+	assert.NotEmpty(t, status.Error)
+	assert.Equal(t, http.StatusUnprocessableEntity, get(t, handler, "/jobs/"+submitted.ID+"/result").Code)
+
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/jobs/unknown").Code)
+}
+
+func TestJobsMounted(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Jobs: true, JobDir: t.TempDir()})))
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(uploadLog))
+	mux.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	var status jobStatus
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	location, err := request.URL.Parse(recorder.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/jobs/"+status.ID, location.Path)
+
+	// the result link of the status resolves under the prefix too
+	for status.Status != jobDone {
+		recorder = httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, location.String(), nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+		if status.Status == jobFailed {
+			t.Fatal(status.Error)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	result, err := location.Parse(status.Result)
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/jobs/"+status.ID+"/result", result.Path)
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, result.String(), nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var matches map[string]qlp.Match
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &matches))
+	assert.Len(t, matches, 1)
+}
+
+func TestJobsRetention(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{
+		Jobs: true, JobDir: t.TempDir(), JobRetention: time.Millisecond,
+	})
+	submitted := submitJob(t, handler, uploadLog)
+	assert.Eventually(t, func() bool {
+		return get(t, handler, "/jobs/"+submitted.ID).Code == http.StatusNotFound
+	}, time.Second, time.Millisecond)
+}
+
+func TestJobsLimits(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Jobs: true, JobDir: t.TempDir(), MaxJobs: 2})
+	var ids []string
+	for range 3 {
+		submitted := submitJob(t, handler, uploadLog)
+		waitForJob(t, handler, submitted.ID)
+		ids = append(ids, submitted.ID)
+	}
+	// the job which finished first is forgotten
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/jobs/"+ids[0]).Code)
+	assert.Equal(t, http.StatusOK, get(t, handler, "/jobs/"+ids[1]).Code)
+	assert.Equal(t, http.StatusOK, get(t, handler, "/jobs/"+ids[2]).Code)
+
+	handler = NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Jobs: true, JobDir: t.TempDir(), MaxJobDisk: 16})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(uploadLog)))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Retry-After"))
+}
+
+func TestJobsResultDisk(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Jobs: true, JobDir: t.TempDir()})
+	submitted := submitJob(t, handler, uploadLog)
+	waitForJob(t, handler, submitted.ID)
+	size := int64(get(t, handler, "/jobs/"+submitted.ID+"/result").Body.Len())
+
+	// results are kept on disk, the job which finished first being forgotten to make room
+	dir := t.TempDir()
+	handler = NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Jobs: true, JobDir: dir, MaxResultDisk: size * 3 / 2})
+	var ids []string
+	for range 2 {
+		submitted := submitJob(t, handler, uploadLog)
+		waitForJob(t, handler, submitted.ID)
+		ids = append(ids, submitted.ID)
+	}
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/jobs/"+ids[0]).Code)
+	assert.Equal(t, http.StatusOK, get(t, handler, "/jobs/"+ids[1]+"/result").Code)
+	results, err := filepath.Glob(filepath.Join(dir, "qlp-result-*"))
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// jobs whose matches alone take more fail
+	handler = NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Jobs: true, JobDir: t.TempDir(), MaxResultDisk: size / 2})
+	submitted = submitJob(t, handler, uploadLog)
+	status := waitForJob(t, handler, submitted.ID)
+	assert.Equal(t, jobFailed, status.Status)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, get(t, handler, "/jobs/"+submitted.ID+"/result").Code)
+}
+
+func TestJobsQueue(t *testing.T) {
+	q := newJobQueue(HandlerOptions{Jobs: true, JobDir: t.TempDir(), JobConcurrency: 1, MaxQueuedJobs: 2})
+	assert.Equal(t, int64(DefaultMaxJobDisk), q.maxDisk)
+	handler := http.HandlerFunc(q.submit)
+	// the job being parsed holds the only slot until the test is done with the queue
+	q.mu.Lock()
+	q.running = 1
+	q.mu.Unlock()
+
+	submit := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(uploadLog)))
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusAccepted, submit())
+	assert.Equal(t, http.StatusAccepted, submit())
+	assert.Equal(t, http.StatusServiceUnavailable, submit())
+	q.mu.Lock()
+	assert.Len(t, q.queued, 2)
+	assert.Equal(t, 1, q.running)
+	q.mu.Unlock()
+
+	// once the slot is free, the queued jobs are parsed one at a time
+	q.mu.Lock()
+	q.running--
+	q.start()
+	q.mu.Unlock()
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.queued) == 0 && q.running == 0 && q.disk == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, http.StatusAccepted, submit())
+}
@@ -0,0 +1,191 @@
+package qlphttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// Defaults of the upload options of HandlerOptions.
+const (
+	DefaultMaxUploadSize = 64 << 20 // 64 MiB
+	DefaultParseTimeout  = 30 * time.Second
+)
+
+// HandlerOptions holds the options for NewHandlerWithOptions.
+type HandlerOptions struct {
+	// Upload enables POST /parse, which parses the log uploaded in the request.
+	Upload bool
+	// ParseOptions are the Options uploaded logs are parsed with.
+	ParseOptions qlp.Options
+	// MaxUploadSize is the size of the largest log accepted, in bytes, once decompressed.
+	// It is DefaultMaxUploadSize if not positive.
+	MaxUploadSize int64
+	// ParseTimeout is how long uploading and parsing a log with POST /parse may take. It is
+	// DefaultParseTimeout if not positive.
+	ParseTimeout time.Duration
+
+	// Jobs enables POST /jobs, which parses the log uploaded in the request in the background,
+	// and GET /jobs/{id}, which follows its progress.
+	Jobs bool
+	// JobConcurrency is how many jobs are parsed at a time. It is DefaultJobConcurrency if
+	// not positive.
+	JobConcurrency int
+	// JobRetention is how long the results of jobs are kept once parsed. It is
+	// DefaultJobRetention if not positive.
+	JobRetention time.Duration
+	// MaxJobs is the most finished jobs kept, the ones which finished first being forgotten
+	// first. There is no limit if it is not positive.
+	MaxJobs int
+	// MaxQueuedJobs is the most jobs waiting to be parsed, new jobs being refused with 503
+	// Service Unavailable beyond it. It is DefaultMaxQueuedJobs if not positive.
+	MaxQueuedJobs int
+	// MaxJobDisk is the most bytes the logs of jobs waiting to be parsed may take on disk, new
+	// jobs being refused with 503 Service Unavailable beyond it. It is DefaultMaxJobDisk if
+	// not positive.
+	MaxJobDisk int64
+	// MaxResultDisk is the most bytes the matches of jobs may take on disk, the jobs which
+	// finished first being forgotten first beyond it, and jobs whose matches alone take more
+	// failing. It is DefaultMaxResultDisk if not positive.
+	MaxResultDisk int64
+	// JobDir is the directory the logs of jobs are saved to until parsed, and their matches
+	// until forgotten, the default directory for temporary files if empty.
+	JobDir string
+}
+
+// errUploadTooLarge is returned by the reader of an upload once it is larger than allowed.
+var errUploadTooLarge = errors.New("log too large")
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// parseUpload serves POST /parse: it parses the log in the body of the request, either as the
+// body itself or as the first file of a multipart/form-data body, and responds with its
+// matches. Gzipped logs are told by their first bytes and decompressed.
+func parseUpload(opts HandlerOptions) http.HandlerFunc {
+	maxSize := opts.MaxUploadSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxUploadSize
+	}
+	timeout := opts.ParseTimeout
+	if timeout <= 0 {
+		timeout = DefaultParseTimeout
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		// a client which stops sending would leave the reads of the body blocked past the
+		// timeout, so the connection is given a deadline and the body is closed once it passes
+		http.NewResponseController(w).SetReadDeadline(time.Now().Add(timeout))
+		body := r.Body
+		defer context.AfterFunc(ctx, func() { body.Close() })()
+
+		log, err := uploadedLog(w, r, maxSize)
+		if err != nil {
+			writeUploadError(ctx, w, err)
+			return
+		}
+		matches, err := qlp.NewParser(opts.ParseOptions).Parse(&uploadReader{ctx: ctx, r: log, left: maxSize})
+		if err != nil {
+			writeUploadError(ctx, w, err)
+			return
+		}
+		if matches == nil {
+			matches = qlp.Matches{}
+		}
+		writeJSON(w, matches)
+	}
+}
+
+// uploadedLog returns the log uploaded in the request, decompressed if gzipped. The body read
+// is limited to maxSize, as compressed logs are smaller than the logs themselves.
+func uploadedLog(w http.ResponseWriter, r *http.Request, maxSize int64) (io.Reader, error) {
+	body, err := uploadedBody(w, r, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(body)
+}
+
+// uploadedBody returns the log uploaded in the request as it was sent, either the body itself
+// or the first file of a multipart/form-data body. The body read is limited to maxSize.
+func uploadedBody(w http.ResponseWriter, r *http.Request, maxSize int64) (io.Reader, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("no file in the form")
+		} else if err != nil {
+			return nil, err
+		}
+		if part.FileName() != "" {
+			return part, nil
+		}
+	}
+}
+
+// decompress returns the log read from r, decompressed if it is gzipped, as told by its first
+// bytes.
+func decompress(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	if magic, _ := buffered.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return buffered, nil
+	}
+	return gzip.NewReader(buffered)
+}
+
+// writeUploadError responds to a POST /parse request, whose context is ctx, which failed with
+// err.
+func writeUploadError(ctx context.Context, w http.ResponseWriter, err error) {
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.Is(err, errUploadTooLarge) || errors.As(err, &maxBytes):
+		http.Error(w, errUploadTooLarge.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errResultTooLarge):
+		http.Error(w, errResultTooLarge.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded):
+		http.Error(w, "parsing took too long", http.StatusRequestTimeout)
+	default:
+		http.Error(w, fmt.Sprintf("failed to parse log: %s", err), http.StatusUnprocessableEntity)
+	}
+}
+
+// uploadReader reads an uploaded log, failing with errUploadTooLarge once more than left bytes
+// are read and with the error of its context once it is done.
+type uploadReader struct {
+	ctx  context.Context
+	r    io.Reader
+	left int64
+}
+
+// Read reads from the uploaded log, unless it is too large or the context is done.
+func (u *uploadReader) Read(p []byte) (int, error) {
+	if err := u.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := u.r.Read(p)
+	if u.left -= int64(n); u.left < 0 {
+		return 0, errUploadTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,151 @@
+package qlphttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+const uploadLog = "  0:00 InitGame: \\mapname\\q3dm17\n" +
+	"  0:10 Kill: 2 3 6: Isgalamido killed Zeh by MOD_ROCKET\n" +
+	"  0:20 Exit: Fraglimit hit.\n" +
+	"  0:20 ------------------------------------------------------------\n"
+
+func post(t *testing.T, handler http.Handler, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/parse", body)
+	request.Header.Set("Content-Type", contentType)
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestParseUpload(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Upload: true})
+	assertParsed := func(response *httptest.ResponseRecorder) {
+		t.Helper()
+		assert.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		var matches map[string]qlp.Match
+		assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &matches))
+		assert.Equal(t, "q3dm17", matches["game_1"].MapName)
+		assert.Equal(t, 1, matches["game_1"].TotalKills)
+	}
+
+	assertParsed(post(t, handler, "text/plain", strings.NewReader(uploadLog)))
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(uploadLog))
+	gz.Close()
+	assertParsed(post(t, handler, "application/gzip", &gzipped))
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	writer.WriteField("comment", "last night")
+	file, _ := writer.CreateFormFile("log", "games.log")
+	file.Write([]byte(uploadLog))
+	writer.Close()
+	assertParsed(post(t, handler, writer.FormDataContentType(), &form))
+
+	response := post(t, handler, "text/plain", strings.NewReader("not a log\n"))
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+}
+
+func TestParseUploadTooLarge(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Upload: true, MaxUploadSize: 64})
+	response := post(t, handler, "text/plain", strings.NewReader(uploadLog))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+
+	// the limit holds once decompressed
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(strings.Repeat(uploadLog, 1000)))
+	gz.Close()
+	assert.Less(t, gzipped.Len(), 1024)
+	handler = NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Upload: true, MaxUploadSize: 1024})
+	response = post(t, handler, "application/gzip", &gzipped)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+}
+
+// slowReader reads one line at a time, waiting before each.
+type slowReader struct {
+	lines []string
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(10 * time.Millisecond)
+	n := copy(p, r.lines[0])
+	r.lines = r.lines[1:]
+	return n, nil
+}
+
+func TestParseUploadTimeout(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Upload: true, ParseTimeout: 15 * time.Millisecond})
+	body := &slowReader{lines: strings.SplitAfter(strings.Repeat(uploadLog, 10), "\n")}
+	response := post(t, handler, "text/plain", body)
+	assert.Equal(t, http.StatusRequestTimeout, response.Code)
+}
+
+// stalledBody sends a line and then blocks, as a client which stops sending, until closed.
+type stalledBody struct {
+	sent   bool
+	closed chan struct{}
+}
+
+func (b *stalledBody) Read(p []byte) (int, error) {
+	if !b.sent {
+		b.sent = true
+		return copy(p, uploadLog), nil
+	}
+	<-b.closed
+	return 0, errors.New("read on closed body")
+}
+
+func (b *stalledBody) Close() error {
+	close(b.closed)
+	return nil
+}
+
+func TestParseUploadStalled(t *testing.T) {
+	handler := NewHandlerWithOptions(&MemoryStore{}, HandlerOptions{Upload: true, ParseTimeout: 20 * time.Millisecond})
+	response := post(t, handler, "text/plain", &stalledBody{closed: make(chan struct{})})
+	assert.Equal(t, http.StatusRequestTimeout, response.Code)
+
+	// over a connection, the read deadline ends the read
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "POST /parse HTTP/1.1\r\nHost: qlp\r\nContent-Length: 100000\r\n\r\n%s", uploadLog)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusRequestTimeout, reply.StatusCode)
+		reply.Body.Close()
+	}
+}
+
+func TestParseUploadDisabled(t *testing.T) {
+	response := post(t, NewHandler(&MemoryStore{}), "text/plain", strings.NewReader(uploadLog))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,66 @@
+// Package qlpstats computes statistics over parsed matches, such as leaderboards, ratings, K/D
+// ratios, kill streaks, unique player estimates and aggregates, which are the reports of the
+// "report" subcommand, so that other Go applications can compute them without running the
+// command.
+package qlpstats
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// Standing is the line of a player in the leaderboard.
+type Standing struct {
+	Rank    int    `json:"rank"`
+	Player  string `json:"player"`
+	Kills   int    `json:"kills"`
+	Matches int    `json:"matches"`
+	// AverageKills is the mean of the player's kills per match played.
+	AverageKills float64 `json:"average_kills"`
+	// BestGame and BestScore are the match number and kills of the player's best game, the
+	// earliest one winning ties. Matches are numbered from 1, as in the "game_N" keys.
+	BestGame  int `json:"best_game"`
+	BestScore int `json:"best_score"`
+}
+
+// Leaderboard ranks the players by their kills over every match, as counted in Match.Kills,
+// ties being broken by name. Players with the same kills share a rank.
+func Leaderboard(matches qlp.Matches) []Standing {
+	byPlayer := make(map[string]*Standing)
+	for i, match := range matches {
+		for _, player := range match.Players {
+			kills := match.Kills[player]
+			s := byPlayer[player]
+			if s == nil {
+				s = &Standing{Player: player, BestGame: i + 1, BestScore: kills}
+				byPlayer[player] = s
+			}
+
+			s.Kills += kills
+			s.Matches++
+			if kills > s.BestScore {
+				s.BestGame, s.BestScore = i+1, kills
+			}
+		}
+	}
+
+	standings := make([]Standing, 0, len(byPlayer))
+	for _, s := range byPlayer {
+		s.AverageKills = float64(s.Kills) / float64(s.Matches)
+		standings = append(standings, *s)
+	}
+	slices.SortFunc(standings, func(a, b Standing) int {
+		return cmp.Or(cmp.Compare(b.Kills, a.Kills), cmp.Compare(a.Player, b.Player))
+	})
+
+	for i := range standings {
+		if i > 0 && standings[i].Kills == standings[i-1].Kills {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings
+}
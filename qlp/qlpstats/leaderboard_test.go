@@ -0,0 +1,22 @@
+package qlpstats
+
+import (
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboard(t *testing.T) {
+	matches := qlp.Matches{
+		{Players: []string{"Isgalamido", "Mocinha"}, Kills: map[string]int{"Isgalamido": 3, "Mocinha": 1}},
+		{Players: []string{"Mocinha", "Zeh"}, Kills: map[string]int{"Mocinha": 2, "Zeh": 3}},
+	}
+	assert.Equal(t, []Standing{
+		{Rank: 1, Player: "Isgalamido", Kills: 3, Matches: 1, AverageKills: 3, BestGame: 1, BestScore: 3},
+		{Rank: 1, Player: "Mocinha", Kills: 3, Matches: 2, AverageKills: 1.5, BestGame: 2, BestScore: 2},
+		{Rank: 1, Player: "Zeh", Kills: 3, Matches: 1, AverageKills: 3, BestGame: 2, BestScore: 3},
+	}, Leaderboard(matches))
+
+	assert.Empty(t, Leaderboard(nil))
+}
@@ -0,0 +1,86 @@
+package qlpstats
+
+import (
+	"math"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+const (
+	// InitialRating is the rating of players before their first match.
+	InitialRating = 1500
+	// RatingFactor is the most a player's rating can change in a single match.
+	RatingFactor = 32
+)
+
+// Ratings computes the Elo rating of every player after the given matches, in order, keyed by
+// name. Each match counts as a duel between every pair of its players, won by the one with
+// more kills in Match.Kills, and the change of a player's rating is the average of their
+// duels, so that it does not grow with the size of the match. Matches of a single player are
+// not rated.
+func Ratings(matches qlp.Matches) map[string]float64 {
+	ratings := make(map[string]float64)
+	for _, match := range matches {
+		rateMatch(ratings, match)
+	}
+	return ratings
+}
+
+// RatingPoint is the rating of a player after a match.
+type RatingPoint struct {
+	Match  int     `json:"match"` // 1-indexed, as in the "game_N" keys
+	Rating float64 `json:"rating"`
+}
+
+// RatingHistory computes, for every player, keyed by name, their rating after each match
+// they played, rated as by Ratings, so that the ratings can be plotted over time.
+func RatingHistory(matches qlp.Matches) map[string][]RatingPoint {
+	ratings := make(map[string]float64)
+	history := make(map[string][]RatingPoint)
+	for i, match := range matches {
+		if !rateMatch(ratings, match) {
+			continue
+		}
+		for _, player := range match.Players {
+			history[player] = append(history[player], RatingPoint{Match: i + 1, Rating: ratings[player]})
+		}
+	}
+	return history
+}
+
+// rateMatch updates ratings with the result of the match. It reports false if the match is
+// not rated.
+func rateMatch(ratings map[string]float64, match qlp.Match) bool {
+	if len(match.Players) < 2 {
+		return false
+	}
+	for _, player := range match.Players {
+		if _, ok := ratings[player]; !ok {
+			ratings[player] = InitialRating
+		}
+	}
+
+	players := match.Players
+	changes := make([]float64, len(players))
+	for i, a := range players {
+		for j := i + 1; j < len(players); j++ {
+			b := players[j]
+			expected := 1 / (1 + math.Pow(10, (ratings[b]-ratings[a])/400))
+			actual := 0.5
+			switch {
+			case match.Kills[a] > match.Kills[b]:
+				actual = 1
+			case match.Kills[a] < match.Kills[b]:
+				actual = 0
+			}
+
+			change := RatingFactor * (actual - expected) / float64(len(players)-1)
+			changes[i] += change
+			changes[j] -= change
+		}
+	}
+	for i, player := range players {
+		ratings[player] += changes[i]
+	}
+	return true
+}
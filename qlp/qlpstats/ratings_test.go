@@ -0,0 +1,46 @@
+package qlpstats
+
+import (
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatings(t *testing.T) {
+	matches := qlp.Matches{
+		{Players: []string{"Isgalamido", "Mocinha"}, Kills: map[string]int{"Isgalamido": 3, "Mocinha": 1}},
+		{Players: []string{"Zeh"}, Kills: map[string]int{"Zeh": 5}},
+	}
+	ratings := Ratings(matches)
+	assert.Equal(t, map[string]float64{"Isgalamido": 1516, "Mocinha": 1484}, ratings)
+
+	// a draw between equals changes nothing, and three players split the change of each duel
+	matches = qlp.Matches{
+		{Players: []string{"Isgalamido", "Mocinha", "Zeh"}, Kills: map[string]int{"Isgalamido": 3, "Mocinha": 3, "Zeh": 0}},
+	}
+	assert.Equal(t, map[string]float64{"Isgalamido": 1508, "Mocinha": 1508, "Zeh": 1484}, Ratings(matches))
+
+	assert.Empty(t, Ratings(nil))
+}
+
+func TestRatingHistory(t *testing.T) {
+	matches := qlp.Matches{
+		{Players: []string{"Isgalamido", "Mocinha"}, Kills: map[string]int{"Isgalamido": 3, "Mocinha": 1}},
+		{Players: []string{"Zeh"}, Kills: map[string]int{"Zeh": 5}},
+		{Players: []string{"Isgalamido", "Zeh"}, Kills: map[string]int{"Isgalamido": 0, "Zeh": 0}},
+	}
+	history := RatingHistory(matches)
+
+	assert.Equal(t, []RatingPoint{{Match: 1, Rating: 1484}}, history["Mocinha"])
+	assert.Len(t, history["Isgalamido"], 2)
+	assert.Equal(t, RatingPoint{Match: 1, Rating: 1516}, history["Isgalamido"][0])
+	assert.Equal(t, 3, history["Isgalamido"][1].Match)
+	assert.Less(t, history["Isgalamido"][1].Rating, 1516.0, "a draw against a weaker player loses rating")
+	assert.Len(t, history["Zeh"], 1, "matches which are not rated are left out")
+
+	ratings := Ratings(matches)
+	for player, points := range history {
+		assert.Equal(t, ratings[player], points[len(points)-1].Rating)
+	}
+}
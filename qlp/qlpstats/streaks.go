@@ -0,0 +1,38 @@
+package qlpstats
+
+import "github.com/agstrc/qlp/qlp"
+
+// Streak is the longest kill streak of a player: the most kills they scored in a match
+// without dying in between.
+type Streak struct {
+	Kills int `json:"kills"`
+	// Match is the number of the match the streak happened in, the earliest one winning ties.
+	Match int `json:"match"`
+}
+
+// Streaks computes the longest kill streak of every player, keyed by name. Kills exclude
+// suicides and deaths by the world, which still end the victim's streak; streaks end with
+// their match. It needs the kill feed of the matches.
+func Streaks(matches qlp.Matches) map[string]Streak {
+	longest := make(map[string]Streak)
+	for i, match := range matches {
+		for _, player := range match.Players {
+			if _, ok := longest[player]; !ok {
+				longest[player] = Streak{Match: i + 1}
+			}
+		}
+
+		current := make(map[string]int)
+		for _, kill := range match.KillFeed {
+			current[kill.Victim] = 0
+			if !isFrag(kill) {
+				continue
+			}
+			current[kill.Killer]++
+			if current[kill.Killer] > longest[kill.Killer].Kills {
+				longest[kill.Killer] = Streak{Kills: current[kill.Killer], Match: i + 1}
+			}
+		}
+	}
+	return longest
+}
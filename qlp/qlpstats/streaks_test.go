@@ -0,0 +1,29 @@
+package qlpstats
+
+import (
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreaks(t *testing.T) {
+	matches := qlp.Matches{
+		{Players: []string{"Isgalamido", "Mocinha"}, KillFeed: []qlp.Kill{
+			{Killer: "Isgalamido", Victim: "Mocinha"},
+			{Killer: "Isgalamido", Victim: "Mocinha"},
+			{Killer: "<world>", Victim: "Isgalamido"},
+			{Killer: "Isgalamido", Victim: "Mocinha"},
+		}},
+		{Players: []string{"Isgalamido", "Mocinha"}, KillFeed: []qlp.Kill{
+			{Killer: "Mocinha", Victim: "Isgalamido"},
+			{Killer: "Mocinha", Victim: "Mocinha"},
+			{Killer: "Isgalamido", Victim: "Mocinha"},
+			{Killer: "Isgalamido", Victim: "Mocinha"},
+		}},
+	}
+	assert.Equal(t, map[string]Streak{
+		"Isgalamido": {Kills: 2, Match: 1},
+		"Mocinha":    {Kills: 1, Match: 2},
+	}, Streaks(matches))
+}
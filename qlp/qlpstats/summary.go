@@ -0,0 +1,86 @@
+package qlpstats
+
+import (
+	"math"
+	"slices"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// Summary aggregates every match of the logs.
+type Summary struct {
+	Matches       int          `json:"matches"`
+	KillsPerMatch Distribution `json:"kills_per_match"`
+	MatchDuration Distribution `json:"match_duration"`
+	// KillsByWeapon is keyed by means of death.
+	KillsByWeapon map[string]Distribution `json:"kills_by_weapon"`
+}
+
+// Distribution describes a sample by its totals and percentiles, which compare more honestly
+// than totals alone when a few outliers are present. Percentiles use the nearest rank method.
+type Distribution struct {
+	Total  int     `json:"total"`
+	Mean   float64 `json:"mean"`
+	Min    int     `json:"min"`
+	P25    int     `json:"p25"`
+	Median int     `json:"median"`
+	P75    int     `json:"p75"`
+	P90    int     `json:"p90"`
+	Max    int     `json:"max"`
+}
+
+// Summarize aggregates the matches. Kills by weapon count, per match, the kills of each means
+// of death, matches without any such kill counting as zero.
+func Summarize(matches qlp.Matches) Summary {
+	kills := make([]int, 0, len(matches))
+	durations := make([]int, 0, len(matches))
+	byWeapon := make(map[string][]int)
+	for i, match := range matches {
+		kills = append(kills, match.TotalKills)
+		durations = append(durations, match.Duration)
+		for means, count := range match.KillsByMeans {
+			if byWeapon[means] == nil {
+				byWeapon[means] = make([]int, len(matches))
+			}
+			byWeapon[means][i] = count
+		}
+	}
+
+	s := Summary{
+		Matches:       len(matches),
+		KillsPerMatch: DistributionOf(kills),
+		MatchDuration: DistributionOf(durations),
+		KillsByWeapon: make(map[string]Distribution, len(byWeapon)),
+	}
+	for means, counts := range byWeapon {
+		s.KillsByWeapon[means] = DistributionOf(counts)
+	}
+	return s
+}
+
+// DistributionOf describes values, which it sorts in place.
+func DistributionOf(values []int) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	slices.Sort(values)
+
+	var d Distribution
+	for _, value := range values {
+		d.Total += value
+	}
+	d.Mean = float64(d.Total) / float64(len(values))
+	d.Min, d.Max = values[0], values[len(values)-1]
+	d.P25 = percentile(values, 25)
+	d.Median = percentile(values, 50)
+	d.P75 = percentile(values, 75)
+	d.P90 = percentile(values, 90)
+	return d
+}
+
+// percentile returns the p-th percentile of the sorted, non-empty values by the nearest rank
+// method.
+func percentile(sorted []int, p float64) int {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,29 @@
+package qlpstats
+
+import (
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	matches := qlp.Matches{
+		{TotalKills: 4, Duration: 60, KillsByMeans: map[string]int{"MOD_ROCKET": 4}},
+		{TotalKills: 0, Duration: 30},
+	}
+	summary := Summarize(matches)
+	assert.Equal(t, 2, summary.Matches)
+	assert.Equal(t, Distribution{Total: 4, Mean: 2, Min: 0, P25: 0, Median: 0, P75: 4, P90: 4, Max: 4}, summary.KillsPerMatch)
+	assert.Equal(t, summary.KillsPerMatch, summary.KillsByWeapon["MOD_ROCKET"])
+	assert.Equal(t, 90, summary.MatchDuration.Total)
+}
+
+func TestDistributionOf(t *testing.T) {
+	assert.Equal(t, Distribution{}, DistributionOf(nil))
+
+	d := DistributionOf([]int{10, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	assert.Equal(t, 55, d.Total)
+	assert.Equal(t, 5.5, d.Mean)
+	assert.Equal(t, []int{1, 3, 5, 8, 9, 10}, []int{d.Min, d.P25, d.Median, d.P75, d.P90, d.Max})
+}
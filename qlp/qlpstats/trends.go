@@ -0,0 +1,90 @@
+package qlpstats
+
+import "github.com/agstrc/qlp/qlp"
+
+// TrendWindow is the number of matches in the moving average of the K/D ratio.
+const TrendWindow = 3
+
+// Trend follows the K/D ratio of a player over successive matches.
+type Trend struct {
+	Games []TrendGame `json:"games"`
+	// Best and Worst are the match numbers of the games with the highest and lowest K/D
+	// ratios, the earliest game winning ties.
+	Best  int `json:"best"`
+	Worst int `json:"worst"`
+}
+
+// TrendGame is the performance of a player in a single match.
+type TrendGame struct {
+	Match  int     `json:"match"` // 1-indexed, as in the "game_N" keys
+	Kills  int     `json:"kills"`
+	Deaths int     `json:"deaths"`
+	KD     float64 `json:"kd"`
+	// MovingAverage is the mean K/D ratio of this game and up to TrendWindow-1 games before it.
+	MovingAverage float64 `json:"moving_average"`
+}
+
+// Trends computes the trend of every player, keyed by name. Kills exclude suicides and deaths
+// by the world, while deaths include them. It needs the kill feed of the matches.
+func Trends(matches qlp.Matches) map[string]*Trend {
+	byPlayer := make(map[string]*Trend)
+	for i, match := range matches {
+		kills := make(map[string]int)
+		deaths := make(map[string]int)
+		for _, kill := range match.KillFeed {
+			deaths[kill.Victim]++
+			if isFrag(kill) {
+				kills[kill.Killer]++
+			}
+		}
+
+		for _, player := range match.Players {
+			t := byPlayer[player]
+			if t == nil {
+				t = &Trend{}
+				byPlayer[player] = t
+			}
+			t.Games = append(t.Games, TrendGame{
+				Match:  i + 1,
+				Kills:  kills[player],
+				Deaths: deaths[player],
+				KD:     KDRatio(kills[player], deaths[player]),
+			})
+		}
+	}
+
+	for _, t := range byPlayer {
+		best, worst := t.Games[0], t.Games[0]
+		for i := range t.Games {
+			game := &t.Games[i]
+			window := t.Games[max(0, i-TrendWindow+1) : i+1]
+			for _, previous := range window {
+				game.MovingAverage += previous.KD
+			}
+			game.MovingAverage /= float64(len(window))
+
+			if game.KD > best.KD {
+				best = *game
+			}
+			if game.KD < worst.KD {
+				worst = *game
+			}
+		}
+		t.Best, t.Worst = best.Match, worst.Match
+	}
+	return byPlayer
+}
+
+// KDRatio returns kills divided by deaths, or kills itself if there are no deaths.
+func KDRatio(kills, deaths int) float64 {
+	if deaths == 0 {
+		return float64(kills)
+	}
+	return float64(kills) / float64(deaths)
+}
+
+// isFrag reports whether the kill counts for its killer, which suicides and deaths by the
+// world do not.
+func isFrag(kill qlp.Kill) bool {
+	return kill.Killer != "<world>" && kill.Killer != kill.Victim
+}
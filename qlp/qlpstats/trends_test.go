@@ -0,0 +1,34 @@
+package qlpstats
+
+import (
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrends(t *testing.T) {
+	matches := qlp.Matches{
+		{Players: []string{"Isgalamido"}, KillFeed: []qlp.Kill{
+			{Killer: "Isgalamido", Victim: "Mocinha"},
+			{Killer: "Isgalamido", Victim: "Mocinha"},
+			{Killer: "<world>", Victim: "Isgalamido"},
+		}},
+		{Players: []string{"Isgalamido"}, KillFeed: []qlp.Kill{
+			{Killer: "Isgalamido", Victim: "Isgalamido"},
+		}},
+	}
+	assert.Equal(t, &Trend{
+		Games: []TrendGame{
+			{Match: 1, Kills: 2, Deaths: 1, KD: 2, MovingAverage: 2},
+			{Match: 2, Kills: 0, Deaths: 1, KD: 0, MovingAverage: 1},
+		},
+		Best:  1,
+		Worst: 2,
+	}, Trends(matches)["Isgalamido"])
+}
+
+func TestKDRatio(t *testing.T) {
+	assert.Equal(t, 3.0, KDRatio(3, 0))
+	assert.Equal(t, 0.5, KDRatio(1, 2))
+}
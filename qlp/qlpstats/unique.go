@@ -0,0 +1,73 @@
+package qlpstats
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// UniquePlayers estimates how many people played under the names of the matches.
+type UniquePlayers struct {
+	// Names is the number of distinct player names.
+	Names     int `json:"names"`
+	Estimated int `json:"estimated_unique_players"`
+	// People lists the names of each person, sorted, in order of their first name.
+	People [][]string `json:"people"`
+}
+
+// EstimateUniquePlayers groups the names of the players of the matches by the people behind
+// them: names which connected from the same IP address, in any match, are taken to be the same
+// person, and so are, through them, every name sharing an address with any of those. It needs
+// Match.PlayerIPs; without addresses, each name is a person of its own. Players sharing an
+// address, such as at a LAN party or behind the same NAT, are taken to be one person, so the
+// estimate errs on the low side.
+func EstimateUniquePlayers(matches qlp.Matches) UniquePlayers {
+	// parent links each name to another name of the same person, up to the name representing
+	// them, which is its own parent
+	parent := make(map[string]string)
+	var find func(name string) string
+	find = func(name string) string {
+		if parent[name] == name {
+			return name
+		}
+		root := find(parent[name])
+		parent[name] = root
+		return root
+	}
+
+	byIP := make(map[string]string) // the first name seen at each address
+	for _, match := range matches {
+		for _, player := range match.Players {
+			if _, ok := parent[player]; !ok {
+				parent[player] = player
+			}
+		}
+		for _, player := range slices.Sorted(maps.Keys(match.PlayerIPs)) {
+			if _, ok := parent[player]; !ok {
+				parent[player] = player
+			}
+			ip := match.PlayerIPs[player]
+			first, ok := byIP[ip]
+			if !ok {
+				byIP[ip] = player
+				continue
+			}
+			// the first name in order represents the person
+			if a, b := find(first), find(player); a != b {
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	people := make(map[string][]string)
+	for name := range parent {
+		root := find(name)
+		people[root] = append(people[root], name)
+	}
+	result := UniquePlayers{Names: len(parent), Estimated: len(people), People: [][]string{}}
+	for _, root := range slices.Sorted(maps.Keys(people)) {
+		result.People = append(result.People, slices.Sorted(slices.Values(people[root])))
+	}
+	return result
+}
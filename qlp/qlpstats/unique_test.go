@@ -0,0 +1,33 @@
+package qlpstats
+
+import (
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateUniquePlayers(t *testing.T) {
+	matches := qlp.Matches{
+		{
+			Players:   []string{"Isgalamido", "Isga", "Zeh", "Mocinha"},
+			PlayerIPs: map[string]string{"Isgalamido": "10.0.0.2", "Isga": "10.0.0.2", "Zeh": "10.0.0.3"},
+		},
+		{
+			Players:   []string{"xXZehXx", "Dono da Bola"},
+			PlayerIPs: map[string]string{"xXZehXx": "10.0.0.3", "Dono da Bola": "10.0.0.4"},
+		},
+		{
+			// the address links the names of both earlier matches
+			Players:   []string{"Dono da Bola"},
+			PlayerIPs: map[string]string{"Dono da Bola": "10.0.0.2"},
+		},
+	}
+	assert.Equal(t, UniquePlayers{
+		Names:     6,
+		Estimated: 3,
+		People:    [][]string{{"Dono da Bola", "Isga", "Isgalamido"}, {"Mocinha"}, {"Zeh", "xXZehXx"}},
+	}, EstimateUniquePlayers(matches))
+
+	assert.Equal(t, UniquePlayers{People: [][]string{}}, EstimateUniquePlayers(nil))
+}
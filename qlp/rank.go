@@ -0,0 +1,56 @@
+package qlp
+
+import (
+	"cmp"
+	"slices"
+)
+
+// PlayerRank is the line of a player in the ranking computed by Rank.
+type PlayerRank struct {
+	Rank   int    `json:"rank"`
+	Player string `json:"player"`
+	// Kills counts the players the player killed, leaving out suicides.
+	Kills int `json:"kills"`
+	// Deaths counts every death of the player, including suicides and deaths by the world,
+	// which WorldDeaths counts on their own.
+	Deaths      int `json:"deaths"`
+	WorldDeaths int `json:"world_deaths"`
+	Matches     int `json:"matches"`
+}
+
+// Rank aggregates the kills, deaths and deaths by the world of every player over every match,
+// from Match.Frags, Match.Deaths and Match.DeathsByWorld, and ranks the players by kills, fewer
+// deaths breaking ties and then the name. Players with the same kills and deaths share a rank.
+func Rank(matches Matches) []PlayerRank {
+	byPlayer := make(map[string]*PlayerRank)
+	for _, match := range matches {
+		for _, player := range match.Players {
+			r := byPlayer[player]
+			if r == nil {
+				r = &PlayerRank{Player: player}
+				byPlayer[player] = r
+			}
+			r.Matches++
+			r.Kills += match.Frags[player]
+			r.Deaths += match.Deaths[player]
+			r.WorldDeaths += match.DeathsByWorld[player]
+		}
+	}
+
+	ranking := make([]PlayerRank, 0, len(byPlayer))
+	for _, r := range byPlayer {
+		ranking = append(ranking, *r)
+	}
+	slices.SortFunc(ranking, func(a, b PlayerRank) int {
+		return cmp.Or(cmp.Compare(b.Kills, a.Kills), cmp.Compare(a.Deaths, b.Deaths), cmp.Compare(a.Player, b.Player))
+	})
+
+	for i := range ranking {
+		if i > 0 && ranking[i].Kills == ranking[i-1].Kills && ranking[i].Deaths == ranking[i-1].Deaths {
+			ranking[i].Rank = ranking[i-1].Rank
+		} else {
+			ranking[i].Rank = i + 1
+		}
+	}
+	return ranking
+}
@@ -0,0 +1,46 @@
+package qlp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRank(t *testing.T) {
+	// the kill feed is not needed, so the matches are parsed with the default options
+	log := "  0:00 InitGame:\n" +
+		"  0:01 Kill: 2 3 7: Isgalamido killed Mocinha by MOD_ROCKET_SPLASH\n" +
+		"  0:02 Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT\n" +
+		"  0:03 Kill: 4 3 10: Zeh killed Mocinha by MOD_RAILGUN\n" +
+		"  0:04 " + matchSeparator + "\n" +
+		"  0:05 InitGame:\n" +
+		"  0:06 Kill: 3 3 7: Mocinha killed Mocinha by MOD_ROCKET_SPLASH\n" +
+		"  0:07 Kill: 3 2 10: Mocinha killed Isgalamido by MOD_RAILGUN\n" +
+		"  0:08 " + matchSeparator + "\n"
+	matches, err := ParseLog(strings.NewReader(log))
+	assert.NoError(t, err)
+	assert.Nil(t, matches[0].KillFeed)
+
+	assert.Equal(t, []PlayerRank{
+		{Rank: 1, Player: "Zeh", Kills: 1, Deaths: 0, Matches: 1},
+		{Rank: 2, Player: "Isgalamido", Kills: 1, Deaths: 2, WorldDeaths: 1, Matches: 2},
+		{Rank: 3, Player: "Mocinha", Kills: 1, Deaths: 3, Matches: 2},
+	}, Rank(matches))
+}
+
+func TestMatchDeaths(t *testing.T) {
+	log := "  0:00 InitGame:\n" +
+		"  0:01 Kill: 2 3 7: Isgalamido killed Mocinha by MOD_ROCKET_SPLASH\n" +
+		"  0:02 Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT\n" +
+		"  0:03 Kill: 3 3 7: Mocinha killed Mocinha by MOD_ROCKET_SPLASH\n" +
+		"  0:04 " + matchSeparator + "\n"
+
+	for _, mode := range []WorldDeathMode{WorldDeathsDecrement, WorldDeathsCount} {
+		matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{WorldDeaths: mode})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"Isgalamido": 1, "Mocinha": 0}, matches[0].Frags)
+		assert.Equal(t, map[string]int{"Isgalamido": 1, "Mocinha": 2}, matches[0].Deaths)
+		assert.Equal(t, map[string]int{"Isgalamido": 1}, matches[0].DeathsByWorld)
+	}
+}
@@ -0,0 +1,70 @@
+package qlp
+
+import "strings"
+
+// RestartMode controls how matches restarted with map_restart are handled.
+//
+// A map_restart, as issued by admins or at the end of the warmup, makes the server log the end
+// of the match and the start of a new one on the same map without a new clock, so it looks
+// like any other match in the log. The parser tells it apart by the match ending without an
+// Exit event and the next InitGame event coming right after its separator, at the same time
+// and on the same map.
+type RestartMode int
+
+const (
+	// RestartSeparate keeps the restarted match separate from the match it restarts, with
+	// Match.Restart set.
+	RestartSeparate RestartMode = iota
+	// RestartMerge merges the restarted match into the match it restarts, as a single match
+	// counting the restarts in Match.Restarts. The end of a match without an Exit event is
+	// then only passed on once the next event shows it was not restarted.
+	RestartMerge
+)
+
+// endedMatch is a match which ended without an Exit event, and so may be restarted.
+type endedMatch struct {
+	// timestamp is the one of the separator which ended the match.
+	timestamp string
+	mapName   string
+}
+
+// restartedBy reports whether event, the event following the separators which ended the
+// match, restarts it: an InitGame event at the time the match ended and on the same map.
+func (e endedMatch) restartedBy(p *logParser, event string) bool {
+	return strings.HasPrefix(event, "InitGame:") && p.timestamp == e.timestamp &&
+		parseInfoString(strings.TrimPrefix(event, "InitGame:"))["mapname"] == e.mapName
+}
+
+// endedParser is the parser used with RestartMerge after a match ended without an Exit
+// event, until the next event tells whether the match is restarted. The match is held back
+// until then.
+type endedParser struct {
+	endedMatch
+	match *matchParser
+}
+
+// parseEvent resumes the match if the event restarts it, and otherwise passes the match on
+// and looks for the next one. Separators are skipped.
+func (e endedParser) parseEvent(p *logParser, event string) (eventParser, error) {
+	if strings.HasPrefix(event, "---") {
+		// the match has not been passed on yet, so it still gets the line
+		e.match.recordLine(p)
+		return e, nil
+	}
+	if e.restartedBy(p, event) {
+		e.match.hashEvent(p, event)
+		e.match.recordLine(p)
+		e.match.restarts++
+		return e.match, nil
+	}
+
+	if err := e.flush(p); err != nil {
+		return nil, err
+	}
+	return lookingForGameParser{}.parseEvent(p, event)
+}
+
+// flush passes the match which ended on.
+func (e endedParser) flush(p *logParser) error {
+	return p.emitMatch(e.match.match())
+}
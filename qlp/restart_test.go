@@ -0,0 +1,153 @@
+package qlp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// restartLog holds a warmup restarted with map_restart, a match which ends normally and a
+// match on another map which ends without an Exit event.
+const restartLog = "  0:00 InitGame: \\mapname\\q3dm17\n" +
+	"  0:10 Kill: 2 3 6: Isgalamido killed Zeh by MOD_ROCKET\n" +
+	"  0:20 ShutdownGame:\n" +
+	"  0:20 " + matchSeparator + "\n" +
+	"  0:20 " + matchSeparator + "\n" +
+	"  0:20 InitGame: \\mapname\\q3dm17\n" +
+	"  0:30 Kill: 3 2 6: Zeh killed Isgalamido by MOD_ROCKET\n" +
+	"  5:00 Exit: Fraglimit hit.\n" +
+	"  5:10 ShutdownGame:\n" +
+	"  5:10 " + matchSeparator + "\n" +
+	"  5:10 " + matchSeparator + "\n" +
+	"  5:10 InitGame: \\mapname\\q3dm6\n" +
+	"  5:20 ShutdownGame:\n" +
+	"  5:20 " + matchSeparator + "\n" +
+	"  0:00 " + matchSeparator + "\n" +
+	"  0:00 InitGame: \\mapname\\q3dm6\n" +
+	"  0:05 " + matchSeparator + "\n"
+
+func TestRestartSeparate(t *testing.T) {
+	matches, err := ParseLog(strings.NewReader(restartLog))
+	assert.NoError(t, err)
+
+	assert.Len(t, matches, 4)
+	assert.Equal(t, []bool{false, true, false, false}, []bool{
+		matches[0].Restart, matches[1].Restart, matches[2].Restart, matches[3].Restart,
+	})
+	assert.Equal(t, map[string]int{"Isgalamido": 1, "Zeh": 0}, matches[0].Kills)
+	assert.Equal(t, map[string]int{"Isgalamido": 0, "Zeh": 1}, matches[1].Kills)
+}
+
+func TestEventParsingRestart(t *testing.T) {
+	p := newLogParser()
+	p.timestamp = "0:20"
+	p.parseEvent("InitGame: \\mapname\\q3dm17")
+	p.parseEvent(matchSeparator)
+	// the match ended without an Exit event, so the next event may restart it
+	assert.IsType(t, lookingForGameParser{}, p.evParser)
+	assert.Len(t, p.matches, 1)
+	assert.NotNil(t, p.ended)
+	p.parseEvent(matchSeparator)
+	p.parseEvent("InitGame: \\mapname\\q3dm17")
+	assert.True(t, p.evParser.(*matchParser).restart)
+	assert.Nil(t, p.ended)
+
+	// after an Exit event, it is not restarted
+	p.parseEvent("Exit: Fraglimit hit.")
+	p.parseEvent(matchSeparator)
+	assert.Nil(t, p.ended)
+	p.parseEvent("InitGame: \\mapname\\q3dm17")
+	assert.False(t, p.evParser.(*matchParser).restart)
+
+	// nor by an InitGame event later than the separator, or after other events
+	p.parseEvent(matchSeparator)
+	p.timestamp = "0:21"
+	p.parseEvent("InitGame: \\mapname\\q3dm17")
+	assert.False(t, p.evParser.(*matchParser).restart)
+	p.parseEvent(matchSeparator)
+	p.parseEvent("ClientConnect: 2")
+	p.parseEvent("InitGame: \\mapname\\q3dm17")
+	assert.False(t, p.evParser.(*matchParser).restart)
+}
+
+func TestEventParsingRestartMerge(t *testing.T) {
+	p := newLogParser()
+	p.opts.Restarts = RestartMerge
+	p.parseEvent("InitGame: \\mapname\\q3dm17")
+	p.parseEvent(matchSeparator)
+	// the match which ended is held back until the next event
+	assert.IsType(t, endedParser{}, p.evParser)
+	assert.Empty(t, p.matches)
+	p.parseEvent("InitGame: \\mapname\\q3dm17")
+	assert.IsType(t, &matchParser{}, p.evParser)
+	assert.Empty(t, p.matches)
+
+	p.parseEvent(matchSeparator)
+	p.parseEvent("InitGame: \\mapname\\q3dm6")
+	assert.IsType(t, &matchParser{}, p.evParser)
+	if assert.Len(t, p.matches, 1) {
+		assert.Equal(t, 1, p.matches[0].Restarts)
+	}
+}
+
+func TestRestartMerge(t *testing.T) {
+	matches, _, err := ParseLogWithOptions(strings.NewReader(restartLog), Options{Restarts: RestartMerge})
+	assert.NoError(t, err)
+
+	assert.Len(t, matches, 3)
+	assert.Equal(t, 1, matches[0].Restarts)
+	assert.False(t, matches[0].Restart)
+	assert.Equal(t, 2, matches[0].TotalKills)
+	assert.Equal(t, map[string]int{"Isgalamido": 1, "Zeh": 1}, matches[0].Kills)
+	assert.Equal(t, 310, matches[0].Duration)
+	assert.Equal(t, "q3dm6", matches[2].MapName)
+	assert.Zero(t, matches[2].Restarts)
+}
+
+func TestRestartMergeRaw(t *testing.T) {
+	matches, _, err := ParseLogWithOptions(strings.NewReader(restartLog), Options{Restarts: RestartMerge, Raw: true})
+	assert.NoError(t, err)
+
+	// the merged match holds the lines of both the warmup and the match which followed, up
+	// to the separator ending it
+	separator := "  5:10 " + matchSeparator + "\n"
+	end := strings.Index(restartLog, separator) + len(separator)
+	assert.Equal(t, restartLog[:end], string(matches[0].Raw))
+}
+
+func TestRestartMergeOpenMatch(t *testing.T) {
+	log := "  0:00 InitGame: \\mapname\\q3dm17\n  0:20 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{Restarts: RestartMerge})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	// while the log may still go on, so may the match
+	parser := NewParser(Options{Restarts: RestartMerge})
+	failing := io.MultiReader(strings.NewReader(log), iotest.ErrReader(errors.New("connection lost")))
+	_, err = parser.Parse(failing)
+	assert.Error(t, err)
+	match, ok := parser.OpenMatch()
+	assert.True(t, ok)
+	assert.True(t, match.InProgress)
+	assert.Equal(t, "q3dm17", match.MapName)
+}
+
+func TestRestartParallel(t *testing.T) {
+	log := strings.Repeat(restartLog, 20)
+	for _, opts := range []Options{{}, {Restarts: RestartMerge}} {
+		expected, _, err := ParseLogWithOptions(strings.NewReader(log), opts)
+		assert.NoError(t, err)
+
+		for _, workers := range []int{2, 3, 8, 64} {
+			matches, err := ParseLogParallel(bytes.NewReader([]byte(log)), int64(len(log)), opts, workers)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, matches, "workers: %d", workers)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package qlp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// EventRule describes an extra kind of event to be captured from matches, so that lines
+// written by mods can be aggregated without changes to the parser. Every event of a match
+// which matches Pattern adds to the aggregation named Target, found in Match.Custom.
+type EventRule struct {
+	// Target names the aggregation the rule adds to.
+	Target string
+	// Pattern is matched against each event, which is the line without its timestamp.
+	Pattern *regexp.Regexp
+	// Key names the capture group whose text is the key of the aggregation, e.g. a player
+	// name. If empty, every event adds to the "total" key.
+	Key string
+	// Value names the capture group holding the number to be added. If empty, 1 is added
+	// for each event, i.e. events are counted.
+	Value string
+}
+
+// NewEventRule creates and returns an EventRule, compiling its pattern and making sure that
+// the named capture groups exist.
+func NewEventRule(target, pattern, key, value string) (EventRule, error) {
+	if target == "" {
+		return EventRule{}, fmt.Errorf("event rule has no target")
+	}
+
+	expr, err := regexp.Compile(pattern)
+	if err != nil {
+		return EventRule{}, fmt.Errorf("event rule %q: %w", target, err)
+	}
+	for _, group := range [...]string{key, value} {
+		if group != "" && expr.SubexpIndex(group) < 0 {
+			return EventRule{}, fmt.Errorf("event rule %q: pattern has no capture group named %q", target, group)
+		}
+	}
+
+	return EventRule{Target: target, Pattern: expr, Key: key, Value: value}, nil
+}
+
+// applyRules adds the event to the aggregations of every rule it matches.
+func (m *matchParser) applyRules(p *logParser, event string) {
+	for _, rule := range p.opts.EventRules {
+		groups := rule.Pattern.FindStringSubmatch(event)
+		if groups == nil {
+			continue
+		}
+
+		key := "total"
+		if rule.Key != "" {
+			key = groups[rule.Pattern.SubexpIndex(rule.Key)]
+		}
+
+		value := 1
+		if rule.Value != "" {
+			text := groups[rule.Pattern.SubexpIndex(rule.Value)]
+			number, err := strconv.Atoi(text)
+			if err != nil {
+				p.warn(fmt.Errorf("event rule %q: value %q is not a number", rule.Target, text))
+				continue
+			}
+			value = number
+		}
+
+		if m.custom == nil {
+			m.custom = make(map[string]map[string]int)
+		}
+		if m.custom[rule.Target] == nil {
+			m.custom[rule.Target] = make(map[string]int)
+		}
+		m.custom[rule.Target][key] += value
+	}
+}
@@ -0,0 +1,52 @@
+package qlp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventRules(t *testing.T) {
+	captures, err := NewEventRule("flag_captures", `^CTF: \d+ \d+ 2: (?P<player>.+) captured`, "player", "")
+	assert.NoError(t, err)
+	damage, err := NewEventRule("damage", `^Damage: (?P<player>\S+) (?P<amount>\S+)`, "player", "amount")
+	assert.NoError(t, err)
+	votes, err := NewEventRule("votes", `^Callvote:`, "", "")
+	assert.NoError(t, err)
+
+	log := "  0:00 InitGame:\n" +
+		"  0:01 CTF: 2 1 2: Zeh captured the BLUE flag!\n" +
+		"  0:02 CTF: 2 1 2: Zeh captured the BLUE flag!\n" +
+		"  0:03 CTF: 3 2 2: Mocinha captured the RED flag!\n" +
+		"  0:04 Damage: Zeh 100\n" +
+		"  0:05 Damage: Zeh 25\n" +
+		"  0:06 Damage: Zeh lots\n" +
+		"  0:07 Callvote: 2 map q3dm6\n" +
+		"  0:08 " + matchSeparator + "\n" +
+		"  0:09 InitGame:\n" +
+		"  0:10 " + matchSeparator + "\n"
+
+	opts := Options{EventRules: []EventRule{captures, damage, votes}}
+	matches, warnings, err := ParseLogWithOptions(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]map[string]int{
+		"flag_captures": {"Zeh": 2, "Mocinha": 1},
+		"damage":        {"Zeh": 125},
+		"votes":         {"total": 1},
+	}, matches[0].Custom)
+	assert.Nil(t, matches[1].Custom)
+
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, 7, warnings[0].Line)
+}
+
+func TestNewEventRuleValidation(t *testing.T) {
+	_, err := NewEventRule("", `x`, "", "")
+	assert.Error(t, err)
+	_, err = NewEventRule("broken", `(`, "", "")
+	assert.Error(t, err)
+	_, err = NewEventRule("missing", `(?P<player>\S+)`, "name", "")
+	assert.ErrorContains(t, err, `no capture group named "name"`)
+}
@@ -0,0 +1,27 @@
+package qlp
+
+// ScoreModel tells how the kills of a match change the scores of the players in Match.Kills.
+// The zero value scores as ClassicScoring.
+type ScoreModel struct {
+	Kill       int // for killing another player
+	Teamkill   int // for killing a player of the same team, in team game types
+	Suicide    int // for killing oneself, such as with one's own rocket
+	WorldDeath int // for being killed by <world>, such as by falling into the void
+}
+
+var (
+	// ClassicScoring scores as the original specification of the parser: kills of teammates
+	// count as any other kill and suicides are not scored.
+	ClassicScoring = ScoreModel{Kill: 1, Teamkill: 1, Suicide: 0, WorldDeath: -1}
+	// Q3Scoring scores as Quake III Arena does, taking a point off players for suicides and
+	// for killing teammates.
+	Q3Scoring = ScoreModel{Kill: 1, Teamkill: -1, Suicide: -1, WorldDeath: -1}
+)
+
+// scoring returns the ScoreModel selected by o.Scoring.
+func (o Options) scoring() ScoreModel {
+	if o.Scoring == (ScoreModel{}) {
+		return ClassicScoring
+	}
+	return o.Scoring
+}
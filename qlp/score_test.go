@@ -0,0 +1,48 @@
+package qlp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoring(t *testing.T) {
+	log := "  0:00 InitGame: \\g_gametype\\4\\mapname\\q3wctf1\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Zeh\\t\\1\n" +
+		"  0:01 ClientUserinfoChanged: 3 n\\Mocinha\\t\\1\n" +
+		"  0:01 ClientUserinfoChanged: 4 n\\Isgalamido\\t\\2\n" +
+		"  0:02 Kill: 2 4 6: Zeh killed Isgalamido by MOD_ROCKET\n" +
+		"  0:03 Kill: 2 3 6: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  0:04 Kill: 4 4 7: Isgalamido killed Isgalamido by MOD_ROCKET_SPLASH\n" +
+		"  0:05 Kill: 1022 3 19: <world> killed Mocinha by MOD_FALLING\n" +
+		"  0:06 ClientUserinfoChanged: 3 n\\Mocinha\\t\\2\n" +
+		"  0:07 Kill: 2 3 6: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  0:50 " + matchSeparator + "\n"
+
+	for _, test := range []struct {
+		scoring  ScoreModel
+		expected map[string]int
+	}{
+		{ScoreModel{}, map[string]int{"Zeh": 3, "Mocinha": -1, "Isgalamido": 0}},
+		{ClassicScoring, map[string]int{"Zeh": 3, "Mocinha": -1, "Isgalamido": 0}},
+		{Q3Scoring, map[string]int{"Zeh": 1, "Mocinha": -1, "Isgalamido": -1}},
+		{ScoreModel{Kill: 2, Teamkill: -5, Suicide: -3, WorldDeath: 0}, map[string]int{"Zeh": -1, "Mocinha": 0, "Isgalamido": -3}},
+	} {
+		matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{Scoring: test.scoring})
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, matches[0].Kills, test.scoring)
+	}
+}
+
+func TestScoringWithoutTeams(t *testing.T) {
+	log := "  0:00 InitGame: \\g_gametype\\0\\mapname\\q3dm17\n" +
+		"  0:01 ClientUserinfoChanged: 2 n\\Zeh\\t\\0\n" +
+		"  0:01 ClientUserinfoChanged: 3 n\\Mocinha\\t\\0\n" +
+		"  0:02 Kill: 2 3 6: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  0:50 " + matchSeparator + "\n"
+
+	matches, _, err := ParseLogWithOptions(strings.NewReader(log), Options{Scoring: Q3Scoring})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Zeh": 1, "Mocinha": 0}, matches[0].Kills)
+}
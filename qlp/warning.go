@@ -0,0 +1,67 @@
+package qlp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidUTF8 is the reason of the warnings for lines which were not valid UTF-8 and had
+// to be sanitized according to Options.Decoding.
+var ErrInvalidUTF8 = errors.New("line is not valid UTF-8 and was sanitized")
+
+// Reasons of the warnings issued for suspicious events, which are otherwise skipped.
+var (
+	ErrMalformedKill      = errors.New("kill event is malformed and was skipped")
+	ErrKillOutsideMatch   = errors.New("kill event is outside of a match and was skipped")
+	ErrTimestampBackwards = errors.New("timestamp is earlier than the previous one in the match")
+	ErrMatchInterrupted   = errors.New("match started while another one was still open")
+)
+
+// ErrDuplicatePlayer is the reason of the warnings for clients taking the name of another
+// client still connected. Rather than merging their kills, the client taking the name is told
+// apart by a suffix, as in "Zeh (2)".
+var ErrDuplicatePlayer = errors.New("player name is used by another client and was suffixed")
+
+// ErrUnfinishedMatch is returned when the log ends while a match is still open, which is the
+// case of truncated logs and of logs still being written.
+var ErrUnfinishedMatch = errors.New("log entries ended while a match was still open")
+
+// ErrMalformedLine is the reason of the warnings for lines without a timestamp, which are
+// only skipped with Options.Lenient and when diagnosing a log. Otherwise they make the parse
+// fail.
+var ErrMalformedLine = errors.New("line is malformed")
+
+// ErrTooManyErrors is returned when more lines than Options.MaxErrors were skipped.
+var ErrTooManyErrors = errors.New("too many lines failed to parse")
+
+// CorruptRegionError is the reason of the warnings issued with Options.Resync for corrupted
+// regions of a log, which were skipped up to the next InitGame event. The warning's line is
+// the first line of the region.
+type CorruptRegionError struct {
+	// Start and End are the offsets of the region's first byte and of the byte right after
+	// it.
+	Start, End int64
+	Lines      int
+}
+
+// Error implements the error interface.
+func (e CorruptRegionError) Error() string {
+	return fmt.Sprintf("corrupted region at bytes %d-%d was skipped", e.Start, e.End)
+}
+
+// ParseWarning describes a problem found in a log line which did not stop the parse.
+type ParseWarning struct {
+	Line   int    // 1-indexed number of the line
+	Text   string // text of the line, after sanitization
+	Reason error
+}
+
+// Error implements the error interface, so that warnings may be handled as regular errors.
+func (w ParseWarning) Error() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Reason)
+}
+
+// Unwrap returns the reason of the warning.
+func (w ParseWarning) Unwrap() error {
+	return w.Reason
+}
@@ -0,0 +1,59 @@
+package qlp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWarnings(t *testing.T) {
+	log := "  0:00 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  1:00 InitGame:\n" +
+		"  1:10 Kill: 0 1 2: Zeh killed Mocinha\n" +
+		"  1:05 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  1:20 " + matchSeparator + "\n"
+
+	var called []ParseWarning
+	opts := Options{OnWarning: func(warning ParseWarning) { called = append(called, warning) }}
+	matches, warnings, err := ParseLogWithOptions(strings.NewReader(log), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, matches[0].TotalKills)
+
+	assert.Equal(t, warnings, called)
+	assert.Len(t, warnings, 3)
+	assert.Equal(t, ParseWarning{Line: 1, Text: "  0:00 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET", Reason: ErrKillOutsideMatch}, warnings[0])
+	assert.Equal(t, 3, warnings[1].Line)
+	assert.ErrorIs(t, warnings[1], ErrMalformedKill)
+	assert.Equal(t, 4, warnings[2].Line)
+	assert.ErrorIs(t, warnings[2], ErrTimestampBackwards)
+	assert.EqualError(t, warnings[2], "line 4: timestamp is earlier than the previous one in the match")
+}
+
+func TestParseWarningsReturnedOnError(t *testing.T) {
+	log := "  0:00 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET\nBadLine\n"
+
+	_, warnings, err := ParseLogWithOptions(strings.NewReader(log), Options{})
+	assert.Error(t, err)
+	assert.Len(t, warnings, 1)
+}
+
+func TestTestLogHasNoWarnings(t *testing.T) {
+	_, warnings, err := ParseLogWithOptions(bytes.NewReader(testLogFile), Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestMatchInterruptedWarning(t *testing.T) {
+	log := "  0:00 InitGame:\n  0:01 Kill: 0 1 2: Zeh killed Mocinha by MOD_ROCKET\n" +
+		"  0:00 InitGame:\n  0:02 " + matchSeparator + "\n"
+
+	matches, warnings, err := ParseLogWithOptions(strings.NewReader(log), Options{})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.Len(t, warnings, 2)
+	assert.ErrorIs(t, warnings[0], ErrMatchInterrupted)
+	assert.Equal(t, 3, warnings[0].Line)
+	assert.ErrorIs(t, warnings[1], ErrTimestampBackwards)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/urfave/cli/v2"
+)
+
+// ranking is the output of the "ranking" report.
+type ranking []qlp.PlayerRank
+
+// Table lists one player per row, in order of rank.
+func (r ranking) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"rank", "player", "kills", "deaths", "world_deaths", "matches"}}
+	for _, p := range r {
+		t.Rows = append(t.Rows, []string{
+			strconv.Itoa(p.Rank), p.Player, strconv.Itoa(p.Kills), strconv.Itoa(p.Deaths),
+			strconv.Itoa(p.WorldDeaths), strconv.Itoa(p.Matches),
+		})
+	}
+	return t
+}
+
+// rankCommand returns the "rank" subcommand, which ranks the players of the given logs. It is
+// a shortcut for the "leaderboard" report, or the "ranking" one with --ranking.
+func rankCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "rank",
+		Usage:     "Ranks the players of log files by their kills over every match.",
+		ArgsUsage: "<file...>",
+		Description: "Same as \"report leaderboard\": ranks the players by kills, with their matches played, " +
+			"average kills and best game. With --ranking, same as \"report ranking\": ranks the players by " +
+			"kills of other players, with their deaths and deaths by the world.",
+		Flags: append(reportFlags(), &cli.BoolFlag{
+			Name:  "ranking",
+			Usage: "rank by kills of other players, with deaths and deaths by the world, as the \"ranking\" report does",
+		}),
+		Action: func(c *cli.Context) error {
+			files, err := inputFiles(c)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			name := "leaderboard"
+			if c.Bool("ranking") {
+				name = "ranking"
+			}
+			r, _ := findReport(name)
+			return runReport(c, r, files)
+		},
+	}
+}
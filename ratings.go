@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+)
+
+// ratingHistory is the output of the "ratings" report, keyed by player name.
+type ratingHistory map[string][]qlpstats.RatingPoint
+
+// Table lists one match of a player per row, sorted by player and then by match, which is the
+// long format plotting tools expect.
+func (rh ratingHistory) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"player", "match", "rating"}}
+	for _, player := range sortedKeys(rh) {
+		for _, point := range rh[player] {
+			t.Rows = append(t.Rows, []string{player, strconv.Itoa(point.Match), formatFloat(point.Rating)})
+		}
+	}
+	return t
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// rconTimeout bounds how long the server may take to answer an rcon command.
+const rconTimeout = 3 * time.Second
+
+// rconSettle is how long to wait for further packets once a response started arriving, as
+// long responses are split across packets.
+const rconSettle = 200 * time.Millisecond
+
+// oobPrefix starts every connectionless packet of the Quake III protocol.
+var oobPrefix = []byte("\xff\xff\xff\xff")
+
+// rconCommand returns the "rcon" subcommand, which checks and enables the logging settings of
+// a server through its remote console.
+func rconCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "rcon",
+		Usage: "Checks, and optionally enables, the logging settings of a server through rcon.",
+		Description: "Reports the server's g_log and g_logsync settings, which must be set for the server to write " +
+			"logs the parser can read. With --enable, sets g_logsync to 1 and, if logging is disabled, g_log " +
+			"to games.log, which takes effect on the next map change.\n\n" +
+			"The log itself cannot be read through rcon: the remote console only returns what each command " +
+			"prints, and condump writes the console to a file on the server. Read the log with --remote or " +
+			"a URL instead.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "server",
+				Usage:    "address of the server, as `HOST:PORT`",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "password",
+				Usage:   "rcon password of the server",
+				EnvVars: []string{"QLP_RCON_PASSWORD"},
+			},
+			&cli.BoolFlag{
+				Name:  "enable",
+				Usage: "enable logging and synchronous writes of the log",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			client, err := dialRcon(c.String("server"), c.String("password"))
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to connect to server: %s", err), exitOpen)
+			}
+			defer client.Close()
+
+			logFile, err := client.cvar("g_log")
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to query server: %s", err), exitOpen)
+			}
+			logSync, err := client.cvar("g_logsync")
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to query server: %s", err), exitOpen)
+			}
+			fmt.Fprintf(c.App.Writer, "g_log: %q\ng_logsync: %q\n", logFile, logSync)
+
+			if !c.Bool("enable") {
+				switch {
+				case logFile == "":
+					fmt.Fprintln(c.App.Writer, "Logging is disabled; use --enable to enable it.")
+				case logSync != "1":
+					fmt.Fprintln(c.App.Writer, "Log lines are buffered, so they show up late and are lost on "+
+						"crashes; use --enable to write them right away.")
+				default:
+					fmt.Fprintln(c.App.Writer, "Logging is enabled.")
+				}
+				return nil
+			}
+
+			if logSync != "1" {
+				if _, err := client.command("g_logsync 1"); err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to set g_logsync: %s", err), exitOpen)
+				}
+				fmt.Fprintln(c.App.Writer, "Set g_logsync to 1.")
+			}
+			if logFile == "" {
+				if _, err := client.command("g_log games.log"); err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to set g_log: %s", err), exitOpen)
+				}
+				fmt.Fprintln(c.App.Writer, "Set g_log to games.log; logging starts on the next map change.")
+			}
+			return nil
+		},
+	}
+}
+
+// rconClient sends commands to the remote console of a server.
+type rconClient struct {
+	conn     net.Conn
+	password string
+	// timeout bounds how long the server may take to answer, rconTimeout by default.
+	timeout time.Duration
+}
+
+// dialRcon returns a client for the remote console of the server at address.
+func dialRcon(address, password string) (*rconClient, error) {
+	if password == "" {
+		return nil, errors.New("no rcon password given")
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &rconClient{conn: conn, password: password, timeout: rconTimeout}, nil
+}
+
+// Close closes the client's connection.
+func (r *rconClient) Close() error {
+	return r.conn.Close()
+}
+
+// errBadPassword is returned when the server rejects the rcon password.
+var errBadPassword = errors.New("bad rcon password")
+
+// command runs cmd on the server's console, returning what it printed.
+func (r *rconClient) command(cmd string) (string, error) {
+	packet := append(bytes.Clone(oobPrefix), fmt.Sprintf("rcon %s %s\n", r.password, cmd)...)
+	if _, err := r.conn.Write(packet); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	received := false
+	buffer := make([]byte, 64*1024)
+	deadline := time.Now().Add(r.timeout)
+	for {
+		if err := r.conn.SetReadDeadline(deadline); err != nil {
+			return "", err
+		}
+		n, err := r.conn.Read(buffer)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() && received {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		text, ok := bytes.CutPrefix(buffer[:n], append(bytes.Clone(oobPrefix), "print\n"...))
+		if !ok {
+			continue // not a response to the command
+		}
+		output.Write(text)
+		received = true
+		deadline = time.Now().Add(rconSettle)
+	}
+
+	text := output.String()
+	if strings.HasPrefix(text, "Bad rconpassword.") {
+		return "", errBadPassword
+	}
+	return text, nil
+}
+
+// cvarValueExpr matches the value in the description the server prints of a cvar, such as
+// `"g_log" is:"games.log^7" default:"games.log^7"`.
+var cvarValueExpr = regexp.MustCompile(`is:\s*"(.*?)(?:\^7)?"`)
+
+// cvar returns the value of the server's cvar with the given name.
+func (r *rconClient) cvar(name string) (string, error) {
+	text, err := r.command(name)
+	if err != nil {
+		return "", err
+	}
+	match := cvarValueExpr.FindStringSubmatch(text)
+	if match == nil {
+		return "", fmt.Errorf("unexpected response to %s: %q", name, strings.TrimSpace(text))
+	}
+	return match[1], nil
+}
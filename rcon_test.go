@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRconServer serves a fake remote console on a local UDP port, answering each packet with
+// the packets respond returns for it. It returns a client of the server.
+func fakeRconServer(t *testing.T, respond func(packet string) []string) *rconClient {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buffer := make([]byte, 64*1024)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			for _, packet := range respond(string(buffer[:n])) {
+				conn.WriteTo([]byte(packet), addr)
+			}
+		}
+	}()
+
+	client, err := dialRcon(conn.LocalAddr().String(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	client.timeout = 100 * time.Millisecond
+	return client
+}
+
+func TestRconCvar(t *testing.T) {
+	requests := make(chan string, 1)
+	client := fakeRconServer(t, func(packet string) []string {
+		requests <- packet
+		return []string{
+			// packets other than print responses are not part of the output
+			"\xff\xff\xff\xffstatusResponse\n",
+			"\xff\xff\xff\xffprint\n\"g_log\" is:\"games.log^7\" default:\"games.log^7\"\n",
+		}
+	})
+
+	value, err := client.cvar("g_log")
+	assert.NoError(t, err)
+	assert.Equal(t, "games.log", value)
+	assert.Equal(t, "\xff\xff\xff\xffrcon secret g_log\n", <-requests)
+}
+
+func TestRconMultiplePackets(t *testing.T) {
+	client := fakeRconServer(t, func(string) []string {
+		return []string{
+			"\xff\xff\xff\xffprint\nmap: q3dm17\nnum score ping name\n",
+			"\xff\xff\xff\xffprint\n  0     5   48 Isgalamido\n",
+		}
+	})
+
+	text, err := client.command("status")
+	assert.NoError(t, err)
+	assert.Equal(t, "map: q3dm17\nnum score ping name\n  0     5   48 Isgalamido\n", text)
+}
+
+func TestRconBadPassword(t *testing.T) {
+	client := fakeRconServer(t, func(string) []string {
+		return []string{"\xff\xff\xff\xffprint\nBad rconpassword.\n"}
+	})
+	_, err := client.command("status")
+	assert.ErrorIs(t, err, errBadPassword)
+}
+
+func TestRconTimeout(t *testing.T) {
+	client := fakeRconServer(t, func(string) []string {
+		// an answer without the print prefix is no answer at all
+		return []string{"\xff\xff\xff\xffdisconnect\n"}
+	})
+
+	start := time.Now()
+	_, err := client.command("status")
+	var netErr net.Error
+	if assert.True(t, errors.As(err, &netErr), err) {
+		assert.True(t, netErr.Timeout())
+	}
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// downloadAttempts is how many times a download is attempted before giving up. Attempts after
+// the first resume where the previous one stopped.
+const downloadAttempts = 5
+
+// logFile is an opened log. Logs given as URLs are downloaded to a temporary file, which is
+// removed once closed, and compressed logs are decompressed as they are read.
+type logFile struct {
+	// Reader reads the log, decompressed.
+	io.Reader
+	// File holds the log, compressed if decoder is set.
+	File      *os.File
+	decoder   io.Closer
+	temporary bool
+}
+
+// Close closes the file, removing it if it is temporary.
+func (f logFile) Close() error {
+	if f.decoder != nil {
+		f.decoder.Close()
+	}
+	err := f.File.Close()
+	if f.temporary {
+		os.Remove(f.File.Name())
+	}
+	return err
+}
+
+// seekable reports whether File can be read in place of the log, at any offset.
+func (f logFile) seekable() bool {
+	if f.decoder != nil {
+		return false
+	}
+	_, err := f.File.Seek(0, io.SeekCurrent)
+	return err == nil
+}
+
+// isRemote reports whether path is a URL of a log to be downloaded rather than a local path.
+func isRemote(path string) bool {
+	for _, scheme := range [...]string{"http://", "https://", "s3://", "ssh://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// openLog opens the log at path, which is either a local path, an http(s)://, s3:// or ssh://
+// URL, or "-" for the standard input. Logs compressed as told by qlp.IsCompressed, or by their
+// magic number on the standard input, are decompressed as they are read, unless seekable is
+// set: then they are decompressed to a temporary file, which parsing can seek and map as it
+// does the others, and so is the standard input copied to one.
+func openLog(ctx context.Context, path string, seekable bool) (logFile, error) {
+	if path == "-" {
+		return openStdin(seekable)
+	}
+	log, err := fetchLog(ctx, path)
+	if err != nil || !qlp.IsCompressed(log.File.Name()) {
+		return log, err
+	}
+
+	decoder, err := qlp.OpenLog(log.File.Name())
+	if err != nil {
+		log.Close()
+		return logFile{}, err
+	}
+	if seekable {
+		defer log.Close()
+		defer decoder.Close()
+		return spoolLog(decoder, path)
+	}
+	log.Reader, log.decoder = decoder, decoder
+	return log, nil
+}
+
+// openStdin opens the standard input as a log, as openLog does.
+func openStdin(seekable bool) (logFile, error) {
+	decoder, err := qlp.NewLogReader(os.Stdin)
+	if err != nil {
+		return logFile{}, err
+	}
+	log := logFile{Reader: decoder, File: os.Stdin, decoder: decoder}
+	if !seekable {
+		return log, nil
+	}
+	defer decoder.Close()
+	return spoolLog(decoder, "the standard input")
+}
+
+// fetchLog opens the log at path, which is either a local path or an http(s)://, s3:// or
+// ssh:// URL. Downloads keep the extension of the URL, which tells whether they are
+// compressed.
+func fetchLog(ctx context.Context, path string) (logFile, error) {
+	if !isRemote(path) {
+		file, err := os.Open(path)
+		return logFile{Reader: file, File: file}, err
+	}
+
+	pattern := "qlp-*.log"
+	if u, err := url.Parse(path); err == nil && qlp.IsCompressed(u.Path) {
+		pattern += filepath.Ext(u.Path)
+	}
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return logFile{}, err
+	}
+	log := logFile{Reader: file, File: file, temporary: true}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if strings.HasPrefix(path, "ssh://") {
+		err = sshDownload(ctx, path, file)
+	} else {
+		err = download(ctx, path, file)
+	}
+	if err != nil {
+		log.Close()
+		return logFile{}, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Close()
+		return logFile{}, err
+	}
+	return log, nil
+}
+
+// spoolLog copies the log read from r, named name in errors, to a temporary file.
+func spoolLog(r io.Reader, name string) (logFile, error) {
+	file, err := os.CreateTemp("", "qlp-*.log")
+	if err != nil {
+		return logFile{}, err
+	}
+	log := logFile{Reader: file, File: file, temporary: true}
+	if _, err := io.Copy(file, r); err != nil {
+		log.Close()
+		return logFile{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Close()
+		return logFile{}, err
+	}
+	return log, nil
+}
+
+// download writes the contents at rawURL to dst. When a transfer is interrupted, the next
+// attempt requests only the missing range, provided the server supports ranges.
+func download(ctx context.Context, rawURL string, dst *os.File) error {
+	var err error
+	for attempt := 0; attempt < downloadAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+
+		var retry bool
+		retry, err = downloadAttempt(ctx, rawURL, dst)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// downloadAttempt continues the download of rawURL into dst, reporting whether a failure is
+// worth retrying.
+func downloadAttempt(ctx context.Context, rawURL string, dst *os.File) (retry bool, err error) {
+	offset, err := dst.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+
+	request, err := newRemoteRequest(ctx, rawURL)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusOK:
+		// the server ignored the range, if any, so the download starts over
+		if err := dst.Truncate(0); err != nil {
+			return false, err
+		}
+		if _, err := dst.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+	case response.StatusCode == http.StatusPartialContent:
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the previous attempt failed right at the end
+		return false, nil
+	default:
+		retry := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+
+	if _, err := io.Copy(dst, response.Body); err != nil {
+		return ctx.Err() == nil, err
+	}
+	return false, nil
+}
+
+// newRemoteRequest returns the request fetching rawURL. s3:// URLs are turned into requests
+// to the bucket's endpoint, signed when AWS credentials are set in the environment.
+func newRemoteRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	if !strings.HasPrefix(rawURL, "s3://") {
+		return http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(rawURL, "s3://"), "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 URL %q, expected s3://bucket/key", rawURL)
+	}
+	region := cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	host := fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, region)
+	path := "/" + awsEscape(key)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+host+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+		if err := signS3Request(request, host, path, region, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+	}
+	return request, nil
+}
+
+// signS3Request signs a GET request to S3 with AWS Signature Version 4, using the credentials
+// of the environment. The payload is left unsigned, as GET requests have none.
+func signS3Request(request *http.Request, host, path, region string, now time.Time) error {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if secretKey == "" {
+		return errors.New("AWS_ACCESS_KEY_ID is set but AWS_SECRET_ACCESS_KEY is not")
+	}
+
+	amzDate := now.Format(sigV4TimeFormat)
+	headers := [][2]string{
+		{"host", host},
+		{"x-amz-content-sha256", "UNSIGNED-PAYLOAD"},
+		{"x-amz-date", amzDate},
+	}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		headers = append(headers, [2]string{"x-amz-security-token", token})
+	}
+	for _, header := range headers {
+		if header[0] != "host" {
+			request.Header.Set(header[0], header[1])
+		}
+	}
+
+	canonical, signedHeaders := sigV4CanonicalRequest(http.MethodGet, path, "", headers, "UNSIGNED-PAYLOAD")
+	signature, scope := sigV4Signature(canonical, secretKey, region, "s3", now)
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature,
+	))
+	return nil
+}
+
+// sigV4TimeFormat is the layout of the times of AWS Signature Version 4.
+const sigV4TimeFormat = "20060102T150405Z"
+
+// sigV4CanonicalRequest returns the canonical request of AWS Signature Version 4 for a request
+// with the given method, escaped path and canonical query, headers, whose names must be
+// lowercase and sorted, and hash of the payload. It also returns the list of signed headers.
+func sigV4CanonicalRequest(method, path, query string, headers [][2]string, payloadHash string) (canonical, signedHeaders string) {
+	var canonicalHeaders, names []string
+	for _, header := range headers {
+		canonicalHeaders = append(canonicalHeaders, header[0]+":"+header[1]+"\n")
+		names = append(names, header[0])
+	}
+	signedHeaders = strings.Join(names, ";")
+	canonical = strings.Join([]string{
+		method,
+		path,
+		query,
+		strings.Join(canonicalHeaders, ""),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	return canonical, signedHeaders
+}
+
+// sigV4Signature returns the AWS Signature Version 4 of the canonical request, made at now
+// with secretKey for the service in region, along with its credential scope.
+func sigV4Signature(canonical, secretKey, region, service string, now time.Time) (signature, scope string) {
+	date := now.Format("20060102")
+	scope = date + "/" + region + "/" + service + "/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format(sigV4TimeFormat) + "\n" + scope + "\n" +
+		hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range [...]string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign)), scope
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEscape percent-encodes the path s the way AWS signatures expect: every byte but slashes
+// and the unreserved characters of RFC 3986 is encoded.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sshURL turns an scp-like location of a file, USER@HOST:PATH, into an ssh:// URL. Relative
+// paths are relative to the home directory of the user. IPv6 hosts are given in brackets, as
+// in USER@[::1]:PATH. The path is escaped, so that it is read back as given whatever
+// characters it holds.
+func sshURL(location string) (string, error) {
+	invalid := fmt.Errorf("invalid remote %q, expected USER@HOST:PATH", location)
+	colon := strings.Index(location, ":")
+	if colon < 0 {
+		return "", invalid
+	}
+	// the user ends at the last @ before the host, as the path may hold some too
+	user, rest := "", location
+	at := strings.LastIndex(location[:colon], "@")
+	hasUser := at >= 0
+	if hasUser {
+		user, rest = location[:at], location[at+1:]
+	}
+
+	var host, path string
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]:")
+		if end < 0 {
+			return "", invalid
+		}
+		host, path = rest[:end+1], rest[end+2:]
+	} else {
+		var ok bool
+		if host, path, ok = strings.Cut(rest, ":"); !ok {
+			return "", invalid
+		}
+	}
+	if host == "" || host == "[]" || path == "" || (hasUser && user == "") {
+		return "", invalid
+	}
+	// ssh would read a user or host starting with a dash as an option
+	if strings.HasPrefix(user, "-") || strings.HasPrefix(host, "-") {
+		return "", fmt.Errorf("invalid remote %q, the user and host cannot start with a dash", location)
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/~/" + path
+	}
+	u := url.URL{Scheme: "ssh", Host: host, Path: path}
+	if hasUser {
+		u.User = url.User(user)
+	}
+	return u.String(), nil
+}
+
+// sshDownload writes the file at the ssh:// URL rawURL to dst, reading it with the system's
+// ssh client, so that its configuration, keys and agent apply.
+func sshDownload(ctx context.Context, rawURL string, dst *os.File) error {
+	cmd, err := sshCommand(ctx, rawURL, "cat --")
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = dst, &stderr
+	return sshError(cmd, cmd.Run(), &stderr)
+}
+
+// sshCommand returns the command running the remote program, such as "cat --", with the path
+// of the file at the ssh:// URL rawURL as its last argument, with the system's ssh client.
+func sshCommand(ctx context.Context, rawURL, program string) (*exec.Cmd, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Path
+	if rest, ok := strings.CutPrefix(path, "/~/"); ok {
+		path = rest
+	}
+	if u.Hostname() == "" || path == "" {
+		return nil, fmt.Errorf("invalid SSH URL %q, expected ssh://user@host/path", rawURL)
+	}
+	if strings.HasPrefix(u.Hostname(), "-") || strings.HasPrefix(u.User.Username(), "-") {
+		return nil, fmt.Errorf("invalid SSH URL %q, the user and host cannot start with a dash", rawURL)
+	}
+
+	// BatchMode fails instead of prompting, as the prompt would be mixed with the output
+	args := []string{"-o", "BatchMode=yes"}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	destination := u.Hostname()
+	if u.User != nil {
+		destination = u.User.Username() + "@" + destination
+	}
+	// "--" ends the options, so that the destination is never read as one
+	args = append(args, "--", destination, program+" "+shellQuote(path))
+	return exec.CommandContext(ctx, "ssh", args...), nil
+}
+
+// sshError returns the error err of the ssh command cmd, with the message ssh wrote to stderr
+// if any.
+func sshError(cmd *exec.Cmd, err error, stderr *bytes.Buffer) error {
+	if err == nil {
+		return nil
+	}
+	if message := strings.TrimSpace(stderr.String()); message != "" {
+		return fmt.Errorf("ssh %s: %s", cmd.Args[len(cmd.Args)-2], message)
+	}
+	return err
+}
+
+// errSSHClosed ends the logs followed over SSH when the connection ends without an error.
+var errSSHClosed = errors.New("SSH connection closed")
+
+// sshTail is the log at an ssh:// URL as the server writes it, read through tail -F run over
+// SSH, which follows the log across rotations. The log never ends while followed, so reading
+// it fails with the error of ssh, or errSSHClosed, once the connection ends.
+type sshTail struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+	err    error // of the command, once it exited
+	exited bool
+}
+
+// openSSHTail starts following the log at the ssh:// URL rawURL from its start. The command
+// is killed once ctx is cancelled.
+func openSSHTail(ctx context.Context, rawURL string) (*sshTail, error) {
+	cmd, err := sshCommand(ctx, rawURL, "tail -n +1 -F --")
+	if err != nil {
+		return nil, err
+	}
+	t := &sshTail{cmd: cmd}
+	cmd.Stderr = &t.stderr
+	if t.stdout, err = cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Read reads the next bytes of the log, waiting for the server to write them.
+func (t *sshTail) Read(p []byte) (int, error) {
+	n, err := t.stdout.Read(p)
+	if errors.Is(err, io.EOF) {
+		err = t.wait()
+	}
+	return n, err
+}
+
+// wait waits for the command to exit, returning why it did.
+func (t *sshTail) wait() error {
+	if !t.exited {
+		t.exited = true
+		t.err = sshError(t.cmd, t.cmd.Wait(), &t.stderr)
+		if t.err == nil {
+			t.err = errSSHClosed
+		}
+	}
+	return t.err
+}
+
+// Close stops following the log.
+func (t *sshTail) Close() error {
+	if !t.exited {
+		t.cmd.Process.Kill()
+		t.wait()
+	}
+	return nil
+}
+
+// shellQuote quotes s for POSIX shells, such as the one running commands over SSH.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
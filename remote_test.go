@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSSH puts an ssh command on the PATH which runs the remote command locally, ignoring the
+// options and the destination.
+func fakeSSH(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\nexec sh -c \"exec $last\"\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSSHTail(t *testing.T) {
+	fakeSSH(t)
+	log := filepath.Join(t.TempDir(), "games.log")
+	assert.NoError(t, os.WriteFile(log, []byte("  0:00 InitGame:\n"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tail, err := openSSHTail(ctx, "ssh://me@host"+log)
+	if !assert.NoError(t, err) {
+		return
+	}
+	line := make([]byte, len("  0:00 InitGame:\n"))
+	_, err = io.ReadFull(tail, line)
+	assert.NoError(t, err)
+	assert.Equal(t, "  0:00 InitGame:\n", string(line))
+
+	// the log is followed as it grows
+	file, err := os.OpenFile(log, os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	file.WriteString("  0:01 ShutdownGame:\n")
+	file.Close()
+	line = make([]byte, len("  0:01 ShutdownGame:\n"))
+	_, err = io.ReadFull(tail, line)
+	assert.NoError(t, err)
+	assert.Equal(t, "  0:01 ShutdownGame:\n", string(line))
+
+	cancel()
+	_, err = io.ReadAll(tail)
+	assert.Error(t, err)
+	assert.NoError(t, tail.Close())
+}
+
+func TestSSHTailClosed(t *testing.T) {
+	fakeSSH(t)
+	tail, err := openSSHTail(context.Background(), "ssh://me@host/nonexistent/games.log")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer tail.Close()
+	// the fake ssh runs the tail of the system, which keeps waiting for missing files, so
+	// the connection is ended by the test
+	tail.cmd.Process.Kill()
+	_, err = io.ReadAll(tail)
+	assert.Error(t, err)
+}
+
+func TestOpenLogCompressed(t *testing.T) {
+	log, err := os.ReadFile("qlp/test_log.txt")
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "games.log.gz")
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	gzipWriter.Write(log)
+	assert.NoError(t, gzipWriter.Close())
+	assert.NoError(t, file.Close())
+
+	for _, seekable := range []bool{false, true} {
+		opened, err := openLog(context.Background(), path, seekable)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		// only the modes which seek the log decompress it to a temporary file
+		assert.Equal(t, seekable, opened.seekable())
+		assert.Equal(t, seekable, opened.temporary)
+		data, err := io.ReadAll(opened)
+		assert.NoError(t, err)
+		assert.Equal(t, log, data)
+		assert.NoError(t, opened.Close())
+		if seekable {
+			assert.NoFileExists(t, opened.File.Name())
+		}
+	}
+}
+
+// sigV4Tests are cases of the AWS Signature Version 4 test suite, signed at 20150830T123600Z
+// for "service" in us-east-1 with its example credentials.
+var sigV4Tests = []struct {
+	name, method, path, query string
+	canonical, signature      string
+}{
+	{
+		name: "get-vanilla", method: "GET", path: "/",
+		canonical: "GET\n/\n\nhost:example.amazonaws.com\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+	},
+	{
+		name: "get-vanilla-empty-query-key", method: "GET", path: "/", query: "Param1=value1",
+		canonical: "GET\n/\nParam1=value1\nhost:example.amazonaws.com\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		signature: "a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb",
+	},
+	{
+		name: "get-utf8", method: "GET", path: awsEscape("/ሴ"),
+		canonical: "GET\n/%E1%88%B4\n\nhost:example.amazonaws.com\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		signature: "8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85",
+	},
+	{
+		name: "post-vanilla", method: "POST", path: "/",
+		canonical: "POST\n/\n\nhost:example.amazonaws.com\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		signature: "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+	},
+	{
+		name: "post-vanilla-query", method: "POST", path: "/", query: "Param1=value1",
+		canonical: "POST\n/\nParam1=value1\nhost:example.amazonaws.com\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		signature: "28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11",
+	},
+}
+
+func TestSigV4(t *testing.T) {
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	headers := [][2]string{{"host", "example.amazonaws.com"}, {"x-amz-date", "20150830T123600Z"}}
+	emptyHash := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	for _, test := range sigV4Tests {
+		canonical, signedHeaders := sigV4CanonicalRequest(test.method, test.path, test.query, headers, emptyHash)
+		assert.Equal(t, test.canonical, canonical, test.name)
+		assert.Equal(t, "host;x-amz-date", signedHeaders, test.name)
+
+		signature, scope := sigV4Signature(canonical, "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", now)
+		assert.Equal(t, test.signature, signature, test.name)
+		assert.Equal(t, "20150830/us-east-1/service/aws4_request", scope, test.name)
+	}
+}
+
+func TestNewRemoteRequestS3(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	request, err := newRemoteRequest(context.Background(), "s3://logs/2024/games 1.log")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://logs.s3.eu-west-1.amazonaws.com/2024/games%201.log", request.URL.String())
+	assert.Equal(t, "UNSIGNED-PAYLOAD", request.Header.Get("x-amz-content-sha256"))
+	assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-west-1/s3/aws4_request, `+
+		`SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`, request.Header.Get("Authorization"))
+
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	_, err = newRemoteRequest(context.Background(), "s3://logs/games.log")
+	assert.Error(t, err)
+	_, err = newRemoteRequest(context.Background(), "s3://logs")
+	assert.Error(t, err)
+}
+
+func TestDownloadResume(t *testing.T) {
+	log, err := os.ReadFile("qlp/test_log.txt")
+	assert.NoError(t, err)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// the first transfer breaks off halfway through the body
+			w.Header().Set("Content-Length", strconv.Itoa(len(log)))
+			w.Write(log[:len(log)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "games.log", time.Time{}, bytes.NewReader(log))
+	}))
+	defer server.Close()
+
+	dst, err := os.CreateTemp(t.TempDir(), "games-*.log")
+	assert.NoError(t, err)
+	defer dst.Close()
+	assert.NoError(t, download(context.Background(), server.URL+"/games.log", dst))
+
+	downloaded, err := os.ReadFile(dst.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, log, downloaded)
+	assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(log)/2)}, ranges)
+}
+
+func TestSSHURL(t *testing.T) {
+	for location, expected := range map[string]string{
+		"me@host:/var/log/games.log": "ssh://me@host/var/log/games.log",
+		"host:games.log":             "ssh://host/~/games.log",
+		"me@host:logs/my games.log":  "ssh://me@host/~/logs/my%20games.log",
+		"me@host:/logs/100%?#.log":   "ssh://me@host/logs/100%25%3F%23.log",
+		"me@[::1]:/var/log/a:b.log":  "ssh://me@[::1]/var/log/a:b.log",
+		"[fe80::1]:games.log":        "ssh://[fe80::1]/~/games.log",
+		"host:/logs/me@work.log":     "ssh://host/logs/me@work.log",
+	} {
+		u, err := sshURL(location)
+		assert.NoError(t, err, location)
+		assert.Equal(t, expected, u, location)
+	}
+	for _, location := range []string{
+		"host", ":games.log", "me@host:", "@host:games.log", "me@[::1]", "[]:games.log",
+		"-oProxyCommand=touch pwned:games.log", "-me@host:games.log", "me@-host:games.log",
+	} {
+		_, err := sshURL(location)
+		assert.Error(t, err, location)
+	}
+}
+
+func TestSSHCommandQuoting(t *testing.T) {
+	for _, path := range []string{
+		"/var/log/my games.log",
+		"/var/log/it's.log",
+		"/var/log/$(touch pwned).log",
+		"/var/log/`id` && rm -rf ~;.log",
+		"-rf.log",
+		"-- '$HOME' \"x\"\n.log",
+	} {
+		location := "me@host:" + path
+		u, err := sshURL(location)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		cmd, err := sshCommand(context.Background(), u, "printf %s")
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		assert.Equal(t, []string{"ssh", "-o", "BatchMode=yes", "--", "me@host"}, cmd.Args[:5], path)
+
+		// the remote shell gets the path back as a single argument, as given
+		remote := cmd.Args[len(cmd.Args)-1]
+		output, err := exec.Command("sh", "-c", remote).Output()
+		assert.NoError(t, err, path)
+		assert.Equal(t, path, string(output), path)
+	}
+}
+
+func TestSSHCommandOptions(t *testing.T) {
+	cmd, err := sshCommand(context.Background(), "ssh://me@[::1]:2222/var/log/games.log", "cat --")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ssh", "-o", "BatchMode=yes", "-p", "2222", "--", "me@::1", "cat -- '/var/log/games.log'"}, cmd.Args)
+
+	for _, rawURL := range []string{"ssh://-oProxyCommand=id/games.log", "ssh://-me@host/games.log"} {
+		_, err := sshCommand(context.Background(), rawURL, "cat --")
+		assert.Error(t, err, rawURL)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'games.log'`, shellQuote("games.log"))
+	assert.Equal(t, `'it'\''s $(id).log'`, shellQuote("it's $(id).log"))
+	assert.Equal(t, `'-n'`, shellQuote("-n"))
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/urfave/cli/v2"
+)
+
+// replCommand returns the "repl" subcommand, which loads logs and answers queries about their
+// matches interactively.
+func replCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "repl",
+		Usage:     "Loads log files and offers an interactive prompt to query their matches.",
+		ArgsUsage: "<file...>",
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			var matches qlp.Matches
+			for _, filePath := range c.Args().Slice() {
+				err := parseFile(filePath, parseConfig{jobs: 1}, func(match qlp.Match) error {
+					matches = append(matches, match)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			r := &repl{in: c.App.Reader, out: c.App.Writer, matches: matches}
+			r.run()
+			return nil
+		},
+	}
+}
+
+// repl holds the state of an interactive session. The loaded matches are kept in memory
+// between queries; a map filter narrows down which of them the queries consider.
+type repl struct {
+	in      io.Reader
+	out     io.Writer
+	matches qlp.Matches
+	mapName string
+}
+
+// replHelp describes the commands of the REPL.
+const replHelp = `Commands:
+  list              lists the matches
+  show N            shows match N as JSON
+  top [N]           shows the N players with the most kills (default 10)
+  player NAME       shows the kills of a player in each match
+  map [NAME]        only considers matches played on map NAME; without NAME, every match
+  help              shows this help
+  quit              ends the session`
+
+// run reads and answers queries until the input ends or the user quits.
+func (r *repl) run() {
+	fmt.Fprintf(r.out, "Loaded %d matches. Type \"help\" for the list of commands.\n", len(r.matches))
+
+	scanner := bufio.NewScanner(r.in)
+	for {
+		fmt.Fprint(r.out, "qlp> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case "":
+		case "list", "ls":
+			r.list()
+		case "show":
+			r.show(arg)
+		case "top":
+			r.top(arg)
+		case "player":
+			r.player(arg)
+		case "map":
+			r.mapName = arg
+			fmt.Fprintf(r.out, "%d matches selected.\n", len(r.selected()))
+		case "help", "?":
+			fmt.Fprintln(r.out, replHelp)
+		case "quit", "exit":
+			return
+		default:
+			fmt.Fprintf(r.out, "Unknown command %q. Type \"help\" for the list of commands.\n", command)
+		}
+	}
+}
+
+// selected returns the numbers, 1-indexed, of the matches that pass the map filter.
+func (r *repl) selected() []int {
+	var numbers []int
+	for i, match := range r.matches {
+		if r.mapName == "" || strings.EqualFold(match.MapName, r.mapName) {
+			numbers = append(numbers, i+1)
+		}
+	}
+	return numbers
+}
+
+// list writes a summary line for each selected match.
+func (r *repl) list() {
+	for _, number := range r.selected() {
+		match := r.matches[number-1]
+		fmt.Fprintf(r.out, "game_%-4d %s %3d kills  %2d players\n",
+			number, qlpformat.PadRight(match.MapName, 16), match.TotalKills, len(match.Players))
+	}
+}
+
+// show writes the match numbered arg as indented JSON.
+func (r *repl) show(arg string) {
+	number, err := strconv.Atoi(strings.TrimPrefix(arg, "game_"))
+	if err != nil || number < 1 || number > len(r.matches) {
+		fmt.Fprintf(r.out, "There is no match %q; matches go from 1 to %d.\n", arg, len(r.matches))
+		return
+	}
+
+	matchJSON, err := json.MarshalIndent(r.matches[number-1], "", "  ")
+	if err != nil {
+		fmt.Fprintf(r.out, "Failed to marshal match: %s\n", err)
+		return
+	}
+	fmt.Fprintf(r.out, "%s\n", matchJSON)
+}
+
+// top writes the players with the most kills over the selected matches.
+func (r *repl) top(arg string) {
+	limit := 10
+	if arg != "" {
+		var err error
+		if limit, err = strconv.Atoi(arg); err != nil || limit < 1 {
+			fmt.Fprintf(r.out, "Invalid number of players: %q\n", arg)
+			return
+		}
+	}
+
+	kills := make(map[string]int)
+	for _, number := range r.selected() {
+		for player, count := range r.matches[number-1].Kills {
+			kills[player] += count
+		}
+	}
+
+	players := make([]string, 0, len(kills))
+	for player := range kills {
+		players = append(players, player)
+	}
+	slices.SortFunc(players, func(a, b string) int {
+		return cmp.Or(cmp.Compare(kills[b], kills[a]), cmp.Compare(a, b))
+	})
+
+	for i, player := range players[:min(limit, len(players))] {
+		fmt.Fprintf(r.out, "%3d. %s %d\n", i+1, qlpformat.PadRight(player, 24), kills[player])
+	}
+}
+
+// player writes the kills of a player in each selected match they took part in.
+func (r *repl) player(name string) {
+	found := false
+	for _, number := range r.selected() {
+		if kills, ok := r.matches[number-1].Kills[name]; ok {
+			fmt.Fprintf(r.out, "game_%-4d %s %d kills\n", number, qlpformat.PadRight(r.matches[number-1].MapName, 16), kills)
+			found = true
+		}
+	}
+	if !found {
+		fmt.Fprintf(r.out, "%q did not play in the selected matches.\n", name)
+	}
+}
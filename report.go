@@ -0,0 +1,265 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+	"github.com/urfave/cli/v2"
+)
+
+// report describes an analysis over the parsed matches, computed by the "report" subcommand.
+type report struct {
+	name  string
+	usage string
+	// opts enables the optional data of the matches the report needs, such as the kill feed.
+	opts qlp.Options
+	// compute returns the report, computed according to env.
+	compute func(matches qlp.Matches, env *reportEnv) any
+}
+
+// reportEnv holds the settings the reports are computed with.
+type reportEnv struct {
+	// loc is the locale the text of the reports is written in.
+	loc *locale
+	// clans finds the clan tags in player names.
+	clans clanMatcher
+	// geo finds the countries of the players, if enabled.
+	geo *geoIP
+}
+
+// reports lists every report, in the order they are presented to the user.
+var reports = []report{
+	{
+		name:    "admin",
+		usage:   "the kicks, bans and mutes of each player over every match, from the lines admin mods log",
+		opts:    qlp.Options{AdminActions: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return buildAdminReport(matches) },
+	},
+	{
+		name:    "anomalies",
+		usage:   "flags statistically implausible performances, such as impossible kill rates",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, env *reportEnv) any { return findAnomalies(matches, env.loc) },
+	},
+	{
+		name:    "clans",
+		usage:   "clans ranked by the kills of their members, clans being told by the tags in player names",
+		compute: func(matches qlp.Matches, env *reportEnv) any { return buildClanLeaderboard(matches, env.clans) },
+	},
+	{
+		name:    "comebacks",
+		usage:   "lead changes in each match and the largest deficit the winner overcame",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return findComebacks(matches) },
+	},
+	{
+		name:    "duels",
+		usage:   "matches of two players, with the share of the Mega Health and armor pickups each took from the other",
+		opts:    qlp.Options{ItemControl: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return findDuels(matches) },
+	},
+	{
+		name:    "highlights",
+		usage:   "notable moments to find in demos: multi-kills, ends of long kill streaks and air rockets",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return findHighlights(matches) },
+	},
+	{
+		name:    "leaderboard",
+		usage:   "players ranked by kills, with their matches played, average kills and best game",
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return leaderboard(qlpstats.Leaderboard(matches)) },
+	},
+	{
+		name:    "pings",
+		usage:   "the minimum, average and maximum ping of each player in each match, from the score lines",
+		opts:    qlp.Options{Pings: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return buildPingReport(matches) },
+	},
+	{
+		name:    "powerups",
+		usage:   "how many kills each player scored within 30 seconds of picking up each powerup, such as Quad Damage",
+		opts:    qlp.Options{KillFeed: true, Powerups: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return buildPowerupStats(matches) },
+	},
+	{
+		name:    "profiles",
+		usage:   "per player statistics, such as nemesis and favorite victim, per match and overall",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, env *reportEnv) any { return buildProfiles(matches, env.clans, env.geo) },
+	},
+	{
+		name:    "ranking",
+		usage:   "players ranked by kills of other players, fewer deaths breaking ties, with their deaths and deaths by the world",
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return ranking(qlp.Rank(matches)) },
+	},
+	{
+		name:    "ratings",
+		usage:   "each player's Elo rating after every match they played, to plot ratings over time",
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return ratingHistory(qlpstats.RatingHistory(matches)) },
+	},
+	{
+		name:    "servers",
+		usage:   "the summary report for each server, keyed by hostname, and for all of them",
+		compute: func(matches qlp.Matches, env *reportEnv) any { return buildServerSummaries(matches, env.loc) },
+	},
+	{
+		name:    "streaks",
+		usage:   "each player's longest kill streak, the most kills without dying in between, and its match",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return streaks(qlpstats.Streaks(matches)) },
+	},
+	{
+		name:    "summary",
+		usage:   "medians and percentiles of kills per match, match duration and kills by weapon",
+		compute: func(matches qlp.Matches, env *reportEnv) any { return buildSummary(matches, env.loc) },
+	},
+	{
+		name:    "timeline",
+		usage:   "kills by weapon over each match, in one minute intervals, to chart when each weapon dominated",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, env *reportEnv) any { return buildTimelines(matches, env.loc) },
+	},
+	{
+		name:    "trends",
+		usage:   "each player's K/D ratio over successive matches, with a moving average and best and worst games",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return trends(qlpstats.Trends(matches)) },
+	},
+	{
+		name:  "unique-players",
+		usage: "an estimate of how many people played, grouping the names which connected from the same IP address",
+		opts:  qlp.Options{ClientIPs: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any {
+			return uniquePlayers(qlpstats.EstimateUniquePlayers(matches))
+		},
+	},
+	{
+		name:    "votes",
+		usage:   "the votes called in each match, who called them, what for and whether they passed",
+		opts:    qlp.Options{Votes: true},
+		compute: func(matches qlp.Matches, _ *reportEnv) any { return buildVoteReport(matches) },
+	},
+	{
+		name:    "weapons",
+		usage:   "for each weapon, the top 10 players by kills with it over every match",
+		opts:    qlp.Options{KillFeed: true},
+		compute: func(matches qlp.Matches, env *reportEnv) any { return buildWeaponLeaderboards(matches, env.loc) },
+	},
+}
+
+// findReport returns the report with the given name.
+func findReport(name string) (report, bool) {
+	index := slices.IndexFunc(reports, func(r report) bool { return r.name == name })
+	if index < 0 {
+		return report{}, false
+	}
+	return reports[index], true
+}
+
+// reportCommand returns the "report" subcommand, which computes reports over the matches of
+// the given logs.
+func reportCommand() *cli.Command {
+	var descriptions []string
+	for _, r := range reports {
+		descriptions = append(descriptions, fmt.Sprintf("  %-12s %s", r.name, r.usage))
+	}
+
+	return &cli.Command{
+		Name:        "report",
+		Usage:       "Computes a report over the matches of log files.",
+		ArgsUsage:   "<report> <file...>",
+		Description: "Available reports:\n" + strings.Join(descriptions, "\n"),
+		Flags:       reportFlags(),
+		Action: func(c *cli.Context) error {
+			files, err := inputFiles(c)
+			if err != nil {
+				return err
+			}
+			if len(files) < 2 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			r, ok := findReport(files[0])
+			if !ok {
+				return cli.Exit(fmt.Sprintf("Unknown report: %s", files[0]), exitUsage)
+			}
+			return runReport(c, r, files[1:])
+		},
+	}
+}
+
+// reportFlags returns the flags of the "report" subcommand.
+func reportFlags() []cli.Flag {
+	return append(append(append(inputFlags(), fileFlags()...),
+		&cli.PathFlag{
+			Name:  "geoip",
+			Usage: "find the countries of the players, for logs which record their IP addresses, in the MaxMind DB `FILE`",
+		},
+		&cli.BoolFlag{
+			Name:  "no-geoip",
+			Usage: "do not look up the countries of the players, even if a database is configured",
+		},
+		&cli.BoolFlag{
+			Name:  "no-ips",
+			Usage: "never read the IP addresses of the players, for privacy, which implies --no-geoip and leaves each name a person of its own in unique-players",
+		},
+		&cli.StringFlag{
+			Name:  "lang",
+			Usage: "write the text of the report, such as weapon names, in `LANGUAGE` (en or pt-BR), instead of the one of LANG",
+		},
+	), outputFlags...)
+}
+
+// runReport computes r over the matches of files and writes it out as the flags of the
+// "report" subcommand tell.
+func runReport(c *cli.Context, r report, files []string) error {
+	loc, err := findLocale(c.String("lang"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Invalid language: %s", err), exitUsage)
+	}
+	config, fileConfig, err := newParseConfig(c, r.opts)
+	if err != nil {
+		return err
+	}
+	clans, err := newClanMatcher(fileConfig.ClanPatterns)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+	}
+	env := &reportEnv{loc: loc, clans: clans}
+	if database := cmp.Or(c.Path("geoip"), fileConfig.GeoIPDatabase); database != "" && !c.Bool("no-geoip") && !c.Bool("no-ips") {
+		if env.geo, err = openGeoIP(database); err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to open GeoIP database: %s", err), exitOpen)
+		}
+		defer env.geo.Close()
+	}
+
+	config.opts.ClientIPs = (r.opts.ClientIPs || env.geo != nil) && !c.Bool("no-ips")
+	var matches qlp.Matches
+	for _, filePath := range files {
+		err := parseFile(filePath, config, func(match qlp.Match) error {
+			matches = append(matches, match)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	output, err := openOutput(c)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	if err := writeFormatted(output, output.format, r.compute(matches, env)); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to write report: %s", err), exitWrite)
+	}
+	if err := output.Close(); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to write report: %s", err), exitWrite)
+	}
+	return nil
+}
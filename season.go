@@ -0,0 +1,391 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+	"github.com/urfave/cli/v2"
+)
+
+// dateLayout is the layout of the dates of --boundary and of the seasons.
+const dateLayout = "2006-01-02"
+
+// season is the record of the matches played within a period of time.
+type season struct {
+	Name string `json:"season"`
+	// Start and End are the first day of the season and the day after its last day.
+	Start   string `json:"start"`
+	End     string `json:"end,omitempty"`
+	Files   int    `json:"files"`
+	Matches int    `json:"matches"`
+	// Standings ranks the players of the season by their kills, as the leaderboard does.
+	Standings []seasonStanding `json:"standings"`
+	// Maps holds the records of each map played in the season.
+	Maps map[string]mapRecord `json:"maps"`
+}
+
+// seasonStanding is the line of a player in the standings of a season.
+type seasonStanding struct {
+	Rank    int     `json:"rank"`
+	Player  string  `json:"player"`
+	Kills   int     `json:"kills"`
+	Deaths  int     `json:"deaths"`
+	KD      float64 `json:"kd"`
+	Matches int     `json:"matches"`
+	// Rating is the player's Elo rating at the end of the season, every player starting the
+	// season at qlpstats.InitialRating.
+	Rating float64 `json:"rating"`
+}
+
+// mapRecord holds the records of a map in a season.
+type mapRecord struct {
+	Matches int `json:"matches"`
+	// TopPlayer is the player with the most kills on the map over the season.
+	TopPlayer string `json:"top_player"`
+	TopKills  int    `json:"top_kills"`
+	// RecordPlayer scored the most kills in a single match on the map, RecordKills, in the
+	// RecordMatch of the season, the earliest match winning ties. Matches are numbered from 1.
+	RecordPlayer string `json:"record_player"`
+	RecordKills  int    `json:"record_kills"`
+	RecordMatch  int    `json:"record_match"`
+}
+
+// seasons is the output of the "season" subcommand.
+type seasons []season
+
+// Table lists one player of a season per row, in order of season and rank.
+func (ss seasons) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"season", "rank", "player", "kills", "deaths", "kd", "matches", "rating"}}
+	for _, s := range ss {
+		for _, standing := range s.Standings {
+			t.Rows = append(t.Rows, []string{
+				s.Name, strconv.Itoa(standing.Rank), standing.Player, strconv.Itoa(standing.Kills),
+				strconv.Itoa(standing.Deaths), formatFloat(standing.KD), strconv.Itoa(standing.Matches),
+				formatFloat(standing.Rating),
+			})
+		}
+	}
+	return t
+}
+
+// seasonPeriod tells which season the matches played at a given time belong to.
+type seasonPeriod interface {
+	// of returns the name and the first day of the season of t, and the first day of the next
+	// season, which is zero if there is none. It reports false if t is in no season.
+	of(t time.Time) (name string, start, end time.Time, ok bool)
+}
+
+// calendarPeriod divides time into seasons of a number of calendar months.
+type calendarPeriod struct {
+	months int // 1, 3 or 12
+}
+
+func (p calendarPeriod) of(t time.Time) (string, time.Time, time.Time, bool) {
+	month := (int(t.Month())-1)/p.months*p.months + 1
+	start := time.Date(t.Year(), time.Month(month), 1, 0, 0, 0, 0, t.Location())
+	end := start.AddDate(0, p.months, 0)
+	switch p.months {
+	case 12:
+		return strconv.Itoa(t.Year()), start, end, true
+	case 3:
+		return fmt.Sprintf("%d-Q%d", t.Year(), month/3+1), start, end, true
+	default:
+		return start.Format("2006-01"), start, end, true
+	}
+}
+
+// boundaryPeriod divides time into seasons starting at the given days, in order. Matches
+// played before the first one are in no season.
+type boundaryPeriod []time.Time
+
+func (p boundaryPeriod) of(t time.Time) (string, time.Time, time.Time, bool) {
+	i, found := slices.BinarySearchFunc(p, t, func(boundary, t time.Time) int { return boundary.Compare(t) })
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return "", time.Time{}, time.Time{}, false
+	}
+	var end time.Time
+	if i+1 < len(p) {
+		end = p[i+1]
+	}
+	return p[i].Format(dateLayout), p[i], end, true
+}
+
+// newSeasonPeriod returns the seasonPeriod set by the flags of the "season" subcommand. Its
+// errors are ready to be returned from a cli.ActionFunc.
+func newSeasonPeriod(c *cli.Context) (seasonPeriod, error) {
+	if dates := c.StringSlice("boundary"); len(dates) > 0 {
+		var period boundaryPeriod
+		for _, date := range dates {
+			boundary, err := time.ParseInLocation(dateLayout, date, time.Local)
+			if err != nil {
+				return nil, cli.Exit(fmt.Sprintf("Invalid season boundary: %s", date), exitUsage)
+			}
+			period = append(period, boundary)
+		}
+		slices.SortFunc(period, time.Time.Compare)
+		return slices.CompactFunc(period, time.Time.Equal), nil
+	}
+
+	switch period := c.String("period"); period {
+	case "month":
+		return calendarPeriod{months: 1}, nil
+	case "", "quarter":
+		return calendarPeriod{months: 3}, nil
+	case "year":
+		return calendarPeriod{months: 12}, nil
+	default:
+		return nil, cli.Exit(fmt.Sprintf("Invalid season period: %s", period), exitUsage)
+	}
+}
+
+// seasonFile is a log file along with when it was last written.
+type seasonFile struct {
+	path    string
+	modTime time.Time
+}
+
+// findSeasonFiles returns the regular files of the given directories, at any depth, and the
+// given files themselves, in order of modification time.
+func findSeasonFiles(paths []string) ([]seasonFile, error) {
+	var files []seasonFile
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.Type().IsRegular() {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			files = append(files, seasonFile{path: path, modTime: info.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.SortStableFunc(files, func(a, b seasonFile) int { return a.modTime.Compare(b.modTime) })
+	return files, nil
+}
+
+// fileNameDate matches the date in the name of a log file, such as games-2024-03-01.log.
+var fileNameDate = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// matchDate returns when the match of the given file was played, as told by dates: "log",
+// the start time of the match anchored on its g_timestamp setting or else the date in the name of the file, or "mtime",
+// when the file was last written. It reports false if the match has no date.
+func matchDate(match qlp.Match, file seasonFile, dates string) (time.Time, bool) {
+	if dates == "mtime" {
+		return file.modTime, true
+	}
+	if date, err := time.Parse(time.RFC3339, match.StartedAt); err == nil {
+		return date, true
+	}
+	if name := fileNameDate.FindString(filepath.Base(file.path)); name != "" {
+		if date, err := time.ParseInLocation(dateLayout, name, time.Local); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// datedMatch is a match along with when it was played and the index of its file.
+type datedMatch struct {
+	match qlp.Match
+	date  time.Time
+	file  int
+}
+
+// seasonCommand returns the "season" subcommand, which computes the standings of the seasons
+// of logs spanning months.
+func seasonCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "season",
+		Usage:     "Computes the standings and map records of each season of the logs in directories.",
+		ArgsUsage: "<dir|file...>",
+		Description: "Parses every file in the given directories, at any depth, and groups their matches into " +
+			"seasons by when they were played: by the g_timestamp setting of each match or else by the " +
+			"YYYY-MM-DD date in the name of its file, or by when each file was last written with " +
+			"--dates mtime. Files which fail to parse and matches without a date are skipped with a " +
+			"warning. Each season has the kills, deaths, K/D ratio and Elo rating of its players and the " +
+			"records of each map.",
+		Flags: append(append(inputFlags(),
+			&cli.StringFlag{
+				Name:  "period",
+				Value: "quarter",
+				Usage: "the length of the seasons: month, quarter or year, as in the calendar",
+			},
+			&cli.StringSliceFlag{
+				Name:  "boundary",
+				Usage: "start a season on `DATE` (YYYY-MM-DD), instead of the seasons of --period; may be repeated, and matches played before the first date are left out",
+			},
+			&cli.StringFlag{
+				Name:  "dates",
+				Value: "log",
+				Usage: "where the dates of the matches come from: log, their g_timestamp setting or else the name of their file, or mtime, when their file was last written",
+			},
+		), outputFlags...),
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			period, err := newSeasonPeriod(c)
+			if err != nil {
+				return err
+			}
+			dates := c.String("dates")
+			if dates != "log" && dates != "mtime" {
+				return cli.Exit(fmt.Sprintf("Invalid source of dates: %s", dates), exitUsage)
+			}
+			config, _, err := newParseConfig(c, qlp.Options{KillFeed: true})
+			if err != nil {
+				return err
+			}
+			files, err := findSeasonFiles(c.Args().Slice())
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to list logs: %s", err), exitOpen)
+			}
+
+			var dated []datedMatch
+			for i, file := range files {
+				var matches qlp.Matches
+				err := parseFile(file.path, config, func(match qlp.Match) error {
+					matches = append(matches, match)
+					return nil
+				})
+				if err != nil {
+					// The directories of logs often hold other files, such as notes or archives.
+					fmt.Fprintf(os.Stderr, "Warning: %s (skipped)\n", err)
+					continue
+				}
+
+				undated := 0
+				for _, match := range matches {
+					date, ok := matchDate(match, file, dates)
+					if !ok {
+						undated++
+						continue
+					}
+					dated = append(dated, datedMatch{match: match, date: date, file: i})
+				}
+				if undated > 0 {
+					fmt.Fprintf(os.Stderr, "Warning: %d matches of %s have no date (skipped)\n", undated, file.path)
+				}
+			}
+			slices.SortStableFunc(dated, func(a, b datedMatch) int { return a.date.Compare(b.date) })
+
+			result := seasons{}
+			var matches qlp.Matches
+			var current *season
+			var seasonFiles map[int]bool
+			for _, match := range dated {
+				name, start, end, ok := period.of(match.date)
+				if !ok {
+					continue
+				}
+				if current == nil || current.Name != name {
+					if current != nil {
+						current.Files = len(seasonFiles)
+						result = append(result, buildSeason(*current, matches))
+					}
+					current = &season{Name: name, Start: start.Format(dateLayout)}
+					if !end.IsZero() {
+						current.End = end.Format(dateLayout)
+					}
+					matches, seasonFiles = nil, make(map[int]bool)
+				}
+				matches = append(matches, match.match)
+				seasonFiles[match.file] = true
+			}
+			if current != nil {
+				current.Files = len(seasonFiles)
+				result = append(result, buildSeason(*current, matches))
+			}
+
+			output, err := openOutput(c)
+			if err != nil {
+				return err
+			}
+			defer output.Close()
+
+			if err := writeFormatted(output, output.format, result); err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to write seasons: %s", err), exitWrite)
+			}
+			if err := output.Close(); err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to write seasons: %s", err), exitWrite)
+			}
+			return nil
+		},
+	}
+}
+
+// buildSeason completes s with the standings and the map records of its matches. It needs the
+// kill feed of the matches.
+func buildSeason(s season, matches qlp.Matches) season {
+	s.Matches = len(matches)
+	s.Maps = make(map[string]mapRecord)
+
+	ratings := qlpstats.Ratings(matches)
+	deaths := make(map[string]int)
+	matchesPlayed := make(map[string]int)
+	mapKills := make(map[string]map[string]int)
+	for i, match := range matches {
+		for _, kill := range match.KillFeed {
+			deaths[kill.Victim]++
+		}
+
+		record := s.Maps[match.MapName]
+		record.Matches++
+		if mapKills[match.MapName] == nil {
+			mapKills[match.MapName] = make(map[string]int)
+		}
+		for _, player := range match.Players {
+			kills := match.Kills[player]
+			matchesPlayed[player]++
+			mapKills[match.MapName][player] += kills
+			if record.RecordPlayer == "" || kills > record.RecordKills {
+				record.RecordPlayer, record.RecordKills, record.RecordMatch = player, kills, i+1
+			}
+		}
+		s.Maps[match.MapName] = record
+	}
+
+	for mapName, kills := range mapKills {
+		record := s.Maps[mapName]
+		for _, player := range sortedKeys(kills) {
+			if record.TopPlayer == "" || kills[player] > record.TopKills {
+				record.TopPlayer, record.TopKills = player, kills[player]
+			}
+		}
+		s.Maps[mapName] = record
+	}
+
+	s.Standings = []seasonStanding{}
+	for _, standing := range qlpstats.Leaderboard(matches) {
+		s.Standings = append(s.Standings, seasonStanding{
+			Rank:    standing.Rank,
+			Player:  standing.Player,
+			Kills:   standing.Kills,
+			Deaths:  deaths[standing.Player],
+			KD:      qlpstats.KDRatio(standing.Kills, deaths[standing.Player]),
+			Matches: matchesPlayed[standing.Player],
+			Rating:  math.Round(cmp.Or(ratings[standing.Player], qlpstats.InitialRating)),
+		})
+	}
+	return s
+}
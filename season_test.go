@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestMatchDate(t *testing.T) {
+	modTime := time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local)
+	file := seasonFile{path: filepath.Join("2025-01-01", "games-2026-03-01.log"), modTime: modTime}
+	stamped := qlp.Match{StartedAt: "2026-02-14T21:30:00-03:00"}
+
+	date, ok := matchDate(stamped, file, "log")
+	assert.True(t, ok)
+	assert.True(t, time.Date(2026, 2, 15, 0, 30, 0, 0, time.UTC).Equal(date), date)
+
+	// only the name of the file is searched for a date, not its directories
+	date, ok = matchDate(qlp.Match{}, file, "log")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), date)
+
+	date, ok = matchDate(stamped, file, "mtime")
+	assert.True(t, ok)
+	assert.Equal(t, modTime, date)
+
+	_, ok = matchDate(qlp.Match{}, seasonFile{path: "games.log", modTime: modTime}, "log")
+	assert.False(t, ok)
+}
+
+// runSeason runs the "season" subcommand with args, returning its seasons and error.
+func runSeason(t *testing.T, args ...string) (seasons, error) {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "seasons.json")
+	app := &cli.App{
+		Commands:       []*cli.Command{seasonCommand()},
+		ExitErrHandler: func(*cli.Context, error) {},
+	}
+	err := app.Run(append([]string{"qlp", "season", "--format", "json", "-o", output}, args...))
+	var result seasons
+	if data, readErr := os.ReadFile(output); readErr == nil {
+		assert.NoError(t, json.Unmarshal(data, &result))
+	}
+	return result, err
+}
+
+func TestSeasonCommand(t *testing.T) {
+	dir := t.TempDir()
+	logs := map[string]string{
+		// the matches of a file may be played in different seasons
+		"games.log": "  0:00 InitGame: \\mapname\\q3dm17\\g_timestamp\\2026-03-30 20:00:00\n" +
+			"  0:10 Kill: 2 3 7: Isgalamido killed Zeh by MOD_ROCKET_SPLASH\n" +
+			"  0:20 " + fixtureSeparator + "\n" +
+			"  0:00 InitGame: \\mapname\\q3dm6\\g_timestamp\\2026-04-02 20:00:00\n" +
+			"  0:10 Kill: 3 2 7: Zeh killed Isgalamido by MOD_ROCKET_SPLASH\n" +
+			"  0:20 " + fixtureSeparator + "\n",
+		"games-2026-05-10.log": "  0:00 InitGame: \\mapname\\q3dm6\n" +
+			"  0:10 Kill: 3 2 7: Zeh killed Isgalamido by MOD_ROCKET_SPLASH\n" +
+			"  0:20 " + fixtureSeparator + "\n",
+		"README": "These are the logs of the league.\n",
+	}
+	for name, log := range logs {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(log), 0o644))
+	}
+
+	result, err := runSeason(t, dir)
+	assert.NoError(t, err)
+	if assert.Len(t, result, 2) {
+		assert.Equal(t, "2026-Q1", result[0].Name)
+		assert.Equal(t, 1, result[0].Files)
+		assert.Equal(t, 1, result[0].Matches)
+		assert.Equal(t, "Isgalamido", result[0].Standings[0].Player)
+
+		assert.Equal(t, "2026-Q2", result[1].Name)
+		assert.Equal(t, "2026-04-01", result[1].Start)
+		assert.Equal(t, "2026-07-01", result[1].End)
+		assert.Equal(t, 2, result[1].Files)
+		assert.Equal(t, 2, result[1].Matches)
+		assert.Equal(t, 2, result[1].Maps["q3dm6"].Matches)
+	}
+
+	_, err = runSeason(t, "--dates", "ctime", dir)
+	assert.EqualError(t, err, "Invalid source of dates: ctime")
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlphttp"
+	"github.com/urfave/cli/v2"
+)
+
+// shutdownTimeout is how long in-flight requests are given to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
+// pruneInterval is how often the matches which ended more than --max-age ago are pruned.
+const pruneInterval = time.Minute
+
+// serveCommand returns the "serve" subcommand, which serves the matches of logs over HTTP.
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "serve",
+		Usage:     "Serves the matches of log files over HTTP.",
+		ArgsUsage: "[file...]",
+		Description: "Parses the files and serves their matches as JSON at GET /matches, each match at " +
+			"GET /matches/{n}, the ranking of the players at GET /ranking and the statistics of each " +
+			"player at GET /players/{name}, and a page showing them at GET /scoreboard. Servers listed " +
+			"in the configuration file, each with its own logs, are served separately under " +
+			"/servers/{id}/, such as GET /servers/{id}/ranking. With --upload, POST /parse parses the log in " +
+			"the body of the request, raw or gzipped, and responds with its matches; with --jobs, POST /jobs " +
+			"parses it in the background, and GET /jobs/{id} follows its progress. When started " +
+			"through a systemd socket unit, the socket passed by systemd is used instead of --listen, " +
+			"and readiness is reported to systemd once the logs are parsed. Local log files are then " +
+			"followed as the game servers write them, each match being served as soon as it ends.\n\n" +
+			"SIGTERM and SIGINT shut the server down gracefully, letting in-flight requests finish. " +
+			"SIGHUP reloads the configuration and parses the files again.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Value: ":8080",
+				Usage: "listen on `ADDRESS`, unless socket activated",
+			},
+			&cli.PathFlag{
+				Name:  "config",
+				Usage: "read settings, such as extra event rules, from the YAML or JSON `FILE`",
+			},
+			&cli.PathFlag{
+				Name:  "audit-log",
+				Usage: "append a line of JSON for every file parsed, including on reloads, to `FILE`",
+			},
+			&cli.PathFlag{
+				Name:  "pid-file",
+				Usage: "write the process ID to `FILE`, which is removed on exit",
+			},
+			&cli.BoolFlag{
+				Name:  "upload",
+				Usage: "parse logs uploaded to POST /parse",
+			},
+			&cli.Int64Flag{
+				Name:  "max-upload-size",
+				Value: qlphttp.DefaultMaxUploadSize,
+				Usage: "refuse uploaded logs larger than `BYTES`, once decompressed",
+			},
+			&cli.DurationFlag{
+				Name:  "parse-timeout",
+				Value: qlphttp.DefaultParseTimeout,
+				Usage: "give up on uploaded logs which take longer than `DURATION` to upload and parse",
+			},
+			&cli.BoolFlag{
+				Name:  "jobs",
+				Usage: "parse logs uploaded to POST /jobs in the background",
+			},
+			&cli.IntFlag{
+				Name:  "job-concurrency",
+				Value: qlphttp.DefaultJobConcurrency,
+				Usage: "parse up to `N` jobs at a time",
+			},
+			&cli.DurationFlag{
+				Name:  "job-retention",
+				Value: qlphttp.DefaultJobRetention,
+				Usage: "keep the results of jobs for `DURATION` once parsed",
+			},
+			&cli.IntFlag{
+				Name:  "max-jobs",
+				Usage: "keep the results of the latest `N` jobs only",
+			},
+			&cli.IntFlag{
+				Name:  "max-queued-jobs",
+				Value: qlphttp.DefaultMaxQueuedJobs,
+				Usage: "refuse jobs while `N` jobs are waiting to be parsed",
+			},
+			&cli.Int64Flag{
+				Name:  "max-job-disk",
+				Value: qlphttp.DefaultMaxJobDisk,
+				Usage: "refuse jobs while the logs waiting to be parsed take more than `BYTES` on disk",
+			},
+			&cli.Int64Flag{
+				Name:  "max-result-disk",
+				Value: qlphttp.DefaultMaxResultDisk,
+				Usage: "forget the jobs which finished first while their matches take more than `BYTES` on disk",
+			},
+			&cli.IntFlag{
+				Name:  "max-matches",
+				Usage: "serve the latest `N` matches of the logs, and of each server, only",
+			},
+			&cli.DurationFlag{
+				Name:  "max-age",
+				Usage: "leave out the matches which ended more than `DURATION` ago, as told by their g_timestamp setting or else by when their file was last written, pruning them as they age",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 && c.Path("config") == "" && !c.Bool("upload") && !c.Bool("jobs") {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			logs := &servedLogs{
+				files:      c.Args().Slice(),
+				configPath: c.Path("config"),
+				maxMatches: c.Int("max-matches"),
+				maxAge:     c.Duration("max-age"),
+			}
+			if filePath := c.Path("audit-log"); filePath != "" {
+				audit, err := openAuditLog(filePath)
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to open audit log: %s", err), exitOpen)
+				}
+				defer audit.Close()
+				logs.audit = audit
+			}
+			if err := logs.load(); err != nil {
+				return err
+			}
+			defer logs.stop()
+
+			if pidFile := c.Path("pid-file"); pidFile != "" {
+				if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to write PID file: %s", err), exitUsage)
+				}
+				defer os.Remove(pidFile)
+			}
+
+			listener, err := activationListener()
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to listen: %s", err), exitUsage)
+			}
+			if listener == nil {
+				if listener, err = net.Listen("tcp", c.String("listen")); err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to listen: %s", err), exitUsage)
+				}
+			}
+
+			handler := qlphttp.NewHandlerWithOptions(logs, qlphttp.HandlerOptions{
+				Upload:         c.Bool("upload"),
+				MaxUploadSize:  c.Int64("max-upload-size"),
+				ParseTimeout:   c.Duration("parse-timeout"),
+				Jobs:           c.Bool("jobs"),
+				JobConcurrency: c.Int("job-concurrency"),
+				JobRetention:   c.Duration("job-retention"),
+				MaxJobs:        c.Int("max-jobs"),
+				MaxQueuedJobs:  c.Int("max-queued-jobs"),
+				MaxJobDisk:     c.Int64("max-job-disk"),
+				MaxResultDisk:  c.Int64("max-result-disk"),
+			})
+			// event streams never end on their own, so they are ended as the server shuts down
+			streams, endStreams := context.WithCancel(context.Background())
+			defer endStreams()
+			server := &http.Server{Handler: handler, BaseContext: func(net.Listener) context.Context { return streams }}
+			server.RegisterOnShutdown(endStreams)
+			served := make(chan error, 1)
+			go func() { served <- server.Serve(listener) }()
+			notifySystemd("READY=1")
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+			defer signal.Stop(signals)
+
+			// matches age out of --max-age while being served
+			var prunes <-chan time.Time
+			if logs.maxAge > 0 {
+				ticker := time.NewTicker(pruneInterval)
+				defer ticker.Stop()
+				prunes = ticker.C
+			}
+
+			for {
+				select {
+				case err := <-served:
+					return cli.Exit(fmt.Sprintf("Failed to serve: %s", err), exitUsage)
+				case <-prunes:
+					logs.prune()
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						notifySystemd("RELOADING=1")
+						if err := logs.load(); err != nil {
+							// the logs loaded before are kept
+							fmt.Fprintf(os.Stderr, "Error: failed to reload: %v\n", err)
+						}
+						notifySystemd("READY=1")
+						continue
+					}
+
+					notifySystemd("STOPPING=1")
+					ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+					defer cancel()
+					if err := server.Shutdown(ctx); err != nil {
+						return cli.Exit(fmt.Sprintf("Failed to shut down: %s", err), exitUsage)
+					}
+					if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+						return cli.Exit(fmt.Sprintf("Failed to serve: %s", err), exitUsage)
+					}
+					return nil
+				}
+			}
+		},
+	}
+}
+
+// notifySystemd sends a state to systemd, warning about failures, which are not fatal.
+func notifySystemd(state string) {
+	if err := sdNotify(state); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to notify systemd: %s\n", err)
+	}
+}
+
+// servedLogs is the qlphttp.Store of the "serve" subcommand. It holds the matches of the logs
+// given as arguments and those of each server of the configuration file. They are replaced as
+// a whole when the logs are reloaded, and copied along with each match added as the logs are
+// followed, so each request sees a consistent set of matches, and the clients of GET /events
+// are then notified.
+type servedLogs struct {
+	files      []string
+	configPath string
+	audit      *auditLog
+	// maxMatches and maxAge, when positive, limit the matches served to the latest ones and
+	// to those which ended recently, as told by ended.
+	maxMatches int
+	maxAge     time.Duration
+	state      atomic.Pointer[qlphttp.MemoryStore]
+
+	// mu serializes the changes to the matches.
+	mu sync.Mutex
+	// changed is closed once the matches are replaced. It is only allocated when asked for.
+	changed chan struct{}
+	// stopFollowing stops following the logs, before they are parsed again.
+	stopFollowing context.CancelFunc
+	// ended holds when the matches served ended, by hash, with maxAge only. Matches without
+	// Match.EndedAt ended when their file was last written, as of parsing, or when following
+	// the file yielded them.
+	ended map[string]time.Time
+}
+
+// load parses the logs, according to the configuration file, and replaces the matches being
+// served. On failure, the matches are left untouched.
+func (l *servedLogs) load() error {
+	fileConfig, err := loadConfig(l.configPath)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+	}
+	eventRules, err := fileConfig.eventRules()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to load configuration: %s", err), exitUsage)
+	}
+
+	// the kill feed is served along with the matches
+	config := parseConfig{opts: qlp.Options{EventRules: eventRules, KillFeed: true}, jobs: 1, audit: l.audit}
+	state := &qlphttp.MemoryStore{Sources: make(map[string]qlp.Matches, len(fileConfig.Servers))}
+	ended := make(map[string]time.Time)
+	var followers []func(context.Context)
+	if state.Logs, followers, err = l.parse("", l.files, config, ended); err != nil {
+		return err
+	}
+	for _, server := range fileConfig.Servers {
+		matches, serverFollowers, err := l.parse(server.ID, server.Logs, config, ended)
+		if err != nil {
+			return err
+		}
+		state.Sources[server.ID] = matches
+		followers = append(followers, serverFollowers...)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	l.mu.Lock()
+	if l.stopFollowing != nil {
+		l.stopFollowing()
+	}
+	l.stopFollowing = stop
+	l.state.Store(state)
+	l.ended = ended
+	l.notify()
+	l.mu.Unlock()
+	for _, follow := range followers {
+		go follow(ctx)
+	}
+	return nil
+}
+
+// stop stops following the logs.
+func (l *servedLogs) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopFollowing != nil {
+		l.stopFollowing()
+	}
+}
+
+// notify notifies the clients waiting for the matches to change. l.mu must be held.
+func (l *servedLogs) notify() {
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+}
+
+// parse parses the log files of source, the id of a game server or "" for the files given as
+// arguments, according to config, recording when the matches ended in ended and keeping the
+// ones retained. It also returns the functions following the files which can be followed, as
+// told by followable, which add the matches ending from then on to source. Their last match
+// may then still be open, and is left for following to add once it ends.
+func (l *servedLogs) parse(source string, files []string, config parseConfig, ended map[string]time.Time) (qlp.Matches, []func(context.Context), error) {
+	matches := qlp.Matches{}
+	var followed []string
+	for _, filePath := range files {
+		// logs which cannot be told when they were written, such as remote ones, are as old
+		// as the parsing
+		written := time.Now()
+		if info, err := os.Stat(filePath); err == nil {
+			written = info.ModTime()
+		}
+		err := parseFile(filePath, config, func(match qlp.Match) error {
+			matches = append(matches, match)
+			if l.maxAge > 0 {
+				ended[match.MatchHash] = matchEnd(match, written)
+			}
+			return nil
+		})
+		if followable(filePath) {
+			followed = append(followed, filePath)
+			if errors.Is(err, qlp.ErrUnfinishedMatch) {
+				err = nil
+			}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// following reads the files from their start, yielding the matches parsed so far again
+	var followers []func(context.Context)
+	for _, filePath := range followed {
+		seen := qlp.NewDeduper(qlp.DedupeDrop)
+		for _, match := range matches {
+			seen.Filter(&match)
+		}
+		followers = append(followers, func(ctx context.Context) {
+			l.follow(ctx, source, filePath, config.opts, seen)
+		})
+	}
+
+	return l.retain(matches, ended, time.Now()), followers, nil
+}
+
+// matchEnd returns when the match ended: Match.EndedAt, for logs with the g_timestamp setting,
+// or else fallback.
+func matchEnd(match qlp.Match, fallback time.Time) time.Time {
+	if ended, err := time.Parse(time.RFC3339, match.EndedAt); err == nil {
+		return ended
+	}
+	return fallback
+}
+
+// retain returns the matches which are kept among the given ones: the latest maxMatches ones
+// of those which ended at most maxAge before now, as told by ended. The matches given are not
+// modified.
+func (l *servedLogs) retain(matches qlp.Matches, ended map[string]time.Time, now time.Time) qlp.Matches {
+	expired := func(match qlp.Match) bool { return now.Sub(ended[match.MatchHash]) > l.maxAge }
+	if l.maxAge > 0 && slices.ContainsFunc(matches, expired) {
+		matches = slices.DeleteFunc(slices.Clone(matches), expired)
+	}
+	if l.maxMatches > 0 && len(matches) > l.maxMatches {
+		matches = matches[len(matches)-l.maxMatches:]
+	}
+	return matches
+}
+
+// prune leaves out the matches which ended more than maxAge ago, notifying the clients of
+// GET /events if there were any.
+func (l *servedLogs) prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	current := l.state.Load()
+	state := &qlphttp.MemoryStore{
+		Logs:    l.retain(current.Logs, l.ended, now),
+		Sources: make(map[string]qlp.Matches, len(current.Sources)),
+	}
+	pruned := len(state.Logs) < len(current.Logs)
+	for id, matches := range current.Sources {
+		state.Sources[id] = l.retain(matches, l.ended, now)
+		pruned = pruned || len(state.Sources[id]) < len(matches)
+	}
+	if !pruned {
+		return
+	}
+	maps.DeleteFunc(l.ended, func(_ string, ended time.Time) bool { return now.Sub(ended) > l.maxAge })
+	l.state.Store(state)
+	l.notify()
+}
+
+// followable reports whether the log file at filePath is followed once parsed: local files
+// which are not compressed, as archived logs do not grow.
+func followable(filePath string) bool {
+	return filePath != "-" && !isRemote(filePath) && !qlp.IsCompressed(filePath)
+}
+
+// follow parses the log file at filePath as it grows, until ctx is cancelled, adding the
+// matches which were not seen yet to source. Following stops with a warning on failure, the
+// matches served so far being kept.
+func (l *servedLogs) follow(ctx context.Context, source, filePath string, opts qlp.Options, seen *qlp.Deduper) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to follow %s: %s\n", filePath, err)
+		return
+	}
+	defer file.Close()
+
+	matches, errs := qlp.NewParser(opts).Follow(ctx, file)
+	for match := range matches {
+		if seen.Filter(&match) {
+			l.add(ctx, source, match)
+		}
+	}
+	if err := <-errs; err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to follow %s: %s\n", filePath, err)
+	}
+}
+
+// add adds a match to the ones of source, keeping the ones retained, unless ctx
+// was cancelled, as happens once the logs are parsed again. The matches being served are
+// copied rather than modified, as requests may be reading them.
+func (l *servedLogs) add(ctx context.Context, source string, match qlp.Match) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+
+	state := *l.state.Load()
+	matches := state.Logs
+	if source != "" {
+		state.Sources = maps.Clone(state.Sources)
+		matches = state.Sources[source]
+	}
+	now := time.Now()
+	if l.maxAge > 0 {
+		l.ended[match.MatchHash] = matchEnd(match, now)
+	}
+	matches = l.retain(append(slices.Clip(matches), match), l.ended, now)
+	if source != "" {
+		state.Sources[source] = matches
+	} else {
+		state.Logs = matches
+	}
+	l.state.Store(&state)
+	l.notify()
+}
+
+// Matches implements qlphttp.Store.
+func (l *servedLogs) Matches() qlp.Matches {
+	return l.state.Load().Matches()
+}
+
+// Servers implements qlphttp.Store.
+func (l *servedLogs) Servers() []string {
+	return l.state.Load().Servers()
+}
+
+// Server implements qlphttp.Store.
+func (l *servedLogs) Server(id string) (qlphttp.Store, bool) {
+	store, ok := l.state.Load().Server(id)
+	if !ok {
+		return nil, false
+	}
+	return servedServer{Store: store, logs: l}, true
+}
+
+// Changed implements qlphttp.Notifier.
+func (l *servedLogs) Changed() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.changed == nil {
+		l.changed = make(chan struct{})
+	}
+	return l.changed
+}
+
+// servedServer is the store of a game server of servedLogs, whose matches change along with
+// them.
+type servedServer struct {
+	qlphttp.Store
+	logs *servedLogs
+}
+
+// Changed implements qlphttp.Notifier.
+func (s servedServer) Changed() <-chan struct{} {
+	return s.logs.Changed()
+}
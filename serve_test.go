@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServedLogsFollow(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "games.log")
+	// the second match is still being played
+	log := "  0:00 InitGame: \\mapname\\q3dm17\n" +
+		"  0:05 Kill: 0 1 7: Isgalamido killed Mocinha by MOD_ROCKET_SPLASH\n" +
+		"  1:00 " + fixtureSeparator + "\n" +
+		"  1:05 InitGame: \\mapname\\q3dm6\n" +
+		"  1:10 Kill: 1 0 10: Mocinha killed Isgalamido by MOD_RAILGUN\n"
+	assert.NoError(t, os.WriteFile(logPath, []byte(log), 0o644))
+
+	logs := &servedLogs{files: []string{logPath}}
+	assert.NoError(t, logs.load())
+	defer logs.stop()
+	assert.Len(t, logs.Matches(), 1)
+	changed := logs.Changed()
+
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	_, err = file.WriteString("  2:00 " + fixtureSeparator + "\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the match which ended was not served")
+	}
+	matches := logs.Matches()
+	if assert.Len(t, matches, 2) {
+		assert.Equal(t, "q3dm17", matches[0].MapName)
+		assert.Equal(t, "q3dm6", matches[1].MapName)
+	}
+}
+
+func TestServedLogsMaxAge(t *testing.T) {
+	// the first match ended a week ago and the second an hour ago
+	stamp := func(ago time.Duration) string {
+		return time.Now().Add(-ago).Format("2006-01-02 15:04:05")
+	}
+	log := "  0:00 InitGame: \\g_timestamp\\" + stamp(7*24*time.Hour) + "\\mapname\\q3dm17\n" +
+		"  1:00 " + fixtureSeparator + "\n" +
+		"  1:05 InitGame: \\g_timestamp\\" + stamp(time.Hour) + "\\mapname\\q3dm6\n" +
+		"  1:05 " + fixtureSeparator + "\n"
+	logPath := filepath.Join(t.TempDir(), "games.log")
+	assert.NoError(t, os.WriteFile(logPath, []byte(log), 0o644))
+
+	logs := &servedLogs{files: []string{logPath}, maxAge: 24 * time.Hour}
+	assert.NoError(t, logs.load())
+	defer logs.stop()
+	if assert.Len(t, logs.Matches(), 1) {
+		assert.Equal(t, "q3dm6", logs.Matches()[0].MapName)
+	}
+
+	// matches are pruned as they age
+	changed := logs.Changed()
+	logs.prune()
+	assert.Len(t, logs.Matches(), 1)
+	logs.maxAge = 30 * time.Minute
+	logs.prune()
+	assert.Empty(t, logs.Matches())
+	select {
+	case <-changed:
+	default:
+		t.Error("pruning was not announced")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// sinkTimeout bounds how long a sink may take to deliver a match.
+const sinkTimeout = 10 * time.Second
+
+// sink delivers finished matches somewhere outside of the program, such as a chat channel.
+type sink interface {
+	// send delivers the match numbered number, as in the "game_N" keys.
+	send(ctx context.Context, number int, match qlp.Match) error
+}
+
+// sinkSet delivers matches to every sink. Failures are reported on stderr instead of stopping
+// the parse, as chat services being down should not lose the output.
+type sinkSet []sink
+
+// send delivers the match to every sink, unless it is still in progress, returning how many
+// sinks failed to deliver it.
+func (sinks sinkSet) send(ctx context.Context, number int, match qlp.Match) (failed int) {
+	if match.InProgress {
+		return 0
+	}
+	for _, s := range sinks {
+		ctx, cancel := context.WithTimeout(ctx, sinkTimeout)
+		if err := s.send(ctx, number, match); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to deliver game_%d: %s\n", number, err)
+			failed++
+		}
+		cancel()
+	}
+	return failed
+}
+
+// matchHighlights holds what is worth announcing about a match.
+type matchHighlights struct {
+	// winner is empty when nobody scored.
+	winner string
+	// topFraggers holds up to three players with the most kills, best first.
+	topFraggers []string
+	// weapon is the display name of the means of death with the most kills, if any.
+	weapon string
+}
+
+// highlightsOf returns the highlights of a match, ties being broken alphabetically.
+func highlightsOf(match qlp.Match) matchHighlights {
+	var h matchHighlights
+
+	players := slices.Clone(match.Players)
+	slices.SortFunc(players, func(a, b string) int {
+		return cmp.Or(cmp.Compare(match.Kills[b], match.Kills[a]), cmp.Compare(a, b))
+	})
+	for _, player := range players[:min(3, len(players))] {
+		h.topFraggers = append(h.topFraggers, fmt.Sprintf("%s (%d)", player, match.Kills[player]))
+	}
+	if len(players) > 0 && match.Kills[players[0]] > 0 {
+		h.winner = players[0]
+	}
+
+	topMeans := ""
+	for _, means := range sortedKeys(match.KillsByMeans) {
+		if topMeans == "" || match.KillsByMeans[means] > match.KillsByMeans[topMeans] {
+			topMeans = means
+		}
+	}
+	if topMeans != "" {
+		h.weapon = locales[defaultLocale].meansName(topMeans)
+	}
+	return h
+}
+
+// postJSON posts payload, encoded as JSON, to url, failing unless the response is a success.
+func postJSON(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+	return nil
+}
+
+// orNone returns s, or a dash if s is empty, as chat services reject empty fields.
+func orNone(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+// discordSink posts an embed to a Discord webhook for each match.
+type discordSink struct {
+	url string
+}
+
+// discordColor is the color of the embeds' side bar.
+const discordColor = 0xc0392b
+
+// send implements sink.
+func (d discordSink) send(ctx context.Context, number int, match qlp.Match) error {
+	h := highlightsOf(match)
+	type field struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Inline bool   `json:"inline"`
+	}
+	embed := map[string]any{
+		"title": fmt.Sprintf("game_%d finished on %s", number, orNone(match.MapName)),
+		"color": discordColor,
+		"fields": []field{
+			{Name: "Winner", Value: orNone(h.winner), Inline: true},
+			{Name: "Kills", Value: fmt.Sprint(match.TotalKills), Inline: true},
+			{Name: "Weapon of the match", Value: orNone(h.weapon), Inline: true},
+			{Name: "Top fraggers", Value: orNone(strings.Join(h.topFraggers, "\n"))},
+		},
+	}
+	if match.Server.Hostname != "" {
+		embed["footer"] = map[string]string{"text": match.Server.Hostname}
+	}
+	// player names such as "@everyone" must not ping anybody
+	return postJSON(ctx, d.url, map[string]any{
+		"embeds":           []any{embed},
+		"allowed_mentions": map[string][]string{"parse": {}},
+	})
+}
+
+// slackEscaper escapes the characters which Slack reads as markup, such as in "<!channel>"
+// and links.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackSink posts a Block Kit message to a Slack incoming webhook for each match.
+type slackSink struct {
+	url string
+	// channel overrides the default channel of the webhook, if not empty.
+	channel string
+}
+
+// send implements sink.
+func (s slackSink) send(ctx context.Context, number int, match qlp.Match) error {
+	h := highlightsOf(match)
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	// plain_text is shown as is, so only mrkdwn and the top-level text are escaped
+	escape := slackEscaper.Replace
+	title := fmt.Sprintf("game_%d finished on %s", number, orNone(match.MapName))
+	blocks := []any{
+		map[string]any{"type": "header", "text": text{Type: "plain_text", Text: title}},
+		map[string]any{"type": "section", "fields": []text{
+			{Type: "mrkdwn", Text: "*Winner*\n" + escape(orNone(h.winner))},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Kills*\n%d", match.TotalKills)},
+			{Type: "mrkdwn", Text: "*Weapon of the match*\n" + escape(orNone(h.weapon))},
+			{Type: "mrkdwn", Text: "*Top fraggers*\n" + escape(orNone(strings.Join(h.topFraggers, "\n")))},
+		}},
+	}
+	if match.Server.Hostname != "" {
+		blocks = append(blocks, map[string]any{
+			"type":     "context",
+			"elements": []text{{Type: "plain_text", Text: match.Server.Hostname}},
+		})
+	}
+
+	// text is shown by notifications, which do not render blocks.
+	payload := map[string]any{"text": escape(title), "blocks": blocks}
+	if s.channel != "" {
+		payload["channel"] = s.channel
+	}
+	return postJSON(ctx, s.url, payload)
+}
+
+// telegramAPI is the base URL of the Telegram Bot API.
+const telegramAPI = "https://api.telegram.org"
+
+// telegramSink sends a message through a Telegram bot to a chat for each match.
+type telegramSink struct {
+	token  string
+	chatID string
+}
+
+// send implements sink.
+func (t telegramSink) send(ctx context.Context, number int, match qlp.Match) error {
+	h := highlightsOf(match)
+	lines := []string{
+		fmt.Sprintf("<b>game_%d finished on %s</b>", number, html.EscapeString(orNone(match.MapName))),
+		"Winner: " + html.EscapeString(orNone(h.winner)),
+		fmt.Sprintf("Kills: %d", match.TotalKills),
+		"Weapon of the match: " + html.EscapeString(orNone(h.weapon)),
+	}
+	if len(h.topFraggers) > 0 {
+		lines = append(lines, "", "<b>Top fraggers</b>")
+		for _, fragger := range h.topFraggers {
+			lines = append(lines, html.EscapeString(fragger))
+		}
+	}
+	if match.Server.Hostname != "" {
+		lines = append(lines, "", "<i>"+html.EscapeString(match.Server.Hostname)+"</i>")
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPI, t.token)
+	err := postJSON(ctx, endpoint, map[string]string{
+		"chat_id":    t.chatID,
+		"text":       strings.Join(lines, "\n"),
+		"parse_mode": "HTML",
+	})
+	// The URL holds the token, which must not end up in the warnings.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
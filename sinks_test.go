@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/stretchr/testify/assert"
+)
+
+// sinkPayload sends the match to the sink built for the URL of a test server, returning the
+// payload the server received.
+func sinkPayload(t *testing.T, newSink func(url string) sink, match qlp.Match) map[string]any {
+	t.Helper()
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+	assert.NoError(t, newSink(server.URL).send(context.Background(), 1, match))
+	return payload
+}
+
+// markupMatch is a match whose names hold chat markup.
+var markupMatch = qlp.Match{
+	MapName:    "tom&jerry",
+	Players:    []string{"<!channel>", "@everyone"},
+	Kills:      map[string]int{"<!channel>": 2, "@everyone": 1},
+	TotalKills: 3,
+	Server:     qlp.Server{Hostname: "Tom & Jerry's <server>"},
+}
+
+func TestSlackSinkEscapes(t *testing.T) {
+	payload := sinkPayload(t, func(url string) sink { return slackSink{url: url} }, markupMatch)
+	assert.Equal(t, "game_1 finished on tom&amp;jerry", payload["text"])
+	blocks := payload["blocks"].([]any)
+	fields := fmt.Sprint(blocks[1])
+	assert.NotContains(t, fields, "<!channel>")
+	assert.Contains(t, fields, "&lt;!channel&gt; (2)")
+
+	// plain_text is not read as markup, so it is left unescaped
+	header := blocks[0].(map[string]any)["text"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "plain_text", "text": "game_1 finished on tom&jerry"}, header)
+	hostname := blocks[2].(map[string]any)["elements"].([]any)[0]
+	assert.Equal(t, map[string]any{"type": "plain_text", "text": "Tom & Jerry's <server>"}, hostname)
+}
+
+func TestDiscordSinkMentions(t *testing.T) {
+	payload := sinkPayload(t, func(url string) sink { return discordSink{url: url} }, markupMatch)
+	assert.Equal(t, map[string]any{"parse": []any{}}, payload["allowed_mentions"])
+}
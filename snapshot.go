@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// snapshot records which matches some logs held at a point in time.
+type snapshot struct {
+	Files   []string        `json:"files"`
+	Matches []snapshotMatch `json:"matches"`
+}
+
+// snapshotMatch identifies a match of a snapshot.
+type snapshotMatch struct {
+	MatchHash  string `json:"match_hash"`
+	MapName    string `json:"map_name,omitempty"`
+	TotalKills int    `json:"total_kills"`
+}
+
+// snapshotCommand returns the "snapshot" subcommand, which saves snapshots of logs and
+// compares them with earlier ones.
+func snapshotCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "snapshot",
+		Usage:     "Saves which matches logs hold, or compares them with a previous snapshot.",
+		ArgsUsage: "<file...>",
+		Description: "With --compare, prints which matches are new and which were edited or removed since the " +
+			"snapshot was saved. With --save, saves a snapshot of the logs; both may be given at once.",
+		Flags: []cli.Flag{
+			&cli.PathFlag{
+				Name:  "save",
+				Usage: "save the snapshot to `FILE`",
+			},
+			&cli.PathFlag{
+				Name:  "compare",
+				Usage: "compare the logs with the snapshot saved in `FILE`",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 || c.Path("save") == "" && c.Path("compare") == "" {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			current := snapshot{Files: c.Args().Slice(), Matches: []snapshotMatch{}}
+			config := parseConfig{jobs: 1}
+			for _, filePath := range c.Args().Slice() {
+				err := parseFile(filePath, config, func(match qlp.Match) error {
+					current.Matches = append(current.Matches, snapshotMatch{
+						MatchHash:  match.MatchHash,
+						MapName:    match.MapName,
+						TotalKills: match.TotalKills,
+					})
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if filePath := c.Path("compare"); filePath != "" {
+				previous, err := loadSnapshot(filePath)
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to load snapshot: %s", err), exitOpen)
+				}
+				compareSnapshots(previous, current).print(c.App.Writer)
+			}
+
+			if filePath := c.Path("save"); filePath != "" {
+				snapshotJSON, err := json.MarshalIndent(current, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(filePath, snapshotJSON, 0o644); err != nil {
+					return cli.Exit(fmt.Sprintf("Failed to save snapshot: %s", err), exitWrite)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// loadSnapshot reads a snapshot saved by the "snapshot" subcommand.
+func loadSnapshot(filePath string) (snapshot, error) {
+	var s snapshot
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("invalid snapshot %s: %w", filePath, err)
+	}
+	return s, nil
+}
+
+// snapshotDelta is the difference between two snapshots. Matches are identified by their
+// 1-indexed position in the snapshot they belong to.
+type snapshotDelta struct {
+	unchanged int
+	// added are the matches of the current snapshot missing from the previous one, after its
+	// last match found in both. Those before are the edited versions of edited matches.
+	added []int
+	// edited are the matches of the previous snapshot missing from the current one while
+	// later matches are still there, which means the log was changed after the fact.
+	edited []int
+	// removed are the matches of the previous snapshot missing from the current one, with no
+	// later match left, as happens when logs are rotated or truncated.
+	removed []int
+}
+
+// compareSnapshots computes what changed from previous to current.
+func compareSnapshots(previous, current snapshot) snapshotDelta {
+	var delta snapshotDelta
+
+	previousHashes := make(map[string]bool, len(previous.Matches))
+	for _, match := range previous.Matches {
+		previousHashes[match.MatchHash] = true
+	}
+	lastKept := -1
+	currentHashes := make(map[string]bool, len(current.Matches))
+	for i, match := range current.Matches {
+		currentHashes[match.MatchHash] = true
+		if previousHashes[match.MatchHash] {
+			lastKept = i
+		}
+	}
+	for i := lastKept + 1; i < len(current.Matches); i++ {
+		delta.added = append(delta.added, i+1)
+	}
+
+	lastKept = -1
+	for i, match := range previous.Matches {
+		if currentHashes[match.MatchHash] {
+			delta.unchanged++
+			lastKept = i
+		}
+	}
+	for i, match := range previous.Matches {
+		switch {
+		case currentHashes[match.MatchHash]:
+		case i < lastKept:
+			delta.edited = append(delta.edited, i+1)
+		default:
+			delta.removed = append(delta.removed, i+1)
+		}
+	}
+	return delta
+}
+
+// print writes a concise description of the delta to w.
+func (d snapshotDelta) print(w io.Writer) {
+	if len(d.added) == 0 && len(d.edited) == 0 && len(d.removed) == 0 {
+		fmt.Fprintf(w, "No changes: %d matches\n", d.unchanged)
+		return
+	}
+
+	fmt.Fprintf(w, "%d unchanged matches\n", d.unchanged)
+	for _, change := range []struct {
+		label   string
+		matches []int
+	}{
+		{"new", d.added},
+		{"edited since the snapshot (previous numbering)", d.edited},
+		{"removed (previous numbering)", d.removed},
+	} {
+		if len(change.matches) == 0 {
+			continue
+		}
+		names := make([]string, len(change.matches))
+		for i, number := range change.matches {
+			names[i] = fmt.Sprintf("game_%d", number)
+		}
+		fmt.Fprintf(w, "%d %s: %s\n", len(change.matches), change.label, strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// checkSplitOutput checks the flags --split-output is used with. Its errors are ready to be
+// returned from a cli.ActionFunc.
+func checkSplitOutput(c *cli.Context) error {
+	switch {
+	case c.Path("out-dir") == "":
+		return cli.Exit("--split-output requires --out-dir", exitUsage)
+	case c.Path("output") != "":
+		return cli.Exit("Only one of --split-output and --output may be given", exitUsage)
+	case c.String("format") != "json":
+		return cli.Exit("--split-output only writes JSON", exitUsage)
+	case c.Bool("event-stats"):
+		return cli.Exit("Only one of --split-output and --event-stats may be given", exitUsage)
+	case c.Bool("gzip") && c.Bool("zstd"):
+		return cli.Exit("Only one of --gzip and --zstd may be given", exitUsage)
+	}
+	return nil
+}
+
+// openSplitOutput returns the encoder of --split-output, after checking the flags it is used
+// with. Its errors are ready to be returned from a cli.ActionFunc.
+func openSplitOutput(c *cli.Context) (*splitEncoder, error) {
+	if err := checkSplitOutput(c); err != nil {
+		return nil, err
+	}
+
+	encoder, err := newSplitEncoder(c.Path("out-dir"), c.Bool("gzip"), c.Bool("zstd"))
+	if err != nil {
+		return nil, cli.Exit(fmt.Sprintf("Failed to create output directory: %s", err), exitOpen)
+	}
+	encoder.rawFiles = c.String("include-raw") == "files"
+	return encoder, nil
+}
+
+// splitEncoder writes each match to its own JSON file in a directory, as requested with
+// --split-output, which suits static sites and archives better than a single document.
+type splitEncoder struct {
+	dir     string
+	gzipped bool
+	zstded  bool
+	// rawFiles writes the raw lines of each match, as asked for with --include-raw files, to
+	// a file of the same name as the match's but ending in .log instead of embedding them.
+	rawFiles bool
+	// written is the number of matches written so far, which numbers the files.
+	written int
+}
+
+// newSplitEncoder returns an encoder writing the matches to files in dir, which is created if
+// needed. The files are compressed with gzip or zstd if requested.
+func newSplitEncoder(dir string, gzipped, zstded bool) (*splitEncoder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &splitEncoder{dir: dir, gzipped: gzipped, zstded: zstded}, nil
+}
+
+// Encode writes the match to a new file, replacing any file of the same name.
+func (e *splitEncoder) Encode(match qlp.Match) error {
+	e.written++
+	name := matchFileName(e.written, match)
+	if e.rawFiles {
+		raw := match.Raw
+		match.Raw = nil
+		err := e.writeFile(strings.TrimSuffix(name, ".json")+".log", func(w io.Writer) error {
+			_, err := w.Write(raw)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return e.writeFile(name, func(w io.Writer) error { return writeFormatted(w, "json", match) })
+}
+
+// writeFile creates the file of the given name in the directory, compressed if requested, and
+// writes it with write.
+func (e *splitEncoder) writeFile(name string, write func(w io.Writer) error) error {
+	switch {
+	case e.gzipped:
+		name += ".gz"
+	case e.zstded:
+		name += ".zst"
+	}
+
+	file, err := os.Create(filepath.Join(e.dir, name))
+	if err != nil {
+		return err
+	}
+	out := &output{Writer: file, format: "json"}
+	out.push(file, file)
+	if err := out.bufferAndCompress(e.gzipped, e.zstded); err != nil {
+		out.Close()
+		return err
+	}
+	if err := write(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Close does nothing, as every file is closed once its match is written.
+func (e *splitEncoder) Close() error {
+	return nil
+}
+
+// matchFileName returns the name of the file of the match with the given number, such as
+// "game_0001_q3dm17_1m05s.json": the number, the map and when the match started, in time
+// since the server started, as that is the only time the logs record.
+func matchFileName(number int, match qlp.Match) string {
+	mapName := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, match.MapName)
+	if mapName == "" {
+		mapName = "unknown"
+	}
+	return fmt.Sprintf("game_%04d_%s_%dm%02ds.json", number, mapName, match.StartTime/60, match.StartTime%60)
+}
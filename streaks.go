@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+)
+
+// streaks is the output of the "streaks" report, keyed by player name.
+type streaks map[string]qlpstats.Streak
+
+// Table lists one player per row, sorted by name.
+func (s streaks) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"player", "kills", "match"}}
+	for _, player := range sortedKeys(s) {
+		t.Rows = append(t.Rows, []string{player, strconv.Itoa(s[player].Kills), strconv.Itoa(s[player].Match)})
+	}
+	return t
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+)
+
+// summary is the output of the "summary" report.
+type summary struct {
+	qlpstats.Summary
+	// WeaponNames maps the means of death of KillsByWeapon to their display names.
+	WeaponNames map[string]string `json:"weapon_names"`
+}
+
+// summaryHeader is the header of the tables of summaries.
+var summaryHeader = []string{"metric", "total", "mean", "min", "p25", "median", "p75", "p90", "max"}
+
+// Table lists one distribution per row, those of kills by weapon being named after the weapon.
+func (s summary) Table() qlpformat.Table {
+	return qlpformat.Table{Header: summaryHeader, Rows: s.rows()}
+}
+
+// rows returns the rows of the summary's table.
+func (s summary) rows() [][]string {
+	rows := [][]string{
+		distributionRow("kills_per_match", s.KillsPerMatch),
+		distributionRow("match_duration", s.MatchDuration),
+	}
+	for _, means := range sortedKeys(s.KillsByWeapon) {
+		rows = append(rows, distributionRow(s.WeaponNames[means], s.KillsByWeapon[means]))
+	}
+	return rows
+}
+
+// distributionRow returns the row of the distribution of metric in a summary's table.
+func distributionRow(metric string, d qlpstats.Distribution) []string {
+	row := []string{metric, strconv.Itoa(d.Total), formatFloat(d.Mean)}
+	for _, value := range [...]int{d.Min, d.P25, d.Median, d.P75, d.P90, d.Max} {
+		row = append(row, strconv.Itoa(value))
+	}
+	return row
+}
+
+// serverSummaries aggregates the matches of each server as well as all of them together.
+type serverSummaries struct {
+	// Servers is keyed by the hostname of the servers, matches of servers without one being
+	// grouped under unknownServer.
+	Servers map[string]summary `json:"servers"`
+	Global  summary            `json:"global"`
+}
+
+// globalServer names the global summary in the tables of server summaries.
+const globalServer = "(all)"
+
+// Table lists the rows of every server's summary, each prefixed with the server, followed by
+// those of the global summary.
+func (s serverSummaries) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: append([]string{"server"}, summaryHeader...)}
+	for _, hostname := range sortedKeys(s.Servers) {
+		for _, row := range s.Servers[hostname].rows() {
+			t.Rows = append(t.Rows, append([]string{hostname}, row...))
+		}
+	}
+	for _, row := range s.Global.rows() {
+		t.Rows = append(t.Rows, append([]string{globalServer}, row...))
+	}
+	return t
+}
+
+// unknownServer is the key for matches whose server has no hostname.
+const unknownServer = "(unknown)"
+
+// buildServerSummaries aggregates the matches per server, as told by their hostname, and
+// globally.
+func buildServerSummaries(matches qlp.Matches, loc *locale) serverSummaries {
+	byServer := make(map[string]qlp.Matches)
+	for _, match := range matches {
+		hostname := match.Server.Hostname
+		if hostname == "" {
+			hostname = unknownServer
+		}
+		byServer[hostname] = append(byServer[hostname], match)
+	}
+
+	summaries := serverSummaries{
+		Servers: make(map[string]summary, len(byServer)),
+		Global:  buildSummary(matches, loc),
+	}
+	for hostname, serverMatches := range byServer {
+		summaries.Servers[hostname] = buildSummary(serverMatches, loc)
+	}
+	return summaries
+}
+
+// buildSummary aggregates the matches, with weapon names written according to loc.
+func buildSummary(matches qlp.Matches, loc *locale) summary {
+	s := summary{
+		Summary:     qlpstats.Summarize(matches),
+		WeaponNames: make(map[string]string),
+	}
+	for means := range s.KillsByWeapon {
+		s.WeaponNames[means] = loc.meansName(means)
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// timelineInterval is the length, in seconds, of the intervals of the weapon timelines.
+const timelineInterval = 60
+
+// timelines is the output of the "timeline" report.
+type timelines struct {
+	Matches []matchTimeline `json:"matches"`
+	// WeaponNames maps the means of death of the buckets to their display names.
+	WeaponNames map[string]string `json:"weapon_names"`
+}
+
+// matchTimeline holds the kills of a match by means of death over time.
+type matchTimeline struct {
+	Match   int              `json:"match"` // 1-indexed, as in the "game_N" keys
+	MapName string           `json:"map_name,omitempty"`
+	Buckets []timelineBucket `json:"buckets"`
+}
+
+// timelineBucket holds the kills of an interval of a match.
+type timelineBucket struct {
+	// Start and End are the server times, in seconds, the interval starts at and ends right
+	// before.
+	Start int            `json:"start"`
+	End   int            `json:"end"`
+	Kills map[string]int `json:"kills"`
+}
+
+// Table lists one means of death of a bucket per row, sorted by match, time and means.
+func (ts timelines) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"match", "map", "start", "end", "weapon", "kills"}}
+	for _, timeline := range ts.Matches {
+		for _, bucket := range timeline.Buckets {
+			for _, means := range sortedKeys(bucket.Kills) {
+				t.Rows = append(t.Rows, []string{
+					strconv.Itoa(timeline.Match), timeline.MapName, strconv.Itoa(bucket.Start),
+					strconv.Itoa(bucket.End), ts.WeaponNames[means], strconv.Itoa(bucket.Kills[means]),
+				})
+			}
+		}
+	}
+	return t
+}
+
+// buildTimelines counts the kills of each match by means of death in intervals of
+// timelineInterval seconds of the server clock, which restarts with every map. Intervals from
+// the first to the last kill are all present, even without kills, so they chart evenly. It
+// needs the kill feed of the matches.
+func buildTimelines(matches qlp.Matches, loc *locale) timelines {
+	ts := timelines{Matches: []matchTimeline{}, WeaponNames: make(map[string]string)}
+	for i, match := range matches {
+		timeline := matchTimeline{Match: i + 1, MapName: match.MapName, Buckets: []timelineBucket{}}
+		if len(match.KillFeed) > 0 {
+			first := match.KillFeed[0].Time / timelineInterval
+			for _, kill := range match.KillFeed {
+				index := kill.Time/timelineInterval - first
+				for len(timeline.Buckets) <= index {
+					start := (first + len(timeline.Buckets)) * timelineInterval
+					timeline.Buckets = append(timeline.Buckets, timelineBucket{
+						Start: start,
+						End:   start + timelineInterval,
+						Kills: make(map[string]int),
+					})
+				}
+				// timestamps going backwards are counted in the first interval
+				timeline.Buckets[max(index, 0)].Kills[kill.Means]++
+				ts.WeaponNames[kill.Means] = loc.meansName(kill.Means)
+			}
+		}
+		ts.Matches = append(ts.Matches, timeline)
+	}
+	return ts
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+)
+
+// trends is the output of the "trends" report, keyed by player name.
+type trends map[string]*qlpstats.Trend
+
+// Table lists one game per row, sorted by player and then by match.
+func (ts trends) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"player", "match", "kills", "deaths", "kd", "moving_average"}}
+	for _, player := range sortedKeys(ts) {
+		for _, game := range ts[player].Games {
+			t.Rows = append(t.Rows, []string{
+				player, strconv.Itoa(game.Match), strconv.Itoa(game.Kills), strconv.Itoa(game.Deaths),
+				formatFloat(game.KD), formatFloat(game.MovingAverage),
+			})
+		}
+	}
+	return t
+}
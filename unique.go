@@ -0,0 +1,21 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp/qlpformat"
+	"github.com/agstrc/qlp/qlp/qlpstats"
+)
+
+// uniquePlayers is the output of the "unique-players" report.
+type uniquePlayers qlpstats.UniquePlayers
+
+// Table lists one person per row, with their names.
+func (u uniquePlayers) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"person", "names"}}
+	for i, names := range u.People {
+		t.Rows = append(t.Rows, []string{strconv.Itoa(i + 1), strings.Join(names, ", ")})
+	}
+	return t
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/urfave/cli/v2"
+)
+
+// validateCommand returns the "validate" subcommand, which checks that logs parse cleanly.
+func validateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "Checks that log files parse without warnings, such as malformed lines or unknown events.",
+		ArgsUsage: "<file...>",
+		Description: "Parses each file as the default command would, with --strict, and prints its warnings along " +
+			"with how many matches it has. Exits with status 3 if any file has warnings.",
+		Flags: append(inputFlags(), fileFlags()...),
+		Action: func(c *cli.Context) error {
+			files, err := inputFiles(c)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				cli.ShowSubcommandHelpAndExit(c, exitUsage)
+			}
+
+			config, _, err := newParseConfig(c, qlp.Options{Strict: true})
+			if err != nil {
+				return err
+			}
+
+			invalid := 0
+			for _, filePath := range files {
+				warnings := newWarningReport(filePath)
+				config.opts.OnWarning = warnings.add
+
+				matches := 0
+				err := parseFile(filePath, config, func(qlp.Match) error {
+					matches++
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+
+				warnings.print(c.App.Writer)
+				count := 0
+				for _, lines := range warnings.lines {
+					count += len(lines)
+				}
+				if count > 0 {
+					invalid++
+				}
+				fmt.Fprintf(c.App.Writer, "%s: %d matches, %d warnings\n", filePath, matches, count)
+			}
+
+			if invalid > 0 {
+				return cli.Exit(fmt.Sprintf("%d of %d files have warnings", invalid, len(files)), exitStrict)
+			}
+			return nil
+		},
+	}
+}
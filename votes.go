@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// voteInMatch is a vote called in a single match.
+type voteInMatch struct {
+	Match int    `json:"match"` // 1-indexed, as in the "game_N" keys
+	Map   string `json:"map"`
+	qlp.Vote
+}
+
+// voteReport is the output of the "votes" report.
+type voteReport []voteInMatch
+
+// Table lists one vote per row, in the order they were called.
+func (vr voteReport) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"match", "map", "time", "caller", "vote", "yes", "no", "result"}}
+	for _, v := range vr {
+		t.Rows = append(t.Rows, []string{
+			strconv.Itoa(v.Match), v.Map, strconv.Itoa(v.Time), v.Caller, v.Vote.Vote,
+			strconv.Itoa(v.Yes), strconv.Itoa(v.No), v.Result,
+		})
+	}
+	return t
+}
+
+// buildVoteReport gathers the votes called in every match. It needs the votes of the matches.
+func buildVoteReport(matches qlp.Matches) voteReport {
+	report := voteReport{}
+	for i, match := range matches {
+		for _, vote := range match.Votes {
+			report = append(report, voteInMatch{Match: i + 1, Map: match.MapName, Vote: vote})
+		}
+	}
+	return report
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/agstrc/qlp/qlp"
+)
+
+// maxListedLines is how many line numbers are listed for each kind of warning.
+const maxListedLines = 20
+
+// warningReport collects the warnings of a file, so that they can be printed grouped by
+// reason instead of one line each.
+type warningReport struct {
+	file    string
+	reasons []string
+	lines   map[string][]int
+
+	// unknownEvents counts the warnings about events of unknown types.
+	unknownEvents int
+	// corruptRegions counts the corrupted regions skipped with Options.Resync.
+	corruptRegions int
+	// malformedLines counts the malformed lines skipped with Options.Lenient.
+	malformedLines int
+}
+
+// newWarningReport creates and returns an empty warningReport for the given file.
+func newWarningReport(file string) *warningReport {
+	return &warningReport{file: file, lines: make(map[string][]int)}
+}
+
+// add records a warning. It is meant to be used as qlp.Options.OnWarning.
+func (r *warningReport) add(warning qlp.ParseWarning) {
+	reason := warning.Reason.Error()
+	if _, ok := r.lines[reason]; !ok {
+		r.reasons = append(r.reasons, reason)
+	}
+	r.lines[reason] = append(r.lines[reason], warning.Line)
+
+	var unknownEvent qlp.UnknownEventError
+	if errors.As(warning.Reason, &unknownEvent) {
+		r.unknownEvents++
+	}
+	var corruptRegion qlp.CorruptRegionError
+	if errors.As(warning.Reason, &corruptRegion) {
+		r.corruptRegions++
+	}
+	if errors.Is(warning.Reason, qlp.ErrMalformedLine) {
+		r.malformedLines++
+	}
+}
+
+// print writes one line per reason to w, listing the numbers of the lines it applies to.
+func (r *warningReport) print(w io.Writer) {
+	for _, reason := range r.reasons {
+		lines := r.lines[reason]
+
+		listed := make([]string, 0, min(len(lines), maxListedLines))
+		for _, line := range lines[:cap(listed)] {
+			listed = append(listed, strconv.Itoa(line))
+		}
+		if len(lines) > maxListedLines {
+			listed = append(listed, "…")
+		}
+
+		fmt.Fprintf(w, "Warning: %s: %s (%d lines): %s\n", r.file, reason, len(lines), strings.Join(listed, ", "))
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/agstrc/qlp/qlp"
+	"github.com/agstrc/qlp/qlp/qlpformat"
+)
+
+// weaponTop is the number of players listed for each weapon.
+const weaponTop = 10
+
+// weaponStanding is the line of a player in the leaderboard of a weapon.
+type weaponStanding struct {
+	Rank   int    `json:"rank"`
+	Player string `json:"player"`
+	Kills  int    `json:"kills"`
+}
+
+// weaponLeaderboard lists the top players with a weapon.
+type weaponLeaderboard struct {
+	Name      string           `json:"name"` // display name of the weapon
+	Standings []weaponStanding `json:"standings"`
+}
+
+// weaponLeaderboards is the output of the "weapons" report, keyed by means of death.
+type weaponLeaderboards map[string]*weaponLeaderboard
+
+// Table lists one player per row, sorted by weapon and then by rank.
+func (ws weaponLeaderboards) Table() qlpformat.Table {
+	t := qlpformat.Table{Header: []string{"weapon", "rank", "player", "kills"}}
+	for _, means := range sortedKeys(ws) {
+		for _, s := range ws[means].Standings {
+			t.Rows = append(t.Rows, []string{ws[means].Name, strconv.Itoa(s.Rank), s.Player, strconv.Itoa(s.Kills)})
+		}
+	}
+	return t
+}
+
+// buildWeaponLeaderboards ranks, for each means of death, the weaponTop players with the most
+// kills with it over every match, ties being broken by name. Players with the same kills share
+// a rank. Suicides and deaths by the world are not kills. It needs the kill feed of the matches.
+func buildWeaponLeaderboards(matches qlp.Matches, loc *locale) weaponLeaderboards {
+	kills := make(map[string]map[string]int)
+	for _, match := range matches {
+		for _, kill := range match.KillFeed {
+			if kill.Killer == "<world>" || kill.Killer == kill.Victim {
+				continue
+			}
+			if kills[kill.Means] == nil {
+				kills[kill.Means] = make(map[string]int)
+			}
+			kills[kill.Means][kill.Killer]++
+		}
+	}
+
+	leaderboards := make(weaponLeaderboards, len(kills))
+	for means, byPlayer := range kills {
+		standings := make([]weaponStanding, 0, len(byPlayer))
+		for player, count := range byPlayer {
+			standings = append(standings, weaponStanding{Player: player, Kills: count})
+		}
+		slices.SortFunc(standings, func(a, b weaponStanding) int {
+			return cmp.Or(cmp.Compare(b.Kills, a.Kills), cmp.Compare(a.Player, b.Player))
+		})
+
+		for i := range standings {
+			if i > 0 && standings[i].Kills == standings[i-1].Kills {
+				standings[i].Rank = standings[i-1].Rank
+			} else {
+				standings[i].Rank = i + 1
+			}
+		}
+		leaderboards[means] = &weaponLeaderboard{
+			Name:      loc.meansName(means),
+			Standings: standings[:min(weaponTop, len(standings))],
+		}
+	}
+	return leaderboards
+}